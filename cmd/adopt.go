@@ -0,0 +1,180 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/residwi/sshman/internal/provider"
+	"github.com/residwi/sshman/internal/ssh"
+	"github.com/residwi/sshman/utils"
+	"github.com/spf13/cobra"
+)
+
+var adoptCmdFlags struct {
+	yes      bool
+	provider string
+	purpose  string
+	expires  string
+	keyType  string
+}
+
+var adoptCmd = &cobra.Command{
+	Use:   "adopt [key-name...]",
+	Short: "Adopt SSH keys that were not created by sshman",
+	Long: `Record metadata for private keys that sshman does not manage yet, so rotate, expiry
+tracking and delete cleanup work for them. The provider and purpose are guessed from
+the Host entries that use each key and confirmed interactively unless --yes is given.`,
+	Example: `sshman adopt
+sshman adopt id_rsa --provider github --purpose personal --yes`,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if adoptCmdFlags.provider != "" && !slices.Contains(provider.GetSupportedProviders(), adoptCmdFlags.provider) {
+			return fmt.Errorf("unsupported provider: %s. Supported providers are: %v", adoptCmdFlags.provider, provider.GetSupportedProviders())
+		}
+
+		if adoptCmdFlags.keyType != "" && !slices.Contains(adoptKeyTypes, adoptCmdFlags.keyType) {
+			return fmt.Errorf("unsupported SSH key type: %s. Supported types are: %v", adoptCmdFlags.keyType, adoptKeyTypes)
+		}
+
+		if adoptCmdFlags.expires != "" {
+			if _, err := utils.ParseDuration(adoptCmdFlags.expires); err != nil {
+				return fmt.Errorf("invalid --expires value: %s. Use a duration such as 90d or 12w", adoptCmdFlags.expires)
+			}
+		}
+
+		return nil
+	},
+	RunE:              adoptSSHKeys,
+	ValidArgsFunction: completeKeyNamesRepeated,
+}
+
+// adoptKeyTypes are the key types an adopted key can be, which includes types sshman does not generate
+var adoptKeyTypes = []string{"ed25519", "rsa", "ecdsa", "dsa", "ed25519-sk", "ecdsa-sk"}
+
+func init() {
+	rootCmd.AddCommand(adoptCmd)
+
+	adoptCmd.Flags().BoolVarP(&adoptCmdFlags.yes, "yes", "y", false, "Adopt without asking for confirmation")
+	adoptCmd.Flags().StringVarP(&adoptCmdFlags.provider, "provider", "", "", "Provider of the keys, overriding the guess")
+	adoptCmd.Flags().StringVarP(&adoptCmdFlags.purpose, "purpose", "", "", "Purpose of the keys, overriding the guess")
+	adoptCmd.Flags().StringVarP(&adoptCmdFlags.expires, "expires", "", "", "Key lifetime counted from the key's creation, e.g. 365d (keys do not expire by default)")
+	adoptCmd.Flags().StringVarP(&adoptCmdFlags.keyType, "type", "t", "", "Type of the keys whose type cannot be read from the key files, e.g. rsa")
+	adoptCmd.RegisterFlagCompletionFunc("provider", completeValues(provider.GetSupportedProviders()...))
+	adoptCmd.RegisterFlagCompletionFunc("type", completeValues(adoptKeyTypes...))
+}
+
+func adoptSSHKeys(cmd *cobra.Command, args []string) error {
+	sshPath, _ := cmd.Flags().GetString("ssh-path")
+
+	privateKeys, err := findPrivateKeys(sshPath)
+	if err != nil {
+		return fmt.Errorf("failed to find SSH keys: %w", err)
+	}
+
+	metadata, err := ssh.LoadMetadata(sshPath)
+	if err != nil {
+		return err
+	}
+
+	entries, err := ssh.ReadConfig(sshPath)
+	if err != nil {
+		return err
+	}
+
+	for _, keyName := range args {
+		if utils.IsFileNotExist(filepath.Join(sshPath, keyName)) {
+			return fmt.Errorf("SSH key [%s] does not exist", keyName)
+		}
+		if _, managed := metadata.Get(keyName); managed {
+			utils.PrintWarning("SSH key [" + keyName + "] is already managed by sshman")
+		}
+	}
+
+	headers := []string{"NAME", "TYPE", "PROVIDER", "PURPOSE", "HOSTS"}
+	var rows [][]string
+
+	for _, privateKey := range privateKeys {
+		keyName, err := filepath.Rel(sshPath, privateKey)
+		if err != nil {
+			return fmt.Errorf("failed to resolve %s: %w", privateKey, err)
+		}
+
+		if _, managed := metadata.Get(keyName); managed {
+			continue
+		}
+		if len(args) > 0 && !slices.Contains(args, keyName) {
+			continue
+		}
+
+		guess := ssh.GuessKeyMetadata(privateKey, entries, provider.FindProviderByHostname)
+		keyMetadata := guess.Metadata
+
+		// the type cannot be read from a key encrypted in some formats without its .pub file
+		if keyMetadata.Type == "" {
+			if adoptCmdFlags.keyType == "" {
+				utils.PrintWarning("Skipping [" + keyName + "]: its type cannot be read from the key files, adopt it with --type")
+				continue
+			}
+			keyMetadata.Type = adoptCmdFlags.keyType
+		}
+
+		if adoptCmdFlags.provider != "" {
+			keyMetadata.Provider = adoptCmdFlags.provider
+		}
+		if keyMetadata.Provider == "" {
+			keyMetadata.Provider = "generic"
+		}
+		if adoptCmdFlags.purpose != "" {
+			keyMetadata.Purpose = adoptCmdFlags.purpose
+		}
+
+		if adoptCmdFlags.expires != "" {
+			expiresIn, _ := utils.ParseDuration(adoptCmdFlags.expires)
+			keyMetadata.ExpiresAt = keyMetadata.CreatedAt.Add(expiresIn)
+		}
+
+		if !adoptCmdFlags.yes {
+			question := fmt.Sprintf("Adopt [%s] as %s key", keyName, keyMetadata.Provider)
+			if keyMetadata.Purpose != "" {
+				question += " for " + keyMetadata.Purpose
+			}
+			if len(guess.Hosts) > 0 {
+				question += " (used by " + strings.Join(guess.Hosts, ", ") + ")"
+			}
+
+			confirmed, err := utils.Confirm(question+"?", true)
+			if err != nil {
+				return err
+			}
+			if !confirmed {
+				continue
+			}
+		}
+
+		metadata.Set(keyName, keyMetadata)
+		rows = append(rows, []string{keyName, keyMetadata.Type, keyMetadata.Provider, valueOrDash(keyMetadata.Purpose), valueOrDash(strings.Join(guess.Hosts, ", "))})
+	}
+
+	if len(rows) == 0 {
+		utils.PrintSuccess("No SSH keys to adopt")
+		return nil
+	}
+
+	if err := metadata.Save(sshPath); err != nil {
+		return err
+	}
+
+	utils.PrintTable(os.Stdout, headers, rows)
+	utils.PrintSuccess(fmt.Sprintf("Adopted %d SSH keys", len(rows)))
+
+	return nil
+}
+
+func valueOrDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
@@ -1,20 +1,41 @@
 package cmd
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"syscall"
+	"time"
 
 	"github.com/residwi/sshman/internal/interfaces"
 	"github.com/residwi/sshman/internal/ssh"
 	"github.com/residwi/sshman/utils"
 	"github.com/spf13/cobra"
+	gossh "golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
 )
 
+var agentServeCmdFlags struct {
+	socket         string
+	lifetime       string
+	confirmCommand string
+}
+
 var agentCmd = &cobra.Command{
 	Use:   "agent",
 	Short: "Manage SSH agent",
-	Long:  `Add, remove, list, or clear SSH keys from agent`,
+	Long:  `Add, remove, list, or clear SSH keys from agent, or start an agent when none is running`,
 }
 
+// errAgentNotRunning points to agent start, as a missing agent is a setup problem
+var errAgentNotRunning = errors.New(`agent is not running, start one with: eval "$(sshman agent start)"`)
+
 var agentAddCmd = &cobra.Command{
 	Use:   "add [key-name]",
 	Short: "Add SSH key to agent",
@@ -22,7 +43,8 @@ var agentAddCmd = &cobra.Command{
 	Args:  cobra.ExactArgs(1),
 	Example: `sshman agent add id_ed25519_work
 sshman agent add id_rsa_personal`,
-	RunE: addKeyToAgent,
+	RunE:              addKeyToAgent,
+	ValidArgsFunction: completeKeyNames,
 }
 
 var agentRemoveCmd = &cobra.Command{
@@ -32,7 +54,8 @@ var agentRemoveCmd = &cobra.Command{
 	Args:  cobra.ExactArgs(1),
 	Example: `sshman agent remove id_ed25519_work
 sshman agent remove id_rsa_personal`,
-	RunE: removeKeyFromAgent,
+	RunE:              removeKeyFromAgent,
+	ValidArgsFunction: completeLoadedKeyNames,
 }
 
 var agentListCmd = &cobra.Command{
@@ -51,9 +74,58 @@ var agentClearCmd = &cobra.Command{
 	RunE:  clearAgentKeys,
 }
 
+var agentStartCmd = &cobra.Command{
+	Use:   "start",
+	Short: "Start an ssh-agent and print its exports",
+	Long: `Start an ssh-agent listening on a fixed socket in $XDG_RUNTIME_DIR/sshman and print
+the shell exports pointing at it. When the agent is already running, it is reused, so
+every shell can run this to share one agent.`,
+	Args: cobra.NoArgs,
+	Example: `eval "$(sshman agent start)"
+echo 'eval "$(sshman agent start)"' >> ~/.bashrc`,
+	Annotations: map[string]string{skipExpiryWarningAnnotation: "true"},
+	RunE:        startAgent,
+}
+
+var agentStopCmd = &cobra.Command{
+	Use:   "stop",
+	Short: "Stop the ssh-agent started by sshman",
+	Args:  cobra.NoArgs,
+	RunE:  stopAgent,
+}
+
+var agentStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show which agent is running and its socket",
+	Long: `Show the agent SSH_AUTH_SOCK points at, its socket and loaded keys, and whether the
+agent started by sshman is running. Exits with status 1 when no agent is running.`,
+	Args: cobra.NoArgs,
+	RunE: showAgentStatus,
+}
+
+var agentServeCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Run a built-in ssh-agent in the foreground",
+	Long: `Run an ssh-agent implemented by sshman, for systems without the ssh-agent binary. It
+listens on the same socket as 'sshman agent start', so 'agent stop' and 'agent status' work
+with it, and logs every signing request with the key and the PID of the client.
+
+Keys added with confirmation (ssh-add -c) are only used when the confirm command, by default
+$SSH_ASKPASS, exits with status 0. Without a confirm command, such keys cannot sign.`,
+	Args: cobra.NoArgs,
+	Example: `sshman agent serve &
+sshman agent serve --lifetime 8h --confirm-command ssh-askpass`,
+	Annotations: map[string]string{skipExpiryWarningAnnotation: "true"},
+	RunE:        serveAgent,
+}
+
 func init() {
 	rootCmd.AddCommand(agentCmd)
-	agentCmd.AddCommand(agentAddCmd, agentRemoveCmd, agentListCmd, agentClearCmd)
+	agentCmd.AddCommand(agentAddCmd, agentRemoveCmd, agentListCmd, agentClearCmd, agentStartCmd, agentStopCmd, agentStatusCmd, agentServeCmd)
+
+	agentServeCmd.Flags().StringVarP(&agentServeCmdFlags.socket, "socket", "", "", "Socket to listen on (default: the socket of sshman agent start)")
+	agentServeCmd.Flags().StringVarP(&agentServeCmdFlags.lifetime, "lifetime", "t", "", "Lifetime of keys added without one, e.g. 8h (default: until removed)")
+	agentServeCmd.Flags().StringVarP(&agentServeCmdFlags.confirmCommand, "confirm-command", "", os.Getenv("SSH_ASKPASS"), "Command asked to confirm the use of keys added with ssh-add -c")
 }
 
 func addKeyToAgent(cmd *cobra.Command, args []string) error {
@@ -63,7 +135,7 @@ func addKeyToAgent(cmd *cobra.Command, args []string) error {
 	executor := &interfaces.DefaultCommandExecutor{}
 	agentManager := ssh.NewAgentManager(executor)
 	if !agentManager.IsAgentRunning() {
-		return fmt.Errorf("agent is not running")
+		return errAgentNotRunning
 	}
 
 	if err := agentManager.AddToAgent(sshPath, keyName); err != nil {
@@ -71,6 +143,17 @@ func addKeyToAgent(cmd *cobra.Command, args []string) error {
 	}
 
 	utils.PrintSuccess("SSH key [" + keyName + "] added to agent")
+
+	// ssh-add loads the certificate next to the key along with it
+	if cert := ssh.FindCert(filepath.Join(sshPath, keyName)); cert != nil {
+		now := time.Now()
+		if cert.IsExpired(now) {
+			utils.PrintWarning("Certificate [" + filepath.Base(cert.Path) + "] " + formatCertValidity(cert, now) + ", sign it again with: sshman cert sign " + keyName)
+		} else {
+			utils.PrintSuccess("Certificate [" + filepath.Base(cert.Path) + "] added to agent, " + formatCertValidity(cert, now))
+		}
+	}
+
 	return nil
 }
 
@@ -82,7 +165,7 @@ func removeKeyFromAgent(cmd *cobra.Command, args []string) error {
 	agentManager := ssh.NewAgentManager(executor)
 
 	if !agentManager.IsAgentRunning() {
-		return fmt.Errorf("agent is not running")
+		return errAgentNotRunning
 	}
 
 	if err := agentManager.RemoveFromAgent(sshPath, keyName); err != nil {
@@ -102,6 +185,10 @@ func listAgentKeys(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	if rootCmdFlags.json {
+		return printJSON(keys)
+	}
+
 	if len(keys) == 0 {
 		utils.PrintSuccess("No SSH keys loaded in agent")
 		return nil
@@ -121,7 +208,7 @@ func clearAgentKeys(cmd *cobra.Command, args []string) error {
 	agentManager := ssh.NewAgentManager(executor)
 
 	if !agentManager.IsAgentRunning() {
-		return fmt.Errorf("agent is not running")
+		return errAgentNotRunning
 	}
 
 	if err := agentManager.ClearAgent(sshPath); err != nil {
@@ -130,3 +217,152 @@ func clearAgentKeys(cmd *cobra.Command, args []string) error {
 
 	return nil
 }
+
+func startAgent(cmd *cobra.Command, args []string) error {
+	socket := ssh.ManagedAgentSocket()
+
+	executor := &interfaces.DefaultCommandExecutor{}
+	pid, started, err := ssh.NewAgentManager(executor).StartAgent(socket)
+	if errors.Is(err, exec.ErrNotFound) {
+		return fmt.Errorf("%w, run the built-in agent instead with: sshman agent serve &", err)
+	}
+	if err != nil {
+		return err
+	}
+
+	// stdout is evaluated by the shell, so messages go to stderr
+	if started {
+		fmt.Fprintln(os.Stderr, "Agent started on "+utils.ReplaceHomeDirWithTilde(socket))
+	} else {
+		fmt.Fprintln(os.Stderr, "Agent already running on "+utils.ReplaceHomeDirWithTilde(socket))
+	}
+
+	fmt.Println("export SSH_AUTH_SOCK=" + utils.ShellQuote(socket))
+	if pid > 0 {
+		fmt.Println("export SSH_AGENT_PID=" + strconv.Itoa(pid))
+	}
+
+	return nil
+}
+
+func stopAgent(cmd *cobra.Command, args []string) error {
+	socket := ssh.ManagedAgentSocket()
+
+	executor := &interfaces.DefaultCommandExecutor{}
+	if err := ssh.NewAgentManager(executor).StopAgent(socket); err != nil {
+		return err
+	}
+
+	utils.PrintSuccess("Agent on " + utils.ReplaceHomeDirWithTilde(socket) + " stopped")
+
+	if os.Getenv("SSH_AUTH_SOCK") == socket {
+		utils.PrintWarning("SSH_AUTH_SOCK still points at the stopped agent, clear it with: unset SSH_AUTH_SOCK SSH_AGENT_PID")
+	}
+
+	return nil
+}
+
+func showAgentStatus(cmd *cobra.Command, args []string) error {
+	executor := &interfaces.DefaultCommandExecutor{}
+	agentManager := ssh.NewAgentManager(executor)
+
+	status := agentManager.Status()
+	keyCount := 0
+	if status.Running {
+		keys, err := agentManager.ListAgentKeys()
+		if err != nil {
+			return err
+		}
+		keyCount = len(keys)
+	}
+
+	managedSocket := ssh.ManagedAgentSocket()
+	managedRunning := ssh.AgentSocketAlive(managedSocket)
+
+	if rootCmdFlags.json {
+		if err := printJSON(struct {
+			ssh.AgentStatus
+			Keys           int    `json:"keys"`
+			ManagedSocket  string `json:"managed_socket"`
+			ManagedRunning bool   `json:"managed_running"`
+		}{status, keyCount, managedSocket, managedRunning}); err != nil {
+			return err
+		}
+	} else {
+		managed := "not running"
+		if managedRunning {
+			managed = "running on " + utils.ReplaceHomeDirWithTilde(managedSocket)
+		}
+
+		fmt.Println("Agent:        " + agentStatusText(status))
+		fmt.Println("Socket:       " + valueOrDash(utils.ReplaceHomeDirWithTilde(status.Socket)))
+		fmt.Println("Keys:         " + strconv.Itoa(keyCount) + " loaded")
+		fmt.Println("sshman agent: " + managed)
+
+		if !status.Running && managedRunning {
+			utils.PrintWarning(`The agent started by sshman is running, use it with: eval "$(sshman agent start)"`)
+		} else if !status.Running {
+			utils.PrintWarning(`Start an agent with: eval "$(sshman agent start)"`)
+		}
+	}
+
+	if !status.Running {
+		return errSilentExit
+	}
+	return nil
+}
+
+func serveAgent(cmd *cobra.Command, args []string) error {
+	socket := agentServeCmdFlags.socket
+	if socket == "" {
+		socket = ssh.ManagedAgentSocket()
+		if err := utils.EnsurePrivateDir(filepath.Dir(socket)); err != nil {
+			return fmt.Errorf("failed to prepare agent directory: %w", err)
+		}
+	}
+	socket = utils.ExpandTilde(socket)
+
+	var lifetime time.Duration
+	if agentServeCmdFlags.lifetime != "" {
+		parsed, err := utils.ParseDuration(agentServeCmdFlags.lifetime)
+		if err != nil || parsed < time.Second {
+			return fmt.Errorf("invalid lifetime: %s", agentServeCmdFlags.lifetime)
+		}
+		lifetime = parsed
+	}
+
+	server := ssh.NewAgentServer(log.New(os.Stderr, "sshman agent: ", log.LstdFlags))
+	server.Lifetime = lifetime
+	server.Confirm = confirmWithCommand(agentServeCmdFlags.confirmCommand)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	fmt.Fprintln(os.Stderr, "Agent listening on "+utils.ReplaceHomeDirWithTilde(socket)+", use it with: export SSH_AUTH_SOCK="+utils.ShellQuote(socket))
+	return server.ListenAndServe(ctx, socket)
+}
+
+// confirmWithCommand asks an ssh-askpass compatible command, which allows by exiting with status 0
+func confirmWithCommand(command string) ssh.ConfirmFunc {
+	return func(key *agent.Key, pid int) bool {
+		if command == "" {
+			return false
+		}
+
+		prompt := "Allow use of key " + key.Comment + "?\nKey fingerprint " + gossh.FingerprintSHA256(key)
+		if pid > 0 {
+			prompt += "\nRequested by PID " + strconv.Itoa(pid)
+		}
+
+		confirm := exec.Command(command, prompt)
+		confirm.Env = append(os.Environ(), "SSH_ASKPASS_PROMPT=confirm")
+		return confirm.Run() == nil
+	}
+}
+
+func agentStatusText(status ssh.AgentStatus) string {
+	if !status.Running {
+		return "not running"
+	}
+	return status.Kind + " (running)"
+}
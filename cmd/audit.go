@@ -0,0 +1,189 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/residwi/sshman/internal/ssh"
+	"github.com/residwi/sshman/utils"
+	"github.com/spf13/cobra"
+)
+
+var auditCmdFlags struct {
+	fix    bool
+	failOn string
+}
+
+var auditCmd = &cobra.Command{
+	Use:   "audit",
+	Short: "Audit SSH keys and permissions",
+	Long: `Check the SSH directory for insecure permissions, weak or unencrypted keys and
+unpaired key files. Exits with a non-zero status when a finding is at or above
+the --fail-on severity.`,
+	Args: cobra.NoArgs,
+	Example: `sshman audit
+sshman audit --fix
+sshman audit --json --fail-on medium`,
+	Annotations: map[string]string{skipExpiryWarningAnnotation: "true"},
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if !slices.Contains(ssh.GetSeverities(), auditCmdFlags.failOn) {
+			return fmt.Errorf("unsupported severity: %s. Supported severities are: %v", auditCmdFlags.failOn, ssh.GetSeverities())
+		}
+		return nil
+	},
+	RunE: auditSSHDir,
+}
+
+var auditExpiryCmd = &cobra.Command{
+	Use:   "expiry",
+	Short: "Check SSH keys for expiry",
+	Long: `Display the age and expiry of every key managed by sshman.
+Exits with a non-zero status when a key is past its expiry.`,
+	Args:        cobra.NoArgs,
+	Annotations: map[string]string{skipExpiryWarningAnnotation: "true"},
+	RunE:        auditKeyExpiry,
+}
+
+func init() {
+	rootCmd.AddCommand(auditCmd)
+	auditCmd.AddCommand(auditExpiryCmd)
+
+	auditCmd.Flags().BoolVarP(&auditCmdFlags.fix, "fix", "", false, "Correct insecure permissions")
+	auditCmd.Flags().StringVarP(&auditCmdFlags.failOn, "fail-on", "", ssh.SeverityHigh, fmt.Sprintf("Minimum severity that fails the audit %v", ssh.GetSeverities()))
+	auditCmd.RegisterFlagCompletionFunc("fail-on", completeValues(ssh.GetSeverities()...))
+}
+
+func auditSSHDir(cmd *cobra.Command, args []string) error {
+	sshPath, _ := cmd.Flags().GetString("ssh-path")
+
+	privateKeys, err := findPrivateKeys(sshPath)
+	if err != nil {
+		return fmt.Errorf("failed to list SSH keys: %w", err)
+	}
+
+	findings, err := ssh.AuditSSHDir(sshPath, privateKeys)
+	if err != nil {
+		return err
+	}
+
+	if auditCmdFlags.fix {
+		for i := range findings {
+			if !findings[i].Fixable {
+				continue
+			}
+			if err := ssh.FixFinding(&findings[i]); err != nil && !rootCmdFlags.json {
+				utils.PrintWarning("Warning: " + err.Error())
+			}
+		}
+	}
+
+	failing := 0
+	for _, finding := range findings {
+		if !finding.Fixed && ssh.SeverityRank(finding.Severity) <= ssh.SeverityRank(auditCmdFlags.failOn) {
+			failing++
+		}
+	}
+
+	if rootCmdFlags.json {
+		if findings == nil {
+			findings = []ssh.Finding{}
+		}
+		if err := printJSON(findings); err != nil {
+			return err
+		}
+
+		if failing > 0 {
+			return errSilentExit
+		}
+		return nil
+	}
+
+	if len(findings) == 0 {
+		utils.PrintSuccess("No problems found in " + sshPath)
+		return nil
+	}
+
+	headers := []string{"SEVERITY", "CHECK", "PATH", "MESSAGE"}
+	var rows [][]string
+	for _, finding := range findings {
+		message := finding.Message
+		if finding.Fixed {
+			message += " (fixed)"
+		} else if finding.Fixable {
+			message += " (fixable with --fix)"
+		}
+
+		rows = append(rows, []string{strings.ToUpper(finding.Severity), finding.Check, utils.ReplaceHomeDirWithTilde(finding.Path), message})
+	}
+
+	utils.PrintTable(os.Stdout, headers, rows)
+
+	if failing > 0 {
+		return fmt.Errorf("%d findings at or above %s severity", failing, auditCmdFlags.failOn)
+	}
+
+	return nil
+}
+
+func auditKeyExpiry(cmd *cobra.Command, args []string) error {
+	sshPath, _ := cmd.Flags().GetString("ssh-path")
+	warningDays, _ := cmd.Flags().GetInt("expiry-warning-days")
+
+	metadata, err := ssh.LoadMetadata(sshPath)
+	if err != nil {
+		return err
+	}
+
+	if len(metadata.Keys) == 0 && !rootCmdFlags.json {
+		utils.PrintSuccess("No SSH keys managed by sshman")
+		return nil
+	}
+
+	var keyNames []string
+	for keyName := range metadata.Keys {
+		keyNames = append(keyNames, keyName)
+	}
+	slices.Sort(keyNames)
+
+	now := time.Now()
+	warnWithin := time.Duration(warningDays) * 24 * time.Hour
+
+	headers := []string{"NAME", "AGE", "EXPIRES", "STATUS"}
+	var rows [][]string
+	expired := 0
+	for _, keyName := range keyNames {
+		keyMetadata, _ := metadata.Get(keyName)
+		if utils.IsFileNotExist(filepath.Join(sshPath, keyName)) {
+			continue
+		}
+
+		expires := "-"
+		if !keyMetadata.ExpiresAt.IsZero() {
+			expires = keyMetadata.ExpiresAt.Format("2006-01-02")
+		}
+
+		status := keyMetadata.ExpiryStatus(now, warnWithin)
+		if status == ssh.ExpiryExpired {
+			expired++
+		}
+
+		rows = append(rows, []string{keyName, utils.FormatAge(now.Sub(keyMetadata.CreatedAt)), expires, status})
+	}
+
+	if err := printRows(headers, rows); err != nil {
+		return err
+	}
+
+	if expired > 0 && rootCmdFlags.json {
+		return errSilentExit
+	}
+	if expired > 0 {
+		return fmt.Errorf("%d SSH keys are past expiry, rotate them with: sshman rotate [key-name]", expired)
+	}
+
+	return nil
+}
@@ -0,0 +1,196 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/residwi/sshman/internal/provider"
+	"github.com/residwi/sshman/internal/ssh"
+	"github.com/residwi/sshman/utils"
+	"github.com/spf13/cobra"
+)
+
+var authorizedAddCmdFlags struct {
+	options string
+}
+
+var authorizedRemoveCmdFlags struct {
+	yes bool
+}
+
+var authorizedCmd = &cobra.Command{
+	Use:   "authorized",
+	Short: "Manage keys allowed to log in to this machine",
+	Long:  `List, add and remove the entries of the authorized_keys file in the SSH path.`,
+}
+
+var authorizedListCmd = &cobra.Command{
+	Use:     "list",
+	Short:   "List authorized keys with their fingerprints and options",
+	Args:    cobra.NoArgs,
+	Example: `sshman authorized list`,
+	RunE:    listAuthorizedKeys,
+}
+
+var authorizedAddCmd = &cobra.Command{
+	Use:   "add <file|-|url|github:user|gitlab:user>",
+	Short: "Authorize keys from a file, stdin or a URL",
+	Long: `Add the keys of a public key file, of stdin with '-', or of a URL serving keys in
+authorized_keys format. github:<user> and gitlab:<user> fetch the public keys a user
+has uploaded to the provider. Keys that are already authorized are skipped.`,
+	Args: cobra.ExactArgs(1),
+	Example: `sshman authorized add ~/alice.pub
+cat keys.txt | sshman authorized add -
+sshman authorized add github:residwi
+sshman authorized add https://github.com/residwi.keys --options 'from="10.0.0.0/8",no-port-forwarding'`,
+	RunE: addAuthorizedKeys,
+}
+
+var authorizedRemoveCmd = &cobra.Command{
+	Use:   "remove <fingerprint>",
+	Short: "Remove every entry of an authorized key",
+	Args:  cobra.ExactArgs(1),
+	Example: `sshman authorized remove SHA256:uNiVztksCsDhcc0u9e8BujQXVUpKZIDTMczCvj3tD2s
+sshman authorized remove uNiVztksCsDhcc0u9e8BujQXVUpKZIDTMczCvj3tD2s --yes`,
+	RunE: removeAuthorizedKey,
+}
+
+func init() {
+	rootCmd.AddCommand(authorizedCmd)
+	authorizedCmd.AddCommand(authorizedListCmd)
+	authorizedCmd.AddCommand(authorizedAddCmd)
+	authorizedCmd.AddCommand(authorizedRemoveCmd)
+
+	authorizedAddCmd.Flags().StringVarP(&authorizedAddCmdFlags.options, "options", "o", "", "authorized_keys options for the added keys, e.g. from=\"10.0.0.0/8\",no-pty")
+	authorizedRemoveCmd.Flags().BoolVarP(&authorizedRemoveCmdFlags.yes, "yes", "y", false, "Remove without asking for confirmation")
+}
+
+func listAuthorizedKeys(cmd *cobra.Command, args []string) error {
+	sshPath, _ := cmd.Flags().GetString("ssh-path")
+
+	keys, err := ssh.ReadAuthorizedKeys(sshPath)
+	if err != nil {
+		return err
+	}
+
+	if len(keys) == 0 && !rootCmdFlags.json {
+		utils.PrintWarning("No authorized keys found in " + utils.ReplaceHomeDirWithTilde(sshPath))
+		return nil
+	}
+
+	return printRows([]string{"FINGERPRINT", "TYPE", "COMMENT", "OPTIONS"}, authorizedKeyRows(keys))
+}
+
+func addAuthorizedKeys(cmd *cobra.Command, args []string) error {
+	sshPath, _ := cmd.Flags().GetString("ssh-path")
+
+	content, err := readAuthorizedKeysSource(args[0])
+	if err != nil {
+		return err
+	}
+
+	if len(ssh.ParseAuthorizedKeys(content)) == 0 {
+		return fmt.Errorf("no public keys found in %s", args[0])
+	}
+
+	added, err := ssh.AddAuthorizedKeys(sshPath, content, authorizedAddCmdFlags.options)
+	if err != nil {
+		return err
+	}
+
+	if len(added) == 0 {
+		utils.PrintSuccess("All keys from " + args[0] + " are already authorized")
+		return nil
+	}
+
+	utils.PrintTable(os.Stdout, []string{"FINGERPRINT", "TYPE", "COMMENT", "OPTIONS"}, authorizedKeyRows(added))
+	utils.PrintSuccess(fmt.Sprintf("%d key(s) added to authorized_keys", len(added)))
+
+	return nil
+}
+
+func removeAuthorizedKey(cmd *cobra.Command, args []string) error {
+	sshPath, _ := cmd.Flags().GetString("ssh-path")
+	fingerprint := args[0]
+	if !strings.HasPrefix(fingerprint, "SHA256:") {
+		fingerprint = "SHA256:" + fingerprint
+	}
+
+	keys, err := ssh.ReadAuthorizedKeys(sshPath)
+	if err != nil {
+		return err
+	}
+
+	var matches []ssh.AuthorizedKey
+	for _, key := range keys {
+		if key.Fingerprint == fingerprint {
+			matches = append(matches, key)
+		}
+	}
+	if len(matches) == 0 {
+		return fmt.Errorf("no authorized key with fingerprint %s", fingerprint)
+	}
+
+	utils.PrintTable(os.Stdout, []string{"FINGERPRINT", "TYPE", "COMMENT", "OPTIONS"}, authorizedKeyRows(matches))
+
+	if !authorizedRemoveCmdFlags.yes {
+		confirmed, err := utils.Confirm("Remove this key from authorized_keys?", false)
+		if err != nil {
+			return err
+		}
+		if !confirmed {
+			utils.PrintWarning("Aborted, nothing was removed")
+			return nil
+		}
+	}
+
+	removed, err := ssh.RemoveAuthorizedKey(sshPath, fingerprint)
+	if err != nil {
+		return err
+	}
+
+	utils.PrintSuccess(fmt.Sprintf("%d entry(s) removed from authorized_keys", len(removed)))
+
+	return nil
+}
+
+// readAuthorizedKeysSource reads public keys from stdin, a URL, a provider user or a file
+func readAuthorizedKeysSource(source string) ([]byte, error) {
+	if source == "-" {
+		content, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read stdin: %w", err)
+		}
+		return content, nil
+	}
+
+	if strings.HasPrefix(source, "https://") || strings.HasPrefix(source, "http://") {
+		return provider.FetchPublicKeys(source)
+	}
+
+	if providerName, username, found := strings.Cut(source, ":"); found {
+		if _, exists := provider.GetProviderConfig(providerName); exists {
+			keysURL, err := provider.GetKeysURL(providerName, username)
+			if err != nil {
+				return nil, err
+			}
+			return provider.FetchPublicKeys(keysURL)
+		}
+	}
+
+	content, err := os.ReadFile(utils.ExpandTilde(source))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read public keys: %w", err)
+	}
+	return content, nil
+}
+
+func authorizedKeyRows(keys []ssh.AuthorizedKey) [][]string {
+	var rows [][]string
+	for _, key := range keys {
+		rows = append(rows, []string{key.Fingerprint, strings.ToUpper(key.Type), valueOrDash(key.Comment), valueOrDash(strings.Join(key.Options, ","))})
+	}
+	return rows
+}
@@ -0,0 +1,186 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/residwi/sshman/internal/backup"
+	"github.com/residwi/sshman/internal/interfaces"
+	"github.com/residwi/sshman/internal/ssh"
+	"github.com/residwi/sshman/utils"
+	"github.com/spf13/cobra"
+)
+
+var backupCmdFlags struct {
+	output         string
+	passphraseFile string
+}
+
+var restoreCmdFlags struct {
+	force          bool
+	addToAgent     bool
+	passphraseFile string
+}
+
+var backupCmd = &cobra.Command{
+	Use:   "backup",
+	Short: "Back up SSH keys, config and metadata to an encrypted archive",
+	Long: `Write the private keys, public keys, SSH config and sshman metadata to a single
+archive encrypted with a passphrase (age scrypt), ready to be restored on another machine.`,
+	Args: cobra.NoArgs,
+	Example: `sshman backup
+sshman backup -o laptop.age
+sshman backup --passphrase-file ~/.backup-passphrase`,
+	RunE: backupSSHDir,
+}
+
+var restoreCmd = &cobra.Command{
+	Use:   "restore <file>",
+	Short: "Restore SSH keys, config and metadata from a backup archive",
+	Long: `Restore a backup archive created by 'sshman backup'. Files are written with the
+permissions ssh expects, existing files are skipped unless --force is given.`,
+	Args:        cobra.ExactArgs(1),
+	Annotations: map[string]string{createsSSHPathAnnotation: "true"},
+	Example: `sshman restore sshman-backup-20240101-120000.age
+sshman restore laptop.age --force --add-to-agent`,
+	RunE: restoreSSHDir,
+}
+
+func init() {
+	rootCmd.AddCommand(backupCmd)
+	rootCmd.AddCommand(restoreCmd)
+
+	backupCmd.Flags().StringVarP(&backupCmdFlags.output, "output", "o", "", "Archive path (default sshman-backup-<timestamp>.age)")
+	backupCmd.Flags().StringVarP(&backupCmdFlags.passphraseFile, "passphrase-file", "", "", "Read the passphrase from a file instead of prompting")
+
+	restoreCmd.Flags().BoolVarP(&restoreCmdFlags.force, "force", "f", false, "Overwrite existing files")
+	restoreCmd.Flags().BoolVarP(&restoreCmdFlags.addToAgent, "add-to-agent", "", false, "Add the restored keys to the SSH agent")
+	restoreCmd.Flags().StringVarP(&restoreCmdFlags.passphraseFile, "passphrase-file", "", "", "Read the passphrase from a file instead of prompting")
+}
+
+func backupSSHDir(cmd *cobra.Command, args []string) error {
+	sshPath, _ := cmd.Flags().GetString("ssh-path")
+
+	privateKeys, err := findPrivateKeys(sshPath)
+	if err != nil {
+		return fmt.Errorf("failed to find SSH keys: %w", err)
+	}
+
+	archive, err := backup.CollectFiles(sshPath, privateKeys)
+	if err != nil {
+		return err
+	}
+
+	passphrase, err := readPassphrase(backupCmdFlags.passphraseFile, true)
+	if err != nil {
+		return err
+	}
+
+	output := backupCmdFlags.output
+	if output == "" {
+		output = fmt.Sprintf("sshman-backup-%s.age", time.Now().Format("20060102-150405"))
+	}
+
+	var buf bytes.Buffer
+	if err := archive.Write(&buf, passphrase); err != nil {
+		return err
+	}
+
+	if err := utils.WriteFileAtomic(output, buf.Bytes(), 0600); err != nil {
+		return fmt.Errorf("failed to write backup: %w", err)
+	}
+
+	utils.PrintSuccess(fmt.Sprintf("Backed up %d files (%d keys) to %s", len(archive.Manifest.Files), len(privateKeys), output))
+	return nil
+}
+
+func restoreSSHDir(cmd *cobra.Command, args []string) error {
+	sshPath, _ := cmd.Flags().GetString("ssh-path")
+
+	content, err := os.ReadFile(args[0])
+	if err != nil {
+		return fmt.Errorf("failed to read backup: %w", err)
+	}
+
+	archive, err := readArchive(content, restoreCmdFlags.passphraseFile)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(sshPath, 0700); err != nil {
+		return fmt.Errorf("failed to create SSH directory: %w", err)
+	}
+
+	result, err := archive.Restore(sshPath, restoreCmdFlags.force)
+	if err != nil {
+		return err
+	}
+
+	for _, file := range result.Skipped {
+		utils.PrintWarning(fmt.Sprintf("Skipped %s, it already exists (use --force to overwrite)", file.Name))
+	}
+
+	utils.PrintSuccess(fmt.Sprintf("Restored %d files to %s", len(result.Restored), utils.ReplaceHomeDirWithTilde(sshPath)))
+
+	if restoreCmdFlags.addToAgent {
+		agentManager := ssh.NewAgentManager(&interfaces.DefaultCommandExecutor{})
+		for _, file := range result.Restored {
+			if file.Kind != backup.KindPrivateKey {
+				continue
+			}
+			if err := agentManager.AddToAgent(sshPath, file.Name); err != nil {
+				utils.PrintWarning(fmt.Sprintf("Failed to add %s to SSH agent: %v", file.Name, err))
+				continue
+			}
+			utils.PrintSuccess(fmt.Sprintf("Added %s to SSH agent", file.Name))
+		}
+	}
+
+	return nil
+}
+
+// readArchive parses an archive, asking for its passphrase only when it is encrypted
+func readArchive(content []byte, passphraseFile string) (*backup.Archive, error) {
+	archive, err := backup.ReadArchive(bytes.NewReader(content), "")
+	if !errors.Is(err, backup.ErrPassphraseRequired) {
+		return archive, err
+	}
+
+	passphrase, err := readPassphrase(passphraseFile, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return backup.ReadArchive(bytes.NewReader(content), passphrase)
+}
+
+func readPassphrase(passphraseFile string, confirm bool) (string, error) {
+	var passphrase string
+	var err error
+
+	switch {
+	case passphraseFile != "":
+		var content []byte
+		content, err = os.ReadFile(filepath.Clean(utils.ExpandTilde(passphraseFile)))
+		passphrase = strings.TrimRight(string(content), "\r\n")
+	case confirm:
+		passphrase, err = utils.ReadNewPassword("Backup passphrase: ")
+	default:
+		passphrase, err = utils.ReadPassword("Backup passphrase: ")
+	}
+
+	if err != nil {
+		return "", fmt.Errorf("failed to read passphrase: %w", err)
+	}
+
+	if passphrase == "" {
+		return "", fmt.Errorf("passphrase cannot be empty")
+	}
+
+	return passphrase, nil
+}
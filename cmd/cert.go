@@ -0,0 +1,292 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/residwi/sshman/internal/interfaces"
+	"github.com/residwi/sshman/internal/ssh"
+	"github.com/residwi/sshman/utils"
+	"github.com/spf13/cobra"
+)
+
+const certIssuerTokenEnv = "SSHMAN_CERT_TOKEN"
+
+var certSignCmdFlags struct {
+	ca         string
+	url        string
+	token      string
+	principals []string
+	validity   string
+	identity   string
+	host       bool
+}
+
+var certRenewCmdFlags struct {
+	all    bool
+	within time.Duration
+	ca     string
+	url    string
+	token  string
+}
+
+var certCmd = &cobra.Command{
+	Use:   "cert",
+	Short: "Manage SSH certificates",
+	Long: `Sign SSH keys with a certificate authority and inspect their certificates. A certificate
+is stored next to its key as <key>-cert.pub, where ssh and ssh-add pick it up.
+
+Certificates are signed with a local CA key (--ca) or requested from a signing service
+(--url). The service receives a JSON POST with the public key, identity, principals,
+validity and type, and answers with the certificate as text or as {"certificate": "..."}.
+A bearer token for the service is read from --token or $` + certIssuerTokenEnv + `.`,
+}
+
+var certSignCmd = &cobra.Command{
+	Use:   "sign [key-name]",
+	Short: "Sign an SSH key with a CA key or a signing service",
+	Args:  cobra.ExactArgs(1),
+	Example: `sshman cert sign id_ed25519_work --ca ~/ca/user_ca --principals alice,deploy
+sshman cert sign id_ed25519_work --ca ~/ca/user_ca --principals alice --validity +1d
+sshman cert sign id_ed25519_work --url https://ca.internal/sign --principals alice`,
+	RunE:              signCert,
+	ValidArgsFunction: completeKeyNames,
+}
+
+var certRenewCmd = &cobra.Command{
+	Use:   "renew [key-name...]",
+	Short: "Renew certificates before they expire",
+	Long: `Request new certificates with the same identity, principals, type and lifetime as the
+current ones. With --all, every certificate that expires within --within, or has already
+expired, is renewed.`,
+	Example: `sshman cert renew id_ed25519_work --url https://ca.internal/sign
+sshman cert renew --all --ca ~/ca/user_ca
+sshman cert renew --all --within 2h --url https://ca.internal/sign`,
+	RunE:              renewCerts,
+	ValidArgsFunction: completeKeyNamesRepeated,
+}
+
+var certInspectCmd = &cobra.Command{
+	Use:   "inspect [key-name|cert-file]",
+	Short: "Show the details of a certificate",
+	Args:  cobra.ExactArgs(1),
+	Example: `sshman cert inspect id_ed25519_work
+sshman cert inspect ~/Downloads/id_ed25519-cert.pub`,
+	RunE:              inspectCert,
+	ValidArgsFunction: completeKeyNames,
+}
+
+func init() {
+	rootCmd.AddCommand(certCmd)
+	certCmd.AddCommand(certSignCmd)
+	certCmd.AddCommand(certInspectCmd)
+	certCmd.AddCommand(certRenewCmd)
+
+	certSignCmd.Flags().StringVarP(&certSignCmdFlags.ca, "ca", "", "", "Path to the CA private key")
+	certSignCmd.Flags().StringVarP(&certSignCmdFlags.url, "url", "", "", "URL of a certificate signing service")
+	certSignCmd.Flags().StringVarP(&certSignCmdFlags.token, "token", "", "", "Bearer token for the signing service (defaults to $"+certIssuerTokenEnv+")")
+	certSignCmd.Flags().StringSliceVarP(&certSignCmdFlags.principals, "principals", "n", nil, "Users or hosts the certificate is valid for (required)")
+	certSignCmd.Flags().StringVarP(&certSignCmdFlags.validity, "validity", "V", "+8h", "Validity interval passed to ssh-keygen -V, e.g. +8h or always:forever")
+	certSignCmd.Flags().StringVarP(&certSignCmdFlags.identity, "identity", "I", "", "Key identity logged by servers (defaults to the key name)")
+	certSignCmd.Flags().BoolVarP(&certSignCmdFlags.host, "host", "", false, "Create a host certificate instead of a user certificate")
+	certSignCmd.MarkFlagRequired("principals")
+	certSignCmd.MarkFlagsOneRequired("ca", "url")
+	certSignCmd.MarkFlagsMutuallyExclusive("ca", "url")
+
+	certRenewCmd.Flags().BoolVarP(&certRenewCmdFlags.all, "all", "a", false, "Renew all certificates that are about to expire")
+	certRenewCmd.Flags().DurationVarP(&certRenewCmdFlags.within, "within", "w", time.Hour, "With --all, renew certificates that expire within this duration")
+	certRenewCmd.Flags().StringVarP(&certRenewCmdFlags.ca, "ca", "", "", "Path to the CA private key")
+	certRenewCmd.Flags().StringVarP(&certRenewCmdFlags.url, "url", "", "", "URL of a certificate signing service")
+	certRenewCmd.Flags().StringVarP(&certRenewCmdFlags.token, "token", "", "", "Bearer token for the signing service (defaults to $"+certIssuerTokenEnv+")")
+	certRenewCmd.MarkFlagsOneRequired("ca", "url")
+	certRenewCmd.MarkFlagsMutuallyExclusive("ca", "url")
+}
+
+func signCert(cmd *cobra.Command, args []string) error {
+	sshPath, _ := cmd.Flags().GetString("ssh-path")
+	keyName := args[0]
+	keyPath := filepath.Join(sshPath, keyName)
+
+	if utils.IsFileNotExist(keyPath) {
+		return fmt.Errorf("SSH key [%s] does not exist", keyName)
+	}
+
+	identity := certSignCmdFlags.identity
+	if identity == "" {
+		identity = keyName
+	}
+
+	info, err := ssh.IssueCert(newCertIssuer(certSignCmdFlags.ca, certSignCmdFlags.url, certSignCmdFlags.token), keyPath, ssh.CertRequest{
+		Identity:   identity,
+		Principals: certSignCmdFlags.principals,
+		Validity:   certSignCmdFlags.validity,
+		Type:       certType(certSignCmdFlags.host),
+	})
+	if err != nil {
+		return err
+	}
+
+	utils.PrintSuccess("Certificate [" + filepath.Base(info.Path) + "] created for " + strings.Join(info.Principals, ", ") + ", " + formatCertValidity(info, time.Now()))
+
+	return nil
+}
+
+func renewCerts(cmd *cobra.Command, args []string) error {
+	sshPath, _ := cmd.Flags().GetString("ssh-path")
+
+	if certRenewCmdFlags.all == (len(args) > 0) {
+		return fmt.Errorf("specify the keys to renew or --all")
+	}
+
+	keyPaths := make([]string, 0, len(args))
+	for _, keyName := range args {
+		keyPath := filepath.Join(sshPath, keyName)
+		if utils.IsFileNotExist(keyPath + ssh.CertSuffix) {
+			return fmt.Errorf("SSH key [%s] has no certificate", keyName)
+		}
+		keyPaths = append(keyPaths, keyPath)
+	}
+
+	now := time.Now()
+	if certRenewCmdFlags.all {
+		privateKeys, err := findPrivateKeys(sshPath)
+		if err != nil {
+			return err
+		}
+
+		for _, keyPath := range privateKeys {
+			info := ssh.FindCert(keyPath)
+			if info == nil || info.IsForever() || info.ValidBefore.After(now.Add(certRenewCmdFlags.within)) {
+				continue
+			}
+			keyPaths = append(keyPaths, keyPath)
+		}
+
+		if len(keyPaths) == 0 {
+			utils.PrintSuccess("No certificates expire within " + utils.FormatAge(certRenewCmdFlags.within))
+			return nil
+		}
+	}
+
+	issuer := newCertIssuer(certRenewCmdFlags.ca, certRenewCmdFlags.url, certRenewCmdFlags.token)
+
+	var failed int
+	for _, keyPath := range keyPaths {
+		keyName := filepath.Base(keyPath)
+
+		current, err := ssh.ReadCert(keyPath + ssh.CertSuffix)
+		if err != nil {
+			utils.PrintError(fmt.Sprintf("Failed to renew [%s]: %v", keyName, err))
+			failed++
+			continue
+		}
+
+		request, err := current.RenewRequest()
+		if err != nil {
+			utils.PrintError(fmt.Sprintf("Failed to renew [%s]: %v", keyName, err))
+			failed++
+			continue
+		}
+
+		info, err := ssh.IssueCert(issuer, keyPath, request)
+		if err != nil {
+			utils.PrintError(fmt.Sprintf("Failed to renew [%s]: %v", keyName, err))
+			failed++
+			continue
+		}
+
+		utils.PrintSuccess("Certificate [" + filepath.Base(info.Path) + "] renewed, " + formatCertValidity(info, time.Now()))
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("failed to renew %d of %d certificates", failed, len(keyPaths))
+	}
+
+	return nil
+}
+
+func newCertIssuer(caKeyPath, url, token string) ssh.CertIssuer {
+	if url != "" {
+		if token == "" {
+			token = os.Getenv(certIssuerTokenEnv)
+		}
+		return ssh.NewHTTPCertIssuer(url, token)
+	}
+
+	return ssh.NewLocalCertIssuer(ssh.NewCertSigner(&interfaces.DefaultCommandExecutor{}), utils.ExpandTilde(caKeyPath))
+}
+
+func certType(host bool) string {
+	if host {
+		return "host"
+	}
+	return "user"
+}
+
+func inspectCert(cmd *cobra.Command, args []string) error {
+	sshPath, _ := cmd.Flags().GetString("ssh-path")
+
+	certPath := filepath.Join(sshPath, args[0]+ssh.CertSuffix)
+	if strings.HasSuffix(args[0], ".pub") || strings.ContainsRune(args[0], filepath.Separator) {
+		certPath = utils.ExpandTilde(args[0])
+	}
+
+	if utils.IsFileNotExist(certPath) {
+		return fmt.Errorf("certificate [%s] does not exist", utils.ReplaceHomeDirWithTilde(certPath))
+	}
+
+	info, err := ssh.ReadCert(certPath)
+	if err != nil {
+		return err
+	}
+
+	printCertInfo(info, time.Now())
+
+	return nil
+}
+
+func printCertInfo(info *ssh.CertInfo, now time.Time) {
+	fmt.Printf("Certificate: %s\n", utils.ReplaceHomeDirWithTilde(info.Path))
+	fmt.Printf("Type:        %s certificate\n", info.Type)
+	fmt.Printf("Key ID:      %s\n", info.KeyID)
+	fmt.Printf("Serial:      %d\n", info.Serial)
+	fmt.Printf("Principals:  %s\n", valueOrDash(strings.Join(info.Principals, ", ")))
+
+	validFrom := "always"
+	if !info.ValidAfter.IsZero() {
+		validFrom = info.ValidAfter.Local().Format("2006-01-02 15:04")
+	}
+	validTo := "forever"
+	if !info.IsForever() {
+		validTo = info.ValidBefore.Local().Format("2006-01-02 15:04")
+	}
+	fmt.Printf("Valid:       %s to %s (%s)\n", validFrom, validTo, formatCertValidity(info, now))
+
+	fmt.Printf("Signed by:   %s (%s CA)\n", info.SignedBy, strings.ToUpper(info.SignedByType))
+
+	var options []string
+	for name, value := range info.CriticalOptions {
+		options = append(options, name+"="+value)
+	}
+	slices.Sort(options)
+	fmt.Printf("Options:     %s\n", valueOrDash(strings.Join(options, ", ")))
+	fmt.Printf("Extensions:  %s\n", valueOrDash(strings.Join(info.Extensions, ", ")))
+}
+
+// formatCertValidity describes how long a certificate stays valid
+func formatCertValidity(info *ssh.CertInfo, now time.Time) string {
+	switch {
+	case info.IsForever():
+		return "never expires"
+	case info.IsExpired(now):
+		return "expired " + utils.FormatAge(now.Sub(info.ValidBefore)) + " ago"
+	case now.Before(info.ValidAfter):
+		return "valid in " + utils.FormatAge(info.ValidAfter.Sub(now))
+	default:
+		return "expires in " + utils.FormatAge(info.ValidBefore.Sub(now))
+	}
+}
@@ -0,0 +1,257 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/residwi/sshman/internal/interfaces"
+	"github.com/residwi/sshman/internal/ssh"
+	"github.com/residwi/sshman/utils"
+	"github.com/spf13/cobra"
+)
+
+var completionShells = []string{"bash", "zsh", "fish"}
+
+var completionCmd = &cobra.Command{
+	Use:   "completion [bash|zsh|fish]",
+	Short: "Generate the shell completion script",
+	Long: `Print the completion script for a shell. Key names, host aliases and providers are
+completed from the SSH directory. Use 'sshman completion install' to set it up.`,
+	ValidArgs: completionShells,
+	Args:      cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
+	Example: `source <(sshman completion bash)
+sshman completion fish > ~/.config/fish/completions/sshman.fish`,
+	// the script is printed and installed without an SSH directory, and must not include warnings
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		cmd.SilenceErrors = true
+		return nil
+	},
+	RunE: printCompletion,
+}
+
+var completionInstallCmd = &cobra.Command{
+	Use:   "install [bash|zsh|fish]",
+	Short: "Install the shell completion script",
+	Long: `Write the completion script where the shell loads it from. The shell is detected from
+$SHELL when it is not given.`,
+	ValidArgs: completionShells,
+	Args:      cobra.MatchAll(cobra.MaximumNArgs(1), cobra.OnlyValidArgs),
+	Example: `sshman completion install
+sshman completion install zsh`,
+	RunE: installCompletion,
+}
+
+func init() {
+	rootCmd.AddCommand(completionCmd)
+	completionCmd.AddCommand(completionInstallCmd)
+}
+
+func printCompletion(cmd *cobra.Command, args []string) error {
+	return writeCompletion(cmd.OutOrStdout(), args[0])
+}
+
+func installCompletion(cmd *cobra.Command, args []string) error {
+	shell := filepath.Base(os.Getenv("SHELL"))
+	if len(args) > 0 {
+		shell = args[0]
+	}
+	if !slices.Contains(completionShells, shell) {
+		return fmt.Errorf("unsupported shell: %s. Supported shells are: %v", shell, completionShells)
+	}
+
+	scriptPath, err := completionScriptPath(shell)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(scriptPath), 0755); err != nil {
+		return fmt.Errorf("failed to create completion directory: %w", err)
+	}
+
+	var script strings.Builder
+	if err := writeCompletion(&script, shell); err != nil {
+		return err
+	}
+
+	if err := utils.WriteFileAtomic(scriptPath, []byte(script.String()), 0644); err != nil {
+		return fmt.Errorf("failed to write completion script: %w", err)
+	}
+
+	utils.PrintSuccess("Completion for " + shell + " installed to " + utils.ReplaceHomeDirWithTilde(scriptPath))
+
+	switch shell {
+	case "bash":
+		utils.PrintWarning("Completion needs the bash-completion package, restart the shell to load it")
+	case "zsh":
+		utils.PrintWarning("Add this to ~/.zshrc before compinit if it is not there yet: fpath=(" + utils.ReplaceHomeDirWithTilde(filepath.Dir(scriptPath)) + " $fpath)")
+	}
+
+	return nil
+}
+
+func writeCompletion(w io.Writer, shell string) error {
+	switch shell {
+	case "bash":
+		return rootCmd.GenBashCompletionV2(w, true)
+	case "zsh":
+		return rootCmd.GenZshCompletion(w)
+	case "fish":
+		return rootCmd.GenFishCompletion(w, true)
+	}
+	return fmt.Errorf("unsupported shell: %s. Supported shells are: %v", shell, completionShells)
+}
+
+// completionScriptPath returns where each shell looks for completion scripts of the current user
+func completionScriptPath(shell string) (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+
+	switch shell {
+	case "bash":
+		dataHome := os.Getenv("XDG_DATA_HOME")
+		if dataHome == "" {
+			dataHome = filepath.Join(homeDir, ".local", "share")
+		}
+		return filepath.Join(dataHome, "bash-completion", "completions", "sshman"), nil
+	case "zsh":
+		zdotDir := os.Getenv("ZDOTDIR")
+		if zdotDir == "" {
+			zdotDir = homeDir
+		}
+		return filepath.Join(zdotDir, ".zfunc", "_sshman"), nil
+	default:
+		configHome := os.Getenv("XDG_CONFIG_HOME")
+		if configHome == "" {
+			configHome = filepath.Join(homeDir, ".config")
+		}
+		return filepath.Join(configHome, "fish", "completions", "sshman.fish"), nil
+	}
+}
+
+// completeKeyNames completes the first argument with the private keys in the SSH directory
+func completeKeyNames(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return keyNameCompletions(cmd), cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeKeyNamesRepeated completes every argument with the private keys not given yet
+func completeKeyNamesRepeated(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	var completions []string
+	for _, keyName := range keyNameCompletions(cmd) {
+		if !slices.Contains(args, keyName) {
+			completions = append(completions, keyName)
+		}
+	}
+	return completions, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeLoadedKeyNames completes the first argument with the private keys loaded in the agent
+func completeLoadedKeyNames(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	sshPath, _ := cmd.Flags().GetString("ssh-path")
+	agentKeys, err := ssh.NewAgentManager(&interfaces.DefaultCommandExecutor{}).ListAgentKeys()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	var keyPaths []string
+	for _, keyName := range keyNameCompletions(cmd) {
+		keyPaths = append(keyPaths, filepath.Join(sshPath, keyName))
+	}
+
+	loaded, _ := ssh.MatchAgentKeys(agentKeys, keyPaths)
+
+	var completions []string
+	for _, keyPath := range loaded {
+		if keyName, err := filepath.Rel(sshPath, keyPath); err == nil {
+			completions = append(completions, keyName)
+		}
+	}
+
+	return completions, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeHostAliases completes the first argument with the Host aliases of the SSH config
+func completeHostAliases(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return hostAliasCompletions(cmd), cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeKeyNameThenAlias completes a key name followed by a Host alias
+func completeKeyNameThenAlias(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	switch len(args) {
+	case 0:
+		return keyNameCompletions(cmd), cobra.ShellCompDirectiveNoFileComp
+	case 1:
+		return hostAliasCompletions(cmd), cobra.ShellCompDirectiveNoFileComp
+	}
+	return nil, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeAliasOrKeyName completes the first argument with Host aliases and key names
+func completeAliasOrKeyName(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return append(hostAliasCompletions(cmd), keyNameCompletions(cmd)...), cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeKeyFlag completes flags that take a key name
+func completeKeyFlag(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return keyNameCompletions(cmd), cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeValues completes a flag with a fixed list of values
+func completeValues(values ...string) cobra.CompletionFunc {
+	return cobra.FixedCompletions(values, cobra.ShellCompDirectiveNoFileComp)
+}
+
+func keyNameCompletions(cmd *cobra.Command) []string {
+	sshPath, _ := cmd.Flags().GetString("ssh-path")
+
+	privateKeys, err := findPrivateKeys(sshPath)
+	if err != nil {
+		return nil
+	}
+
+	var keyNames []string
+	for _, privateKey := range privateKeys {
+		if keyName, err := filepath.Rel(sshPath, privateKey); err == nil {
+			keyNames = append(keyNames, keyName)
+		}
+	}
+	return keyNames
+}
+
+func hostAliasCompletions(cmd *cobra.Command) []string {
+	sshPath, _ := cmd.Flags().GetString("ssh-path")
+
+	entries, err := ssh.ReadConfig(sshPath)
+	if err != nil {
+		return nil
+	}
+
+	var aliases []string
+	for _, entry := range entries {
+		// wildcard patterns are not hosts to connect to
+		if strings.ContainsAny(entry.Host, "*?!") || slices.Contains(aliases, entry.Host) {
+			continue
+		}
+		aliases = append(aliases, entry.Host)
+	}
+	return aliases
+}
@@ -2,10 +2,13 @@ package cmd
 
 import (
 	"fmt"
+	"os"
 	"path/filepath"
 	"slices"
 	"strings"
+	"time"
 
+	"github.com/residwi/sshman/internal/git"
 	"github.com/residwi/sshman/internal/interfaces"
 	"github.com/residwi/sshman/internal/provider"
 	"github.com/residwi/sshman/internal/ssh"
@@ -18,39 +21,43 @@ const (
 )
 
 var createCmdFlags struct {
-	typeKey  string
-	email    string
-	purpose  string
-	user     string
-	hostname string
+	typeKey        string
+	email          string
+	purpose        string
+	user           string
+	hostname       string
+	expires        string
+	alias          string
+	nonInteractive bool
+	provider       string
+	noPassphrase   bool
+	pinHostKeys    bool
 }
 
 var createCmd = &cobra.Command{
-	Use:       "create [github|gitlab|bitbucket|generic]",
-	Short:     "Create a new SSH key",
+	Use:   "create [github|gitlab|bitbucket|generic]",
+	Short: "Create a new SSH key",
+	Long: `Create a new SSH key and add a Host entry for it to the SSH config.
+When run in a terminal without the required flags, create asks for the missing values
+and shows a summary before generating the key. Use --non-interactive to disable prompting.`,
 	ValidArgs: provider.GetSupportedProviders(),
-	Args:      cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
-	Example:   `sshman create github --email residwi@mail.com -t ed25519 --purpose work`,
+	Args:      cobra.MatchAll(cobra.MaximumNArgs(1), cobra.OnlyValidArgs),
+	Example: `sshman create
+sshman create github --email residwi@mail.com -t ed25519 --purpose work`,
 	PreRunE: func(cmd *cobra.Command, args []string) error {
-		if err := isSupportedKeyType(createCmdFlags.typeKey); err != nil {
-			return err
-		}
-
-		if args[0] == "generic" && (createCmdFlags.user == "" || createCmdFlags.hostname == "") {
-			return fmt.Errorf("for 'generic' provider, both --user and --hostname flags are required")
+		if len(args) == 1 {
+			createCmdFlags.provider = args[0]
 		}
 
-		if slices.Contains(provider.GetSupportedProviders(), args[0]) {
-			if createCmdFlags.user != "" || createCmdFlags.hostname != "" {
-				utils.PrintWarning("for provider " + args[0] + ", --user and --hostname flags are ignored. Using default values from provider config")
-			}
-
-			if createCmdFlags.email == "" {
-				return fmt.Errorf("email is required for provider %s. Use --email flag", args[0])
+		if !createCmdFlags.nonInteractive && utils.IsTerminal() && isCreateInputMissing() {
+			if err := runCreateWizard(); err != nil {
+				return err
 			}
+		} else if err := cobra.ExactArgs(1)(cmd, args); err != nil {
+			return err
 		}
 
-		return nil
+		return validateCreateFlags()
 	},
 	RunE: generateSSH,
 }
@@ -59,29 +66,204 @@ func init() {
 	typeKeys := strings.Join([]string{defaultSSHKeyAlgorithm, "rsa"}, ", ")
 	rootCmd.AddCommand(createCmd)
 
-	createCmd.Flags().StringVarP(&createCmdFlags.typeKey, "type", "t", defaultSSHKeyAlgorithm, "Type of the SSH key ("+typeKeys+")")
-	createCmd.Flags().StringVarP(&createCmdFlags.email, "email", "", "", "Email for the public key (required)")
+	createCmd.Flags().StringVarP(&createCmdFlags.typeKey, "type", "t", userSettings.KeyType, "Type of the SSH key ("+typeKeys+")")
+	createCmd.Flags().StringVarP(&createCmdFlags.email, "email", "", userSettings.Email, "Email for the public key (required for known providers unless set in settings)")
 	createCmd.Flags().StringVarP(&createCmdFlags.purpose, "purpose", "", "", "Purpose of the SSH key (work, personal, etc.)")
 	createCmd.Flags().StringVarP(&createCmdFlags.user, "user", "", "", "Username for the SSH key (generic only)")
 	createCmd.Flags().StringVarP(&createCmdFlags.hostname, "hostname", "H", "", "Hostname for the SSH key (generic only)")
+	createCmd.Flags().StringVarP(&createCmdFlags.expires, "expires", "", "", "How long the key stays valid, e.g. 90d or 12w, or 'never' (defaults to the provider's max age)")
+	createCmd.Flags().StringVarP(&createCmdFlags.alias, "alias", "", "", "Host alias for the SSH config (defaults to <provider>-<purpose>)")
+	createCmd.Flags().BoolVarP(&createCmdFlags.nonInteractive, "non-interactive", "", false, "Never prompt for missing values")
+	createCmd.Flags().BoolVarP(&createCmdFlags.pinHostKeys, "pin-host-keys", "", false, "Fetch the provider's host keys with ssh-keyscan and pin them in known_hosts")
+	createCmd.RegisterFlagCompletionFunc("type", completeValues(defaultSSHKeyAlgorithm, "rsa"))
+}
+
+func validateCreateFlags() error {
+	providerName := createCmdFlags.provider
+
+	if err := isSupportedKeyType(createCmdFlags.typeKey); err != nil {
+		return err
+	}
+
+	if _, err := parseKeyExpiry(createCmdFlags.expires, providerName); err != nil {
+		return err
+	}
+
+	if providerName == "generic" && (createCmdFlags.user == "" || createCmdFlags.hostname == "") {
+		return fmt.Errorf("for 'generic' provider, both --user and --hostname flags are required")
+	}
+
+	if _, exists := provider.GetProviderConfig(providerName); exists {
+		if createCmdFlags.user != "" || createCmdFlags.hostname != "" {
+			utils.PrintWarning("for provider " + providerName + ", --user and --hostname flags are ignored. Using default values from provider config")
+		}
+
+		// the email identifies the key on the provider, generic hosts do without it
+		if createCmdFlags.email == "" {
+			return fmt.Errorf("email is required for provider %s. Use --email flag", providerName)
+		}
+	}
+
+	return nil
+}
+
+// isCreateInputMissing reports whether the flags lack a value that create requires
+func isCreateInputMissing() bool {
+	switch createCmdFlags.provider {
+	case "":
+		return true
+	case "generic":
+		return createCmdFlags.user == "" || createCmdFlags.hostname == ""
+	default:
+		return createCmdFlags.email == ""
+	}
+}
+
+// runCreateWizard asks for every value of the new key, offering the flags as defaults,
+// and confirms the result before the key is generated
+func runCreateWizard() error {
+	var err error
+
+	createCmdFlags.provider, err = promptChoice("Provider", provider.GetSupportedProviders(), valueOr(createCmdFlags.provider, "github"))
+	if err != nil {
+		return err
+	}
+
+	providerConfig, knownProvider := provider.GetProviderConfig(createCmdFlags.provider)
+	if knownProvider {
+		createCmdFlags.user, createCmdFlags.hostname = "", ""
+	} else {
+		if createCmdFlags.user, err = promptRequired("User", createCmdFlags.user); err != nil {
+			return err
+		}
+		if createCmdFlags.hostname, err = promptRequired("Hostname", createCmdFlags.hostname); err != nil {
+			return err
+		}
+	}
+
+	email := createCmdFlags.email
+	if email == "" {
+		email = git.GetUserEmail(&interfaces.DefaultCommandExecutor{})
+	}
+	if knownProvider {
+		createCmdFlags.email, err = promptRequired("Email", email)
+	} else {
+		createCmdFlags.email, err = utils.Prompt("Email", email)
+	}
+	if err != nil {
+		return err
+	}
+
+	if createCmdFlags.purpose, err = utils.Prompt("Purpose (work, personal, etc.)", createCmdFlags.purpose); err != nil {
+		return err
+	}
+
+	if createCmdFlags.typeKey, err = promptChoice("Key type", []string{defaultSSHKeyAlgorithm, "rsa"}, createCmdFlags.typeKey); err != nil {
+		return err
+	}
+
+	// ssh-keygen asks for the passphrase itself, so it never shows up in the process list
+	usePassphrase, err := utils.Confirm("Protect the key with a passphrase?", true)
+	if err != nil {
+		return err
+	}
+	createCmdFlags.noPassphrase = !usePassphrase
+
+	hostname := createCmdFlags.hostname
+	if knownProvider {
+		hostname = providerConfig.Hostname
+	}
+	defaultAlias := valueOr(createCmdFlags.alias, getHostAlias(createCmdFlags.provider, hostname, createCmdFlags.purpose))
+	if createCmdFlags.alias, err = promptRequired("Host alias", defaultAlias); err != nil {
+		return err
+	}
+
+	user := createCmdFlags.user
+	if knownProvider {
+		user = providerConfig.User
+	}
+	passphraseSummary := "none"
+	if usePassphrase {
+		passphraseSummary = "asked by ssh-keygen"
+	}
+
+	fmt.Println()
+	utils.PrintTable(os.Stdout, []string{"SETTING", "VALUE"}, [][]string{
+		{"Key", filepath.Join(rootCmdFlags.sshPath, ssh.GenerateKeyName(createCmdFlags.typeKey, createCmdFlags.purpose))},
+		{"Provider", createCmdFlags.provider},
+		{"Type", createCmdFlags.typeKey},
+		{"Email", valueOrDash(createCmdFlags.email)},
+		{"Purpose", valueOrDash(createCmdFlags.purpose)},
+		{"Passphrase", passphraseSummary},
+		{"Host", createCmdFlags.alias + " (" + user + "@" + hostname + ")"},
+	})
+	fmt.Println()
+
+	confirmed, err := utils.Confirm("Generate this key?", true)
+	if err != nil {
+		return err
+	}
+	if !confirmed {
+		return fmt.Errorf("aborted, no key was created")
+	}
+
+	return nil
+}
+
+// promptChoice asks until the answer is one of the choices
+func promptChoice(label string, choices []string, defaultValue string) (string, error) {
+	for {
+		answer, err := utils.Prompt(label+" ("+strings.Join(choices, ", ")+")", defaultValue)
+		if err != nil {
+			return "", err
+		}
+		if slices.Contains(choices, answer) {
+			return answer, nil
+		}
+		utils.PrintWarning(fmt.Sprintf("%s must be one of: %s", label, strings.Join(choices, ", ")))
+	}
+}
+
+// promptRequired asks until the answer is not empty
+func promptRequired(label, defaultValue string) (string, error) {
+	for {
+		answer, err := utils.Prompt(label, defaultValue)
+		if err != nil {
+			return "", err
+		}
+		if answer != "" {
+			return answer, nil
+		}
+		utils.PrintWarning(label + " is required")
+	}
+}
+
+func valueOr(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
 }
 
 func generateSSH(cmd *cobra.Command, args []string) error {
 	executor := &interfaces.DefaultCommandExecutor{}
 	keyGen := ssh.NewKeyGenerator(executor)
 
-	providerConfig, exists := provider.GetProviderConfig(args[0])
+	providerName := createCmdFlags.provider
+	providerConfig, exists := provider.GetProviderConfig(providerName)
 	if exists {
 		createCmdFlags.user = providerConfig.User
 		createCmdFlags.hostname = providerConfig.Hostname
 	}
 
 	keyConfig := ssh.KeyConfig{
-		Type:     createCmdFlags.typeKey,
-		Email:    createCmdFlags.email,
-		Purpose:  createCmdFlags.purpose,
-		Provider: args[0],
-		SSHPath:  rootCmdFlags.sshPath,
+		Type:         createCmdFlags.typeKey,
+		Email:        createCmdFlags.email,
+		Purpose:      createCmdFlags.purpose,
+		Provider:     providerName,
+		SSHPath:      rootCmdFlags.sshPath,
+		Bits:         userSettings.RSABits,
+		NoPassphrase: createCmdFlags.noPassphrase,
 	}
 
 	keyName, err := keyGen.GenerateKey(keyConfig)
@@ -91,7 +273,15 @@ func generateSSH(cmd *cobra.Command, args []string) error {
 
 	utils.PrintSuccess("SSH key [" + keyName + "] created!")
 
-	hostAlias := getHostAlias(args[0], createCmdFlags.hostname, createCmdFlags.purpose)
+	expiresIn, _ := parseKeyExpiry(createCmdFlags.expires, providerName)
+	if err := recordKeyMetadata(rootCmdFlags.sshPath, keyName, keyConfig, expiresIn); err != nil {
+		utils.PrintWarning("Warning: " + err.Error())
+	}
+
+	hostAlias := createCmdFlags.alias
+	if hostAlias == "" {
+		hostAlias = getHostAlias(providerName, createCmdFlags.hostname, createCmdFlags.purpose)
+	}
 	configEntry := ssh.ConfigEntry{
 		Host:         hostAlias,
 		User:         createCmdFlags.user,
@@ -105,6 +295,19 @@ func generateSSH(cmd *cobra.Command, args []string) error {
 
 	utils.PrintSuccess("SSH config added for host [" + hostAlias + "] with user [" + createCmdFlags.user + "]")
 
+	if exists && createCmdFlags.pinHostKeys {
+		pinned, err := pinProviderHostKeys(rootCmdFlags.sshPath, providerName)
+		if err != nil {
+			utils.PrintWarning("Warning: Failed to pin host keys: " + err.Error() + ". Pin them later with: sshman known-hosts pin " + providerName)
+		} else if len(pinned) > 0 {
+			utils.PrintSuccess("Host keys of [" + createCmdFlags.hostname + "] pinned in known_hosts")
+		}
+	}
+
+	if !userSettings.AddToAgent {
+		return nil
+	}
+
 	executor = &interfaces.DefaultCommandExecutor{}
 	agentManager := ssh.NewAgentManager(executor)
 	if agentManager.IsAgentRunning() {
@@ -114,11 +317,52 @@ func generateSSH(cmd *cobra.Command, args []string) error {
 		} else {
 			utils.PrintSuccess("SSH key automatically added to ssh-agent")
 		}
+	} else {
+		utils.PrintWarning(`No SSH agent is running, the key was not added. Start one with: eval "$(sshman agent start)"`)
 	}
 
 	return nil
 }
 
+func recordKeyMetadata(sshPath, keyName string, keyConfig ssh.KeyConfig, expiresIn time.Duration) error {
+	metadata, err := ssh.LoadMetadata(sshPath)
+	if err != nil {
+		return err
+	}
+
+	keyMetadata := ssh.KeyMetadata{
+		Type:      keyConfig.Type,
+		Provider:  keyConfig.Provider,
+		Purpose:   keyConfig.Purpose,
+		Email:     keyConfig.Email,
+		CreatedAt: time.Now(),
+	}
+	if expiresIn > 0 {
+		keyMetadata.ExpiresAt = keyMetadata.CreatedAt.Add(expiresIn)
+	}
+
+	metadata.Set(keyName, keyMetadata)
+
+	return metadata.Save(sshPath)
+}
+
+// parseKeyExpiry returns how long a new key stays valid, where zero means it never expires
+func parseKeyExpiry(expires, providerName string) (time.Duration, error) {
+	switch expires {
+	case "":
+		providerConfig, _ := provider.GetProviderConfig(providerName)
+		return providerConfig.MaxKeyAge, nil
+	case "never":
+		return 0, nil
+	}
+
+	expiresIn, err := utils.ParseDuration(expires)
+	if err != nil {
+		return 0, fmt.Errorf("invalid --expires value: %s. Use a duration such as 90d, 12w or 'never'", expires)
+	}
+	return expiresIn, nil
+}
+
 func getHostAlias(provider, hostname, purpose string) string {
 	if purpose != "" {
 		return provider + "-" + purpose
@@ -2,8 +2,9 @@ package cmd
 
 import (
 	"fmt"
-	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 
 	"github.com/residwi/sshman/internal/interfaces"
 	"github.com/residwi/sshman/internal/ssh"
@@ -11,44 +12,120 @@ import (
 	"github.com/spf13/cobra"
 )
 
+var deleteCmdFlags struct {
+	yes    bool
+	dryRun bool
+}
+
 var deleteCmd = &cobra.Command{
 	Use:   "delete [key-name]",
 	Short: "Delete SSH key and remove from agent",
-	Long:  `Delete an SSH key pair and remove it from agent`,
-	Args:  cobra.ExactArgs(1),
+	Long: `Delete an SSH key pair and remove it from agent. The key files, the Host entries
+using the key and its metadata are moved to the trash, from where they can be restored
+with 'sshman trash restore'.`,
+	Args: cobra.ExactArgs(1),
 	Example: `sshman delete id_ed25519_work
-sshman delete id_rsa_personal`,
-	RunE: deleteSSHKey,
+sshman delete id_rsa_personal --dry-run
+sshman delete id_rsa_personal --yes`,
+	RunE:              deleteSSHKey,
+	ValidArgsFunction: completeKeyNames,
 }
 
 func init() {
 	rootCmd.AddCommand(deleteCmd)
+
+	deleteCmd.Flags().BoolVarP(&deleteCmdFlags.yes, "yes", "y", false, "Delete without asking for confirmation")
+	deleteCmd.Flags().BoolVarP(&deleteCmdFlags.dryRun, "dry-run", "", false, "Show what would be deleted without deleting anything")
 }
 
 func deleteSSHKey(cmd *cobra.Command, args []string) error {
 	sshPath, _ := cmd.Flags().GetString("ssh-path")
-	keyName := args[0]
+
+	return deleteKey(sshPath, args[0], deleteCmdFlags.yes, deleteCmdFlags.dryRun)
+}
+
+func deleteKey(sshPath, keyName string, yes, dryRun bool) error {
+	keyPath := filepath.Join(sshPath, keyName)
+
+	plan, err := ssh.PlanTrash(sshPath, keyName)
+	if err != nil {
+		return err
+	}
 
 	executor := &interfaces.DefaultCommandExecutor{}
 	agentManager := ssh.NewAgentManager(executor)
-	if agentManager.IsAgentRunning() {
-		if err := agentManager.RemoveFromAgent(sshPath, keyName); err == nil {
-			utils.PrintSuccess("SSH key [" + keyName + "] removed from agent")
+	loaded := agentManager.IsKeyLoaded(keyPath)
+
+	printDeletePlan(plan, ssh.KeyFingerprint(keyPath), loaded)
+
+	if dryRun {
+		utils.PrintSuccess("Dry run, nothing was deleted")
+		return nil
+	}
+
+	if !yes {
+		confirmed, err := utils.Confirm("Delete SSH key ["+keyName+"]?", false)
+		if err != nil {
+			return err
+		}
+		if !confirmed {
+			utils.PrintWarning("Aborted, nothing was deleted")
+			return nil
 		}
 	}
 
-	privateKeyPath := filepath.Join(sshPath, keyName)
-	if err := os.Remove(privateKeyPath); err != nil {
-		return fmt.Errorf("failed to delete private key: %w", err)
+	if loaded {
+		if err := agentManager.RemoveFromAgent(sshPath, keyName); err != nil {
+			return fmt.Errorf("failed to remove SSH key [%s] from agent, nothing was deleted: %w", keyName, err)
+		}
+		utils.PrintSuccess("SSH key [" + keyName + "] removed from agent")
 	}
-	utils.PrintSuccess("SSH private key [" + keyName + "] deleted successfully")
 
-	publicKeyPath := privateKeyPath + ".pub"
-	if err := os.Remove(publicKeyPath); err != nil {
-		return fmt.Errorf("failed to delete public key: %w", err)
+	entry, err := ssh.TrashKey(sshPath, keyName)
+	if err != nil {
+		if loaded {
+			if err := agentManager.AddToAgent(sshPath, keyName); err != nil {
+				utils.PrintWarning("Warning: Failed to add SSH key [" + keyName + "] back to agent: " + err.Error())
+			}
+		}
+		return err
 	}
-	utils.PrintSuccess("SSH public key [" + keyName + "] deleted successfully")
-	utils.PrintWarning("Note: You may need to manually remove the key from your SSH config file")
+
+	utils.PrintSuccess("SSH key [" + keyName + "] moved to trash, restore it with: sshman trash restore " + entry.ID)
 
 	return nil
 }
+
+func printDeletePlan(plan *ssh.TrashEntry, fingerprint string, loaded bool) {
+	fmt.Printf("Key:         %s\n", plan.KeyName)
+	if fingerprint != "" {
+		fmt.Printf("Fingerprint: %s\n", fingerprint)
+	}
+	fmt.Printf("Files:       %s\n", strings.Join(plan.Files, ", "))
+
+	var removedHosts []string
+	for _, host := range plan.Hosts {
+		if !slices.Contains(plan.KeptHosts, host) {
+			removedHosts = append(removedHosts, host)
+		}
+	}
+	if len(removedHosts) > 0 {
+		fmt.Printf("Hosts:       %s\n", strings.Join(removedHosts, ", "))
+	}
+
+	if plan.Metadata != nil {
+		fmt.Printf("Metadata:    %s key", plan.Metadata.Provider)
+		if plan.Metadata.Purpose != "" {
+			fmt.Printf(" for %s", plan.Metadata.Purpose)
+		}
+		fmt.Println()
+	}
+
+	if loaded {
+		fmt.Println("Agent:       loaded, will be removed")
+	}
+
+	for _, host := range plan.KeptHosts {
+		utils.PrintWarning("Host [" + host + "] also uses other keys and is kept, remove its IdentityFile for [" + plan.KeyName + "] manually")
+	}
+}
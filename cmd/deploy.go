@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/residwi/sshman/internal/interfaces"
+	"github.com/residwi/sshman/internal/ssh"
+	"github.com/residwi/sshman/utils"
+	"github.com/spf13/cobra"
+)
+
+var deployCmdFlags struct {
+	options string
+	remove  bool
+}
+
+var deployCmd = &cobra.Command{
+	Use:   "deploy [key-name] [alias]",
+	Short: "Authorize an SSH key on a server",
+	Long: `Append the public key of an SSH key to the authorized_keys file of a server, unless
+it is already there. The server is reached with ssh, so the alias can be any Host from the
+SSH config or user@hostname, and a password is asked for when the key is not authorized yet.
+Use --options to restrict what the key may do, and --remove to revoke the key again.`,
+	Args: cobra.ExactArgs(2),
+	Example: `sshman deploy id_ed25519_server generic-server
+sshman deploy id_ed25519_ci deploy@example.com --options 'from="10.0.0.0/8",no-agent-forwarding'
+sshman deploy id_ed25519_ci deploy@example.com --remove`,
+	RunE:              deploySSHKey,
+	ValidArgsFunction: completeKeyNameThenAlias,
+}
+
+func init() {
+	rootCmd.AddCommand(deployCmd)
+
+	deployCmd.Flags().StringVarP(&deployCmdFlags.options, "options", "o", "", "authorized_keys options to restrict the key, e.g. from=\"10.0.0.0/8\",no-agent-forwarding")
+	deployCmd.Flags().BoolVarP(&deployCmdFlags.remove, "remove", "", false, "Remove the key from the server's authorized_keys")
+	deployCmd.MarkFlagsMutuallyExclusive("options", "remove")
+}
+
+func deploySSHKey(cmd *cobra.Command, args []string) error {
+	sshPath, _ := cmd.Flags().GetString("ssh-path")
+	keyName, host := args[0], args[1]
+	publicKeyPath := filepath.Join(sshPath, keyName+".pub")
+
+	if utils.IsFileNotExist(publicKeyPath) {
+		return fmt.Errorf("public key of [%s] does not exist", keyName)
+	}
+
+	publicKey := readPublicKey(publicKeyPath)
+	if publicKey == "" {
+		return fmt.Errorf("failed to read public key of [%s]", keyName)
+	}
+
+	deployer := ssh.NewDeployer(&interfaces.DefaultCommandExecutor{})
+
+	if deployCmdFlags.remove {
+		result, err := deployer.Revoke(host, publicKey)
+		if err != nil {
+			return err
+		}
+
+		if result == ssh.DeployAbsent {
+			utils.PrintWarning("SSH key [" + keyName + "] is not authorized on [" + host + "]")
+			return nil
+		}
+		utils.PrintSuccess("SSH key [" + keyName + "] removed from authorized_keys on [" + host + "]")
+		return nil
+	}
+
+	result, err := deployer.Deploy(host, publicKey, deployCmdFlags.options)
+	if err != nil {
+		return err
+	}
+
+	if result == ssh.DeployPresent {
+		utils.PrintSuccess("SSH key [" + keyName + "] is already authorized on [" + host + "]")
+		if deployCmdFlags.options != "" {
+			utils.PrintWarning("The existing entry was kept without the new options, run with --remove first to replace it")
+		}
+		return nil
+	}
+	utils.PrintSuccess("SSH key [" + keyName + "] added to authorized_keys on [" + host + "]")
+
+	return nil
+}
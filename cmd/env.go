@@ -0,0 +1,119 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/residwi/sshman/internal/git"
+	"github.com/residwi/sshman/utils"
+	"github.com/spf13/cobra"
+)
+
+// envDirVar records which .sshman directory the exports came from, so leaving it can undo them
+const envDirVar = "SSHMAN_ENV_DIR"
+
+// envPrevSSHCommandVar keeps the GIT_SSH_COMMAND that was set before entering a .sshman directory
+const envPrevSSHCommandVar = "SSHMAN_ENV_PREV_GIT_SSH_COMMAND"
+
+var envCmdFlags struct {
+	dir    string
+	direnv bool
+}
+
+var envCmd = &cobra.Command{
+	Use:   "env",
+	Short: "Print shell exports selecting the SSH key of the current directory",
+	Long: `Print shell code setting GIT_SSH_COMMAND to the key in the nearest .sshman file, found
+by walking up from the current directory. Outside such a directory, the exports set by a
+previous run are undone again, restoring a GIT_SSH_COMMAND that was set before.
+
+With --direnv, the output is meant for an .envrc file: direnv unloads it by itself and
+reloads it when .sshman changes.`,
+	Args: cobra.NoArgs,
+	Example: `eval "$(sshman env)"
+echo 'eval "$(sshman env --direnv)"' >> .envrc`,
+	Annotations: map[string]string{skipExpiryWarningAnnotation: "true"},
+	RunE:        printDirEnv,
+}
+
+var envInitCmd = &cobra.Command{
+	Use:               "init <key-name>",
+	Short:             "Create a .sshman file selecting an SSH key for a directory",
+	Args:              cobra.ExactArgs(1),
+	Example:           `sshman env init id_ed25519_work --dir ~/work/project`,
+	ValidArgsFunction: completeKeyNames,
+	RunE:              initDirEnv,
+}
+
+func init() {
+	rootCmd.AddCommand(envCmd)
+	envCmd.AddCommand(envInitCmd)
+
+	envCmd.PersistentFlags().StringVarP(&envCmdFlags.dir, "dir", "d", ".", "Directory to start from")
+	envCmd.Flags().BoolVarP(&envCmdFlags.direnv, "direnv", "", false, "Print code for a direnv .envrc file")
+}
+
+func printDirEnv(cmd *cobra.Command, args []string) error {
+	sshPath, _ := cmd.Flags().GetString("ssh-path")
+
+	// the output is evaluated by the shell, so errors must not end up on stdout
+	config, found, err := git.FindDirConfig(utils.ExpandTilde(envCmdFlags.dir))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "sshman: "+err.Error())
+		return errSilentExit
+	}
+
+	if !found {
+		if !envCmdFlags.direnv && os.Getenv(envDirVar) != "" {
+			if previous, ok := os.LookupEnv(envPrevSSHCommandVar); ok {
+				fmt.Println("export GIT_SSH_COMMAND=" + utils.ShellQuote(previous))
+				fmt.Println("unset " + envPrevSSHCommandVar + " " + envDirVar)
+			} else {
+				fmt.Println("unset GIT_SSH_COMMAND " + envDirVar)
+			}
+		}
+		return nil
+	}
+
+	keyPath := config.KeyPath(sshPath)
+	if utils.IsFileNotExist(keyPath) {
+		fmt.Fprintf(os.Stderr, "sshman: SSH key [%s] of %s does not exist\n", config.Key, utils.ReplaceHomeDirWithTilde(config.Path))
+		return errSilentExit
+	}
+
+	if envCmdFlags.direnv {
+		fmt.Println("watch_file " + utils.ShellQuote(config.Path))
+	} else {
+		// only the value from before the first .sshman directory is worth restoring
+		if previous, ok := os.LookupEnv("GIT_SSH_COMMAND"); ok && os.Getenv(envDirVar) == "" {
+			fmt.Println("export " + envPrevSSHCommandVar + "=" + utils.ShellQuote(previous))
+		}
+		fmt.Println("export " + envDirVar + "=" + utils.ShellQuote(filepath.Dir(config.Path)))
+	}
+	fmt.Println("export GIT_SSH_COMMAND=" + utils.ShellQuote(config.SSHCommand(sshPath)))
+
+	return nil
+}
+
+func initDirEnv(cmd *cobra.Command, args []string) error {
+	sshPath, _ := cmd.Flags().GetString("ssh-path")
+	keyName := args[0]
+
+	if utils.IsFileNotExist(filepath.Join(sshPath, keyName)) {
+		return fmt.Errorf("SSH key [%s] does not exist", keyName)
+	}
+
+	dir := utils.ExpandTilde(envCmdFlags.dir)
+	if utils.IsDirectoryNotExist(dir) {
+		return fmt.Errorf("directory does not exist: %s", envCmdFlags.dir)
+	}
+
+	path := filepath.Join(dir, git.DirConfigFileName)
+	if err := git.WriteDirConfig(path, git.DirConfig{Key: keyName}); err != nil {
+		return err
+	}
+
+	utils.PrintSuccess("SSH key [" + keyName + "] selected in " + utils.ReplaceHomeDirWithTilde(path))
+	return nil
+}
@@ -0,0 +1,328 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/residwi/sshman/internal/backup"
+	"github.com/residwi/sshman/internal/interfaces"
+	"github.com/residwi/sshman/internal/provider"
+	"github.com/residwi/sshman/internal/ssh"
+	"github.com/residwi/sshman/utils"
+	"github.com/spf13/cobra"
+)
+
+var exportCmdFlags struct {
+	output         string
+	encrypt        bool
+	passphraseFile string
+}
+
+var importCmdFlags struct {
+	name           string
+	provider       string
+	purpose        string
+	email          string
+	user           string
+	hostname       string
+	expires        string
+	force          bool
+	passphraseFile string
+}
+
+var exportCmd = &cobra.Command{
+	Use:   "export <key-name>",
+	Short: "Export one SSH key with its metadata and Host entries",
+	Long: `Bundle a key pair with its sshman metadata and the Host entries that use it into a
+portable file that can be imported on another machine with 'sshman import'.`,
+	Args: cobra.ExactArgs(1),
+	Example: `sshman export id_ed25519_work
+sshman export id_ed25519_work -o work.sshman --encrypt`,
+	RunE:              exportSSHKey,
+	ValidArgsFunction: completeKeyNames,
+}
+
+var importCmd = &cobra.Command{
+	Use:   "import <file|path>",
+	Short: "Import an exported bundle or an existing private key",
+	Long: `Import a bundle created by 'sshman export', or adopt an existing private key into
+sshman. For a bare private key, --provider is required, the public key is derived when
+it is missing, and a Host entry is added to the SSH config.`,
+	Args:        cobra.ExactArgs(1),
+	Annotations: map[string]string{createsSSHPathAnnotation: "true"},
+	Example: `sshman import work.sshman
+sshman import ~/Downloads/id_ed25519 --provider github --purpose work
+sshman import /mnt/old/id_rsa --provider generic --user deploy --hostname server.com --name id_rsa_server`,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if importCmdFlags.provider != "" && !slices.Contains(provider.GetSupportedProviders(), importCmdFlags.provider) {
+			return fmt.Errorf("unsupported provider: %s. Supported providers are: %v", importCmdFlags.provider, provider.GetSupportedProviders())
+		}
+
+		if importCmdFlags.provider == "generic" && (importCmdFlags.user == "" || importCmdFlags.hostname == "") {
+			return fmt.Errorf("for 'generic' provider, both --user and --hostname flags are required")
+		}
+
+		if importCmdFlags.provider != "" {
+			if _, err := parseKeyExpiry(importCmdFlags.expires, importCmdFlags.provider); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	},
+	RunE: importSSHKey,
+}
+
+func init() {
+	rootCmd.AddCommand(exportCmd)
+	rootCmd.AddCommand(importCmd)
+
+	exportCmd.Flags().StringVarP(&exportCmdFlags.output, "output", "o", "", "Bundle path (default <key-name>.sshman)")
+	exportCmd.Flags().BoolVarP(&exportCmdFlags.encrypt, "encrypt", "", false, "Encrypt the bundle with a passphrase")
+	exportCmd.Flags().StringVarP(&exportCmdFlags.passphraseFile, "passphrase-file", "", "", "Read the passphrase from a file instead of prompting (implies --encrypt)")
+
+	importCmd.Flags().StringVarP(&importCmdFlags.name, "name", "", "", "Name of the imported key (defaults to the original name)")
+	importCmd.Flags().StringVarP(&importCmdFlags.provider, "provider", "", "", "Provider of the key (github, gitlab, bitbucket, generic)")
+	importCmd.Flags().StringVarP(&importCmdFlags.purpose, "purpose", "", "", "Purpose of the key (work, personal, etc.)")
+	importCmd.Flags().StringVarP(&importCmdFlags.email, "email", "", "", "Email of the key (defaults to the public key comment)")
+	importCmd.Flags().StringVarP(&importCmdFlags.user, "user", "", "", "Username for the Host entry (generic only)")
+	importCmd.Flags().StringVarP(&importCmdFlags.hostname, "hostname", "H", "", "Hostname for the Host entry (generic only)")
+	importCmd.Flags().StringVarP(&importCmdFlags.expires, "expires", "", "", "How long the key stays valid, e.g. 90d or 12w, or 'never' (defaults to the provider's max age)")
+	importCmd.Flags().BoolVarP(&importCmdFlags.force, "force", "f", false, "Overwrite an existing key with the same name")
+	importCmd.Flags().StringVarP(&importCmdFlags.passphraseFile, "passphrase-file", "", "", "Read the bundle passphrase from a file instead of prompting")
+	importCmd.RegisterFlagCompletionFunc("provider", completeValues(provider.GetSupportedProviders()...))
+}
+
+func exportSSHKey(cmd *cobra.Command, args []string) error {
+	sshPath, _ := cmd.Flags().GetString("ssh-path")
+	keyName := args[0]
+
+	if utils.IsFileNotExist(filepath.Join(sshPath, keyName)) {
+		return fmt.Errorf("SSH key [%s] does not exist", keyName)
+	}
+
+	archive, err := backup.NewKeyBundle(sshPath, keyName)
+	if err != nil {
+		return err
+	}
+
+	var passphrase string
+	if exportCmdFlags.encrypt || exportCmdFlags.passphraseFile != "" {
+		passphrase, err = readPassphrase(exportCmdFlags.passphraseFile, true)
+		if err != nil {
+			return err
+		}
+	}
+
+	output := exportCmdFlags.output
+	if output == "" {
+		output = keyName + ".sshman"
+	}
+
+	var buf bytes.Buffer
+	if err := archive.Write(&buf, passphrase); err != nil {
+		return err
+	}
+
+	if err := utils.WriteFileAtomic(output, buf.Bytes(), 0600); err != nil {
+		return fmt.Errorf("failed to write bundle: %w", err)
+	}
+
+	utils.PrintSuccess(fmt.Sprintf("SSH key [%s] exported to %s", keyName, output))
+	if passphrase == "" {
+		utils.PrintWarning("The bundle is not encrypted, use --encrypt to protect it with a passphrase")
+	}
+
+	return nil
+}
+
+func importSSHKey(cmd *cobra.Command, args []string) error {
+	sshPath, _ := cmd.Flags().GetString("ssh-path")
+	source := utils.ExpandTilde(args[0])
+
+	if err := os.MkdirAll(sshPath, 0700); err != nil {
+		return fmt.Errorf("failed to create SSH directory: %w", err)
+	}
+
+	if isPrivateKeyFile(source) {
+		return importPrivateKey(sshPath, source)
+	}
+
+	content, err := os.ReadFile(source)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", source, err)
+	}
+
+	archive, err := readArchive(content, importCmdFlags.passphraseFile)
+	if err != nil {
+		return err
+	}
+
+	bundle, err := archive.KeyBundle()
+	if err != nil {
+		return err
+	}
+
+	return importBundle(sshPath, bundle)
+}
+
+func importBundle(sshPath string, bundle *backup.KeyBundle) error {
+	keyName := importCmdFlags.name
+	if keyName == "" {
+		keyName = bundle.KeyName
+	}
+
+	keyPath := filepath.Join(sshPath, keyName)
+	if !importCmdFlags.force && !utils.IsFileNotExist(keyPath) {
+		return fmt.Errorf("SSH key [%s] already exists. Use --name to import it under another name or --force to overwrite it", keyName)
+	}
+
+	if err := utils.WriteFileAtomic(keyPath, bundle.PrivateKey, 0600); err != nil {
+		return fmt.Errorf("failed to write private key: %w", err)
+	}
+
+	if bundle.PublicKey != nil {
+		if err := utils.WriteFileAtomic(keyPath+".pub", bundle.PublicKey, 0644); err != nil {
+			return fmt.Errorf("failed to write public key: %w", err)
+		}
+	} else if err := ssh.NewKeyGenerator(&interfaces.DefaultCommandExecutor{}).DerivePublicKey(keyPath); err != nil {
+		return err
+	}
+
+	utils.PrintSuccess("SSH key [" + keyName + "] imported!")
+
+	if bundle.Metadata == nil {
+		if importCmdFlags.provider == "" {
+			utils.PrintWarning("The bundle has no sshman metadata, use --provider to record it")
+			return nil
+		}
+		return adoptImportedKey(sshPath, keyName)
+	}
+
+	metadata, err := ssh.LoadMetadata(sshPath)
+	if err != nil {
+		return err
+	}
+	metadata.Set(keyName, *bundle.Metadata)
+	if err := metadata.Save(sshPath); err != nil {
+		return err
+	}
+
+	for _, host := range bundle.Hosts {
+		host.IdentityFile = keyPath
+		if err := addImportedHost(sshPath, host); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func importPrivateKey(sshPath, source string) error {
+	if importCmdFlags.provider == "" {
+		return fmt.Errorf("--provider is required when importing a private key")
+	}
+
+	keyName := importCmdFlags.name
+	if keyName == "" {
+		keyName = filepath.Base(source)
+	}
+
+	keyPath := filepath.Join(sshPath, keyName)
+	if !utils.IsSamePath(source, keyPath) {
+		if !importCmdFlags.force && !utils.IsFileNotExist(keyPath) {
+			return fmt.Errorf("SSH key [%s] already exists. Use --name to import it under another name or --force to overwrite it", keyName)
+		}
+
+		if err := copyFile(source, keyPath, 0600); err != nil {
+			return fmt.Errorf("failed to copy private key: %w", err)
+		}
+
+		if !utils.IsFileNotExist(source + ".pub") {
+			if err := copyFile(source+".pub", keyPath+".pub", 0644); err != nil {
+				return fmt.Errorf("failed to copy public key: %w", err)
+			}
+		}
+	}
+
+	if utils.IsFileNotExist(keyPath + ".pub") {
+		if err := ssh.NewKeyGenerator(&interfaces.DefaultCommandExecutor{}).DerivePublicKey(keyPath); err != nil {
+			return err
+		}
+		utils.PrintSuccess("Public key derived from [" + keyName + "]")
+	}
+
+	utils.PrintSuccess("SSH key [" + keyName + "] imported!")
+
+	return adoptImportedKey(sshPath, keyName)
+}
+
+// adoptImportedKey records the metadata of an imported key from the import flags and
+// adds a Host entry for it
+func adoptImportedKey(sshPath, keyName string) error {
+	keyPath := filepath.Join(sshPath, keyName)
+	publicKey := readPublicKey(keyPath + ".pub")
+
+	email := importCmdFlags.email
+	if email == "" {
+		email, _ = ssh.ReadPublicKeyComment(keyPath + ".pub")
+	}
+
+	keyConfig := ssh.KeyConfig{
+		Type:     ssh.KeyTypeFromPublicKey(publicKey),
+		Email:    email,
+		Purpose:  importCmdFlags.purpose,
+		Provider: importCmdFlags.provider,
+	}
+
+	expiresIn, _ := parseKeyExpiry(importCmdFlags.expires, importCmdFlags.provider)
+	if err := recordKeyMetadata(sshPath, keyName, keyConfig, expiresIn); err != nil {
+		return err
+	}
+
+	user, hostname := importCmdFlags.user, importCmdFlags.hostname
+	if providerConfig, exists := provider.GetProviderConfig(importCmdFlags.provider); exists {
+		user, hostname = providerConfig.User, providerConfig.Hostname
+	}
+
+	return addImportedHost(sshPath, ssh.ConfigEntry{
+		Host:         getHostAlias(importCmdFlags.provider, hostname, importCmdFlags.purpose),
+		User:         user,
+		Hostname:     hostname,
+		IdentityFile: keyPath,
+	})
+}
+
+// addImportedHost adds a Host entry unless the alias is already configured
+func addImportedHost(sshPath string, entry ssh.ConfigEntry) error {
+	entries, err := ssh.ReadConfig(sshPath)
+	if err != nil {
+		return err
+	}
+
+	for _, existing := range entries {
+		if existing.Host == entry.Host {
+			utils.PrintWarning("Host [" + entry.Host + "] already exists in SSH config, point its IdentityFile at " + utils.ReplaceHomeDirWithTilde(entry.IdentityFile) + " if needed")
+			return nil
+		}
+	}
+
+	if err := ssh.AddToConfig(sshPath, entry); err != nil {
+		return err
+	}
+
+	utils.PrintSuccess("SSH config added for host [" + entry.Host + "] with user [" + entry.User + "]")
+	return nil
+}
+
+func copyFile(source, destination string, perm os.FileMode) error {
+	content, err := os.ReadFile(source)
+	if err != nil {
+		return err
+	}
+
+	return utils.WriteFileAtomic(destination, content, perm)
+}
@@ -0,0 +1,242 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/residwi/sshman/internal/git"
+	"github.com/residwi/sshman/internal/interfaces"
+	"github.com/residwi/sshman/internal/ssh"
+	"github.com/residwi/sshman/utils"
+	"github.com/spf13/cobra"
+)
+
+var gitBindCmdFlags struct {
+	dir   string
+	key   string
+	email string
+	mode  string
+}
+
+var gitRemoteCmdFlags struct {
+	key    string
+	remote string
+	dryRun bool
+}
+
+var gitCmd = &cobra.Command{
+	Use:   "git",
+	Short: "Manage git identities",
+	Long:  `Route git operations to the right SSH key per directory`,
+}
+
+var gitBindCmd = &cobra.Command{
+	Use:   "bind",
+	Short: "Bind an SSH key to a directory",
+	Long: `Bind an SSH key to every git repository under a directory using an includeIf section
+in the global git config. The key is selected with core.sshCommand, or with url.<alias>.insteadOf
+rules pointing at the key's host alias when --mode insteadof is given.`,
+	Args: cobra.NoArgs,
+	Example: `sshman git bind --dir ~/work --key id_ed25519_work
+sshman git bind --dir ~/oss --key id_ed25519_oss --mode insteadof`,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if utils.IsDirectoryNotExist(utils.ExpandTilde(gitBindCmdFlags.dir)) {
+			utils.PrintWarning("Directory " + gitBindCmdFlags.dir + " does not exist yet")
+		}
+		return nil
+	},
+	RunE: bindGitIdentity,
+}
+
+var gitBindingsCmd = &cobra.Command{
+	Use:   "bindings",
+	Short: "List git identity bindings",
+	Long:  `Display all directories bound to an SSH key`,
+	Args:  cobra.NoArgs,
+	RunE:  listGitBindings,
+}
+
+var gitRemoteCmd = &cobra.Command{
+	Use:   "remote [url]",
+	Short: "Rewrite a remote to use a host alias",
+	Long: `Rewrite the URL of a repository's remote to use the host alias of an SSH key.
+Without --key, the alias is detected from the remote's hostname. When a URL is given,
+the rewritten URL is printed instead, which is useful for cloning.`,
+	Args: cobra.MaximumNArgs(1),
+	Example: `sshman git remote --key id_ed25519_work
+sshman git remote --remote upstream --dry-run
+git clone $(sshman git remote git@github.com:company/project.git --key id_ed25519_work)`,
+	Annotations: map[string]string{skipExpiryWarningAnnotation: "true"},
+	RunE:        rewriteGitRemote,
+}
+
+func init() {
+	rootCmd.AddCommand(gitCmd)
+	gitCmd.AddCommand(gitBindCmd, gitBindingsCmd, gitRemoteCmd)
+
+	gitBindCmd.Flags().StringVarP(&gitBindCmdFlags.dir, "dir", "d", "", "Directory containing the git repositories (required)")
+	gitBindCmd.Flags().StringVarP(&gitBindCmdFlags.key, "key", "k", "", "Name of the SSH key to use (required)")
+	gitBindCmd.Flags().StringVarP(&gitBindCmdFlags.email, "email", "", "", "Email for commits (defaults to the key's email)")
+	gitBindCmd.Flags().StringVarP(&gitBindCmdFlags.mode, "mode", "m", git.ModeSSHCommand, fmt.Sprintf("How the key is selected %v", git.GetSupportedModes()))
+	gitBindCmd.MarkFlagRequired("dir")
+	gitBindCmd.MarkFlagRequired("key")
+
+	gitRemoteCmd.Flags().StringVarP(&gitRemoteCmdFlags.key, "key", "k", "", "Name of the SSH key to use (detected from the remote when omitted)")
+	gitRemoteCmd.Flags().StringVarP(&gitRemoteCmdFlags.remote, "remote", "r", "origin", "Name of the remote to rewrite")
+	gitRemoteCmd.Flags().BoolVarP(&gitRemoteCmdFlags.dryRun, "dry-run", "", false, "Show the new URL without changing the remote")
+
+	gitBindCmd.RegisterFlagCompletionFunc("key", completeKeyFlag)
+	gitBindCmd.RegisterFlagCompletionFunc("mode", completeValues(git.GetSupportedModes()...))
+	gitRemoteCmd.RegisterFlagCompletionFunc("key", completeKeyFlag)
+}
+
+func bindGitIdentity(cmd *cobra.Command, args []string) error {
+	sshPath, _ := cmd.Flags().GetString("ssh-path")
+	keyPath := filepath.Join(sshPath, gitBindCmdFlags.key)
+
+	if utils.IsFileNotExist(keyPath) {
+		return fmt.Errorf("SSH key [%s] does not exist", gitBindCmdFlags.key)
+	}
+
+	binding := git.Binding{
+		Dir:     gitBindCmdFlags.dir,
+		KeyPath: keyPath,
+		Email:   gitBindCmdFlags.email,
+		Mode:    gitBindCmdFlags.mode,
+	}
+
+	if binding.Email == "" {
+		email, err := ssh.ReadPublicKeyComment(keyPath + ".pub")
+		if err != nil || email == "" {
+			utils.PrintWarning("Could not read email from public key, user.email will not be set")
+		}
+		binding.Email = email
+	}
+
+	if binding.Mode == git.ModeInsteadOf {
+		entries, err := ssh.ReadConfig(sshPath)
+		if err != nil {
+			return err
+		}
+
+		matches := ssh.FindEntriesByIdentityFile(entries, keyPath)
+		if len(matches) == 0 {
+			return fmt.Errorf("no host alias in SSH config uses key [%s]", gitBindCmdFlags.key)
+		}
+		if len(matches) > 1 {
+			utils.PrintWarning("Several host aliases use key [" + gitBindCmdFlags.key + "], using [" + matches[0].Host + "]")
+		}
+
+		binding.HostAlias = matches[0].Host
+		binding.User = matches[0].User
+		binding.Hostname = matches[0].Hostname
+	}
+
+	executor := &interfaces.DefaultCommandExecutor{}
+	bindingManager := git.NewBindingManager(executor, filepath.Join(utils.ConfigDir(), "git"))
+
+	configPath, err := bindingManager.Bind(binding)
+	if err != nil {
+		return err
+	}
+
+	utils.PrintSuccess("Repositories under [" + git.NormalizeGitDir(binding.Dir) + "] now use SSH key [" + gitBindCmdFlags.key + "]")
+	utils.PrintSuccess("Git config written to " + utils.ReplaceHomeDirWithTilde(configPath))
+
+	return nil
+}
+
+func listGitBindings(cmd *cobra.Command, args []string) error {
+	executor := &interfaces.DefaultCommandExecutor{}
+	bindingManager := git.NewBindingManager(executor, filepath.Join(utils.ConfigDir(), "git"))
+
+	bindings, err := bindingManager.ListBindings()
+	if err != nil {
+		return err
+	}
+
+	if len(bindings) == 0 && !rootCmdFlags.json {
+		utils.PrintSuccess("No git identity bindings found")
+		return nil
+	}
+
+	headers := []string{"DIR", "KEY", "EMAIL", "MODE"}
+	var rows [][]string
+	for _, binding := range bindings {
+		mode := binding.Mode
+		if binding.Mode == git.ModeInsteadOf {
+			mode += " (" + binding.HostAlias + ")"
+		}
+
+		rows = append(rows, []string{binding.Dir, filepath.Base(binding.KeyPath), binding.Email, mode})
+	}
+
+	return printRows(headers, rows)
+}
+
+func rewriteGitRemote(cmd *cobra.Command, args []string) error {
+	sshPath, _ := cmd.Flags().GetString("ssh-path")
+
+	executor := &interfaces.DefaultCommandExecutor{}
+	remoteManager := git.NewRemoteManager(executor)
+
+	var currentURL string
+	if len(args) == 1 {
+		currentURL = args[0]
+	} else {
+		remoteURL, err := remoteManager.GetURL(gitRemoteCmdFlags.remote)
+		if err != nil {
+			return err
+		}
+		currentURL = remoteURL
+	}
+
+	remote, err := git.ParseRemoteURL(currentURL)
+	if err != nil {
+		return err
+	}
+
+	entries, err := ssh.ReadConfig(sshPath)
+	if err != nil {
+		return err
+	}
+
+	var keyPath string
+	if gitRemoteCmdFlags.key != "" {
+		keyPath = filepath.Join(sshPath, gitRemoteCmdFlags.key)
+	}
+
+	entry, err := git.ResolveHostAlias(entries, remote, keyPath)
+	if err != nil {
+		return err
+	}
+
+	newURL := remote.WithHostAlias(entry).String()
+
+	// a URL argument only prints the result so it can be used with git clone
+	if len(args) == 1 {
+		fmt.Println(newURL)
+		return nil
+	}
+
+	fmt.Println("Before: " + currentURL)
+	fmt.Println("After:  " + newURL)
+
+	if newURL == currentURL {
+		utils.PrintSuccess("Remote [" + gitRemoteCmdFlags.remote + "] already uses host alias [" + entry.Host + "]")
+		return nil
+	}
+
+	if gitRemoteCmdFlags.dryRun {
+		utils.PrintWarning("Dry run, remote [" + gitRemoteCmdFlags.remote + "] was not changed")
+		return nil
+	}
+
+	if err := remoteManager.SetURL(gitRemoteCmdFlags.remote, newURL); err != nil {
+		return err
+	}
+
+	utils.PrintSuccess("Remote [" + gitRemoteCmdFlags.remote + "] now uses host alias [" + entry.Host + "]")
+
+	return nil
+}
@@ -0,0 +1,216 @@
+package cmd
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/residwi/sshman/internal/interfaces"
+	"github.com/residwi/sshman/internal/provider"
+	"github.com/residwi/sshman/internal/ssh"
+	"github.com/residwi/sshman/utils"
+	"github.com/spf13/cobra"
+)
+
+var knownHostsCmd = &cobra.Command{
+	Use:   "known-hosts",
+	Short: "Manage known SSH host keys",
+	Long: `List and remove the entries of the known_hosts file in the SSH path, and pin the
+published host keys of the built-in providers.`,
+}
+
+var knownHostsListCmd = &cobra.Command{
+	Use:   "list [alias|hostname]",
+	Short: "List known host keys",
+	Long: `List the entries of known_hosts. Hashed entries are shown with the hostname they
+match when it appears in the SSH config or is a built-in provider.`,
+	Args: cobra.MaximumNArgs(1),
+	Example: `sshman known-hosts list
+sshman known-hosts list github-work`,
+	RunE:              listKnownHosts,
+	ValidArgsFunction: completeHostAliases,
+}
+
+var knownHostsRemoveCmd = &cobra.Command{
+	Use:   "remove <alias|hostname>",
+	Short: "Remove the host keys of a host",
+	Long: `Remove the known_hosts entries of a hostname, including hashed ones. For a Host alias,
+the entries of its HostName are removed.`,
+	Args: cobra.ExactArgs(1),
+	Example: `sshman known-hosts remove server.example.com
+sshman known-hosts remove generic-server`,
+	RunE:              removeKnownHost,
+	ValidArgsFunction: completeHostAliases,
+}
+
+var knownHostsPinCmd = &cobra.Command{
+	Use:   "pin [github|gitlab|bitbucket...]",
+	Short: "Pin the published host keys of providers",
+	Long: `Fetch the host keys of the built-in providers with ssh-keyscan and add them to
+known_hosts after checking them against the fingerprints the providers publish, so the
+first connection works with StrictHostKeyChecking. Without arguments, every provider is pinned.`,
+	ValidArgs: []string{"github", "gitlab", "bitbucket"},
+	Args:      cobra.OnlyValidArgs,
+	Example: `sshman known-hosts pin
+sshman known-hosts pin github`,
+	RunE: pinKnownHosts,
+}
+
+func init() {
+	rootCmd.AddCommand(knownHostsCmd)
+	knownHostsCmd.AddCommand(knownHostsListCmd)
+	knownHostsCmd.AddCommand(knownHostsRemoveCmd)
+	knownHostsCmd.AddCommand(knownHostsPinCmd)
+}
+
+func listKnownHosts(cmd *cobra.Command, args []string) error {
+	sshPath, _ := cmd.Flags().GetString("ssh-path")
+
+	hosts, err := ssh.ReadKnownHosts(sshPath)
+	if err != nil {
+		return err
+	}
+
+	var filter []string
+	if len(args) == 1 {
+		filter = resolveKnownHostNames(sshPath, args[0])
+	}
+	candidates := knownHostCandidates(sshPath)
+
+	var rows [][]string
+	for _, host := range hosts {
+		if len(filter) > 0 && !slices.ContainsFunc(filter, host.Matches) {
+			continue
+		}
+
+		name := strings.Join(host.Hosts, ",")
+		if host.IsHashed() {
+			name = "(hashed)"
+			if index := slices.IndexFunc(candidates, host.Matches); index != -1 {
+				name = candidates[index] + " (hashed)"
+			}
+		}
+		if host.Marker != "" {
+			name = "@" + host.Marker + " " + name
+		}
+
+		rows = append(rows, []string{name, strings.ToUpper(host.Type), host.Fingerprint})
+	}
+
+	if len(rows) == 0 && !rootCmdFlags.json {
+		utils.PrintWarning("No known hosts found")
+		return nil
+	}
+
+	return printRows([]string{"HOST", "TYPE", "FINGERPRINT"}, rows)
+}
+
+func removeKnownHost(cmd *cobra.Command, args []string) error {
+	sshPath, _ := cmd.Flags().GetString("ssh-path")
+
+	removed := 0
+	for _, hostname := range resolveKnownHostNames(sshPath, args[0]) {
+		hosts, err := ssh.RemoveKnownHost(sshPath, hostname)
+		if err != nil {
+			return err
+		}
+
+		if len(hosts) > 0 {
+			utils.PrintSuccess(fmt.Sprintf("%d host key(s) of [%s] removed from known_hosts", len(hosts), hostname))
+		}
+		removed += len(hosts)
+	}
+
+	if removed == 0 {
+		utils.PrintWarning("No known host keys found for [" + args[0] + "]")
+	}
+
+	return nil
+}
+
+func pinKnownHosts(cmd *cobra.Command, args []string) error {
+	sshPath, _ := cmd.Flags().GetString("ssh-path")
+
+	providers := args
+	if len(providers) == 0 {
+		providers = cmd.ValidArgs
+	}
+
+	var failed []string
+	for _, providerName := range providers {
+		providerConfig, _ := provider.GetProviderConfig(providerName)
+
+		pinned, err := pinProviderHostKeys(sshPath, providerName)
+		if err != nil {
+			utils.PrintError(err.Error())
+			failed = append(failed, providerName)
+			continue
+		}
+
+		if len(pinned) == 0 {
+			utils.PrintSuccess("Host keys of [" + providerConfig.Hostname + "] are already pinned")
+			continue
+		}
+		utils.PrintSuccess(fmt.Sprintf("%d host key(s) of [%s] pinned", len(pinned), providerConfig.Hostname))
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to pin host keys for %s", strings.Join(failed, ", "))
+	}
+
+	return nil
+}
+
+// pinProviderHostKeys adds the host keys of a built-in provider to known_hosts once they match the published fingerprints
+func pinProviderHostKeys(sshPath, providerName string) ([]ssh.KnownHost, error) {
+	providerConfig, exists := provider.GetProviderConfig(providerName)
+	if !exists || len(providerConfig.HostKeyFingerprints) == 0 {
+		return nil, fmt.Errorf("no published host keys for provider %s", providerName)
+	}
+
+	scanned, err := ssh.NewHostKeyScanner(&interfaces.DefaultCommandExecutor{}).Scan(providerConfig.Hostname)
+	if err != nil {
+		return nil, err
+	}
+
+	return ssh.PinHostKeys(sshPath, providerConfig.Hostname, scanned, providerConfig.HostKeyFingerprints)
+}
+
+// resolveKnownHostNames returns the name and, for a Host alias, the HostName it connects to
+func resolveKnownHostNames(sshPath, name string) []string {
+	names := []string{name}
+
+	entries, err := ssh.ReadConfig(sshPath)
+	if err != nil {
+		return names
+	}
+
+	for _, entry := range entries {
+		if entry.Host == name && entry.Hostname != "" && !slices.Contains(names, entry.Hostname) {
+			names = append(names, entry.Hostname)
+		}
+	}
+
+	return names
+}
+
+// knownHostCandidates returns the hostnames hashed known_hosts entries are compared with
+func knownHostCandidates(sshPath string) []string {
+	var candidates []string
+	for _, providerName := range provider.GetSupportedProviders() {
+		if providerConfig, exists := provider.GetProviderConfig(providerName); exists {
+			candidates = append(candidates, providerConfig.Hostname)
+		}
+	}
+
+	entries, _ := ssh.ReadConfig(sshPath)
+	for _, entry := range entries {
+		for _, name := range []string{entry.Hostname, entry.Host} {
+			if name != "" && !slices.Contains(candidates, name) {
+				candidates = append(candidates, name)
+			}
+		}
+	}
+
+	return candidates
+}
@@ -7,6 +7,7 @@ import (
 	"path/filepath"
 	"slices"
 	"strings"
+	"time"
 
 	"github.com/residwi/sshman/internal/interfaces"
 	"github.com/residwi/sshman/internal/ssh"
@@ -33,7 +34,7 @@ func listSSHKeys(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to list SSH keys: %w", err)
 	}
 
-	if len(privateKeys) == 0 {
+	if len(privateKeys) == 0 && !rootCmdFlags.json {
 		utils.PrintSuccess("No SSH keys found in " + sshPath)
 		return nil
 	}
@@ -45,11 +46,19 @@ func listSSHKeys(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	headers := []string{"NAME", "TYPE", "STATUS", "PATH"}
+	metadata, err := ssh.LoadMetadata(sshPath)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	hasCerts := false
+	headers := []string{"NAME", "TYPE", "STATUS", "AGE", "CERT", "PATH"}
 	var rows [][]string
 	for _, privateKey := range privateKeys {
 		keyName := filepath.Base(privateKey)
 		keyInfo := getKeyInfo(privateKey)
+		age := getKeyAge(privateKey, metadata)
 
 		publicKey := readPublicKey(privateKey + ".pub")
 
@@ -58,13 +67,25 @@ func listSSHKeys(cmd *cobra.Command, args []string) error {
 			status = "Loaded"
 		}
 
+		cert := "-"
+		if info := ssh.FindCert(privateKey); info != nil {
+			hasCerts = true
+			cert = strings.Join(info.Principals, ",") + " (" + formatCertValidity(info, now) + ")"
+		}
+
 		path := utils.ReplaceHomeDirWithTilde(privateKey)
-		rows = append(rows, []string{keyName, keyInfo, status, path})
+		rows = append(rows, []string{keyName, keyInfo, status, age, cert, path})
 	}
 
-	utils.PrintTable(os.Stdout, headers, rows)
+	// the certificate column is only shown when there are certificates
+	if !hasCerts {
+		headers = slices.Delete(headers, 4, 5)
+		for i := range rows {
+			rows[i] = slices.Delete(rows[i], 4, 5)
+		}
+	}
 
-	return nil
+	return printRows(headers, rows)
 }
 
 // findPrivateKeys finds all SSH private key files in the given directory
@@ -76,6 +97,10 @@ func findPrivateKeys(sshPath string) ([]string, error) {
 			return err
 		}
 
+		if entry.IsDir() && ssh.IsSSHManDir(entry.Name()) {
+			return filepath.SkipDir
+		}
+
 		if entry.IsDir() || strings.HasSuffix(path, ".pub") {
 			return nil
 		}
@@ -129,6 +154,19 @@ func getKeyInfo(keyPath string) string {
 	return "Unknown"
 }
 
+// getKeyAge uses the creation time recorded by sshman, falling back to the file's modification time
+func getKeyAge(keyPath string, metadata *ssh.Metadata) string {
+	if keyMetadata, exists := metadata.Get(filepath.Base(keyPath)); exists {
+		return utils.FormatAge(time.Since(keyMetadata.CreatedAt))
+	}
+
+	info, err := os.Stat(keyPath)
+	if err != nil {
+		return "-"
+	}
+	return utils.FormatAge(time.Since(info.ModTime()))
+}
+
 func readPublicKey(path string) string {
 	content, err := os.ReadFile(path)
 	if err != nil {
@@ -0,0 +1,41 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/residwi/sshman/utils"
+)
+
+// printRows prints a listing as a table, or with --json as an array of objects keyed by
+// the lowercased headers
+func printRows(headers []string, rows [][]string) error {
+	if !rootCmdFlags.json {
+		utils.PrintTable(os.Stdout, headers, rows)
+		return nil
+	}
+
+	objects := make([]map[string]string, 0, len(rows))
+	for _, row := range rows {
+		object := map[string]string{}
+		for i, header := range headers {
+			if i < len(row) {
+				object[strings.ReplaceAll(strings.ToLower(header), " ", "_")] = row[i]
+			}
+		}
+		objects = append(objects, object)
+	}
+
+	return printJSON(objects)
+}
+
+func printJSON(value any) error {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(value); err != nil {
+		return fmt.Errorf("failed to encode output: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,328 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/residwi/sshman/internal/interfaces"
+	"github.com/residwi/sshman/internal/profile"
+	"github.com/residwi/sshman/internal/ssh"
+	"github.com/residwi/sshman/utils"
+	"github.com/spf13/cobra"
+)
+
+var profileAddCmdFlags struct {
+	lifetime string
+	env      []string
+}
+
+var profileCmd = &cobra.Command{
+	Use:   "profile",
+	Short: "Switch between groups of keys in the agent",
+	Long: `Profiles are named groups of keys, e.g. one per client, stored in profiles.yaml in the
+sshman configuration directory. 'sshman profile use' replaces the keys in the agent with
+the keys of a profile.`,
+}
+
+var profileListCmd = &cobra.Command{
+	Use:     "list",
+	Short:   "List profiles",
+	Aliases: []string{"ls"},
+	Args:    cobra.NoArgs,
+	RunE:    listProfiles,
+}
+
+var profileAddCmd = &cobra.Command{
+	Use:   "add <name> <key-name...>",
+	Short: "Create or replace a profile",
+	Args:  cobra.MinimumNArgs(2),
+	Example: `sshman profile add clientA id_ed25519_clienta id_ed25519_clienta_deploy --lifetime 8h
+sshman profile add personal id_ed25519 --env GIT_AUTHOR_EMAIL=me@example.com`,
+	ValidArgsFunction: completeProfileNameThenKeys,
+	RunE:              addProfile,
+}
+
+var profileRemoveCmd = &cobra.Command{
+	Use:               "remove <name>",
+	Short:             "Remove a profile",
+	Aliases:           []string{"rm"},
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeProfileNames,
+	RunE:              removeProfile,
+}
+
+var profileUseCmd = &cobra.Command{
+	Use:   "use <name>",
+	Short: "Clear the agent and load the keys of a profile",
+	Long: `Remove all keys from the agent and load exactly the keys of the profile, with the
+profile's lifetime.`,
+	Args:              cobra.ExactArgs(1),
+	Example:           `sshman profile use clientA`,
+	ValidArgsFunction: completeProfileNames,
+	RunE:              useProfile,
+}
+
+var profileCurrentCmd = &cobra.Command{
+	Use:   "current",
+	Short: "Print the profile matching the keys in the agent",
+	Args:  cobra.NoArgs,
+	RunE:  printCurrentProfile,
+}
+
+var profileEnvCmd = &cobra.Command{
+	Use:   "env [name]",
+	Short: "Print shell exports for the environment of a profile",
+	Long: `Print export statements for the environment variables of a profile, or of the profile
+matching the keys in the agent when no name is given.`,
+	Args:              cobra.MaximumNArgs(1),
+	Example:           `eval "$(sshman profile env clientA)"`,
+	Annotations:       map[string]string{skipExpiryWarningAnnotation: "true"},
+	ValidArgsFunction: completeProfileNames,
+	RunE:              printProfileEnv,
+}
+
+func init() {
+	rootCmd.AddCommand(profileCmd)
+	profileCmd.AddCommand(profileListCmd, profileAddCmd, profileRemoveCmd, profileUseCmd, profileCurrentCmd, profileEnvCmd)
+
+	profileAddCmd.Flags().StringVarP(&profileAddCmdFlags.lifetime, "lifetime", "l", "", "How long the agent keeps the keys, e.g. 8h (default: until removed)")
+	profileAddCmd.Flags().StringArrayVarP(&profileAddCmdFlags.env, "env", "e", nil, "Environment variable for the profile as NAME=VALUE, can be repeated")
+}
+
+func listProfiles(cmd *cobra.Command, args []string) error {
+	sshPath, _ := cmd.Flags().GetString("ssh-path")
+
+	profiles, err := profile.Load(profile.Path())
+	if err != nil {
+		return err
+	}
+
+	if len(profiles) == 0 && !rootCmdFlags.json {
+		utils.PrintWarning("No profiles found. Create one with: sshman profile add <name> <key-name...>")
+		return nil
+	}
+
+	current := currentProfileName(sshPath, profiles)
+
+	headers := []string{"NAME", "KEYS", "LIFETIME", "ENV", "ACTIVE"}
+	var rows [][]string
+	for _, p := range profiles {
+		active := ""
+		if p.Name == current {
+			active = "yes"
+		}
+		rows = append(rows, []string{p.Name, strings.Join(p.Keys, ", "), valueOrDash(p.Lifetime), valueOrDash(strings.Join(p.EnvNames(), ", ")), active})
+	}
+
+	return printRows(headers, rows)
+}
+
+func addProfile(cmd *cobra.Command, args []string) error {
+	sshPath, _ := cmd.Flags().GetString("ssh-path")
+
+	newProfile := profile.Profile{Name: args[0], Keys: args[1:], Lifetime: profileAddCmdFlags.lifetime}
+	for _, env := range profileAddCmdFlags.env {
+		name, value, ok := strings.Cut(env, "=")
+		if !ok {
+			return fmt.Errorf("invalid --env value: %s. Use NAME=VALUE", env)
+		}
+		if newProfile.Env == nil {
+			newProfile.Env = map[string]string{}
+		}
+		newProfile.Env[name] = value
+	}
+
+	if err := newProfile.Validate(sshPath); err != nil {
+		return err
+	}
+
+	profiles, err := profile.Load(profile.Path())
+	if err != nil {
+		return err
+	}
+
+	_, exists := profile.Find(profiles, newProfile.Name)
+	if err := profile.Save(profile.Path(), profile.Put(profiles, newProfile)); err != nil {
+		return err
+	}
+
+	if exists {
+		utils.PrintSuccess("Profile [" + newProfile.Name + "] updated")
+	} else {
+		utils.PrintSuccess("Profile [" + newProfile.Name + "] created, load it with: sshman profile use " + newProfile.Name)
+	}
+	return nil
+}
+
+func removeProfile(cmd *cobra.Command, args []string) error {
+	name := args[0]
+
+	profiles, err := profile.Load(profile.Path())
+	if err != nil {
+		return err
+	}
+
+	if _, ok := profile.Find(profiles, name); !ok {
+		return fmt.Errorf("profile [%s] does not exist", name)
+	}
+
+	if err := profile.Save(profile.Path(), profile.Remove(profiles, name)); err != nil {
+		return err
+	}
+
+	utils.PrintSuccess("Profile [" + name + "] removed")
+	return nil
+}
+
+func useProfile(cmd *cobra.Command, args []string) error {
+	sshPath, _ := cmd.Flags().GetString("ssh-path")
+
+	p, err := findProfile(args[0])
+	if err != nil {
+		return err
+	}
+
+	if err := p.Validate(sshPath); err != nil {
+		return err
+	}
+	lifetime, _ := p.LifetimeDuration()
+
+	executor := &interfaces.DefaultCommandExecutor{}
+	agentManager := ssh.NewAgentManager(executor)
+	if !agentManager.IsAgentRunning() {
+		return errAgentNotRunning
+	}
+
+	if err := agentManager.ClearAgent(sshPath); err != nil {
+		return err
+	}
+
+	for _, keyName := range p.Keys {
+		if err := agentManager.AddToAgentWithLifetime(sshPath, keyName, lifetime); err != nil {
+			return err
+		}
+	}
+
+	message := fmt.Sprintf("Profile [%s] loaded with %d key(s)", p.Name, len(p.Keys))
+	if lifetime > 0 {
+		message += " for " + p.Lifetime
+	}
+	utils.PrintSuccess(message)
+
+	if len(p.Env) > 0 {
+		utils.PrintWarning("Profile [" + p.Name + "] sets environment variables, apply them with: eval \"$(sshman profile env " + p.Name + ")\"")
+	}
+
+	return nil
+}
+
+func printCurrentProfile(cmd *cobra.Command, args []string) error {
+	sshPath, _ := cmd.Flags().GetString("ssh-path")
+
+	profiles, err := profile.Load(profile.Path())
+	if err != nil {
+		return err
+	}
+
+	executor := &interfaces.DefaultCommandExecutor{}
+	agentKeys, err := ssh.NewAgentManager(executor).ListAgentKeys()
+	if err != nil {
+		return err
+	}
+
+	p, ok := profile.Current(profiles, sshPath, agentKeys)
+	if !ok {
+		return fmt.Errorf("no profile matches the keys loaded in agent")
+	}
+
+	fmt.Println(p.Name)
+	return nil
+}
+
+func printProfileEnv(cmd *cobra.Command, args []string) error {
+	sshPath, _ := cmd.Flags().GetString("ssh-path")
+
+	var p profile.Profile
+	if len(args) == 1 {
+		found, err := findProfile(args[0])
+		if err != nil {
+			return err
+		}
+		p = found
+	} else {
+		profiles, err := profile.Load(profile.Path())
+		if err != nil {
+			return err
+		}
+
+		current, ok := profile.Find(profiles, currentProfileName(sshPath, profiles))
+		if !ok {
+			return fmt.Errorf("no profile matches the keys loaded in agent, specify a profile name")
+		}
+		p = current
+	}
+
+	for _, name := range p.EnvNames() {
+		fmt.Printf("export %s=%s\n", name, utils.ShellQuote(p.Env[name]))
+	}
+	return nil
+}
+
+func findProfile(name string) (profile.Profile, error) {
+	profiles, err := profile.Load(profile.Path())
+	if err != nil {
+		return profile.Profile{}, err
+	}
+
+	p, ok := profile.Find(profiles, name)
+	if !ok {
+		return profile.Profile{}, fmt.Errorf("profile [%s] does not exist", name)
+	}
+	return p, nil
+}
+
+// currentProfileName returns the name of the profile matching the agent, or "" when the
+// agent is not running or no profile matches
+func currentProfileName(sshPath string, profiles []profile.Profile) string {
+	executor := &interfaces.DefaultCommandExecutor{}
+	agentManager := ssh.NewAgentManager(executor)
+	if !agentManager.IsAgentRunning() {
+		return ""
+	}
+
+	agentKeys, err := agentManager.ListAgentKeys()
+	if err != nil {
+		return ""
+	}
+
+	p, _ := profile.Current(profiles, sshPath, agentKeys)
+	return p.Name
+}
+
+func completeProfileNames(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return profileNameCompletions(), cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeProfileNameThenKeys completes an existing profile name first, then key names
+func completeProfileNameThenKeys(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) == 0 {
+		return profileNameCompletions(), cobra.ShellCompDirectiveNoFileComp
+	}
+	return completeKeyNamesRepeated(cmd, args[1:], toComplete)
+}
+
+func profileNameCompletions() []string {
+	profiles, err := profile.Load(profile.Path())
+	if err != nil {
+		return nil
+	}
+
+	var names []string
+	for _, p := range profiles {
+		names = append(names, p.Name)
+	}
+	return names
+}
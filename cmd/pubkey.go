@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/residwi/sshman/internal/clipboard"
+	"github.com/residwi/sshman/internal/interfaces"
+	"github.com/residwi/sshman/internal/ssh"
+	"github.com/residwi/sshman/utils"
+	"github.com/spf13/cobra"
+)
+
+var pubkeyCmdFlags struct {
+	copy   bool
+	format string
+}
+
+var pubkeyCmd = &cobra.Command{
+	Use:   "pubkey [key-name]",
+	Short: "Print or copy a public key",
+	Long: `Print the public key of an SSH key, or copy it to the clipboard with --copy.
+The clipboard is set with wl-copy, xclip or xsel, falling back to the OSC 52 escape
+sequence, which also works from a remote terminal. Use --format to convert the key
+for appliances that do not accept the OpenSSH format.`,
+	Args: cobra.ExactArgs(1),
+	Example: `sshman pubkey id_ed25519_work
+sshman pubkey id_ed25519_work --copy
+sshman pubkey id_rsa_server --format pkcs8`,
+	Annotations:       map[string]string{skipExpiryWarningAnnotation: "true"},
+	RunE:              printPublicKey,
+	ValidArgsFunction: completeKeyNames,
+}
+
+func init() {
+	rootCmd.AddCommand(pubkeyCmd)
+
+	pubkeyCmd.Flags().BoolVarP(&pubkeyCmdFlags.copy, "copy", "c", false, "Copy the public key to the clipboard instead of printing it")
+	pubkeyCmd.Flags().StringVarP(&pubkeyCmdFlags.format, "format", "f", "openssh", "Format of the public key ("+strings.Join(ssh.PublicKeyFormats, ", ")+")")
+	pubkeyCmd.RegisterFlagCompletionFunc("format", completeValues(ssh.PublicKeyFormats...))
+}
+
+func printPublicKey(cmd *cobra.Command, args []string) error {
+	sshPath, _ := cmd.Flags().GetString("ssh-path")
+	keyName := args[0]
+	publicKeyPath := filepath.Join(sshPath, keyName+".pub")
+
+	if utils.IsFileNotExist(publicKeyPath) {
+		return fmt.Errorf("public key of [%s] does not exist", keyName)
+	}
+
+	executor := &interfaces.DefaultCommandExecutor{}
+	publicKey, err := ssh.NewKeyGenerator(executor).ConvertPublicKey(publicKeyPath, pubkeyCmdFlags.format)
+	if err != nil {
+		return err
+	}
+
+	if !pubkeyCmdFlags.copy {
+		fmt.Print(string(publicKey))
+		return nil
+	}
+
+	method, err := clipboard.NewClipboard(executor).Copy(strings.TrimSpace(string(publicKey)))
+	if err != nil {
+		return err
+	}
+
+	if method == clipboard.MethodOSC52 {
+		utils.PrintSuccess("Public key of [" + keyName + "] sent to the terminal clipboard with OSC 52")
+		return nil
+	}
+
+	utils.PrintSuccess("Public key of [" + keyName + "] copied to the clipboard with " + method)
+	return nil
+}
@@ -0,0 +1,143 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/residwi/sshman/internal/git"
+	"github.com/residwi/sshman/internal/interfaces"
+	"github.com/residwi/sshman/internal/profile"
+	"github.com/residwi/sshman/internal/ssh"
+	"github.com/residwi/sshman/utils"
+	"github.com/spf13/cobra"
+)
+
+var renameCmd = &cobra.Command{
+	Use:   "rename <old-name> <new-name>",
+	Short: "Rename an SSH key and update every reference to it",
+	Long: `Rename an SSH key pair. Every IdentityFile, git binding and profile using the old key
+is pointed at the new name, the metadata is moved, and the key is reloaded in the agent if
+it was loaded. A .sshman file of the current directory naming the key is reported.`,
+	Args:              cobra.ExactArgs(2),
+	Example:           `sshman rename id_ed25519 id_ed25519_personal`,
+	RunE:              renameSSHKey,
+	ValidArgsFunction: completeKeyNames,
+}
+
+func init() {
+	rootCmd.AddCommand(renameCmd)
+}
+
+func renameSSHKey(cmd *cobra.Command, args []string) error {
+	sshPath, _ := cmd.Flags().GetString("ssh-path")
+	oldName, newName := args[0], args[1]
+	oldKeyPath := filepath.Join(sshPath, oldName)
+	newKeyPath := filepath.Join(sshPath, newName)
+
+	if utils.IsFileNotExist(oldKeyPath) {
+		return fmt.Errorf("SSH key [%s] does not exist", oldName)
+	}
+	if !utils.IsFileNotExist(newKeyPath) {
+		return fmt.Errorf("SSH key [%s] already exists", newName)
+	}
+
+	// the key is removed from the agent before the move, while ssh-add can still find it
+	executor := &interfaces.DefaultCommandExecutor{}
+	agentManager := ssh.NewAgentManager(executor)
+	loaded := false
+	if agentManager.IsKeyLoaded(oldKeyPath) {
+		if err := agentManager.RemoveFromAgent(sshPath, oldName); err != nil {
+			utils.PrintWarning("Warning: Failed to remove old key from ssh-agent: " + err.Error())
+		} else {
+			loaded = true
+		}
+	}
+
+	// every step is undone when a later one fails, so a failed rename can simply be run again
+	var undo []func()
+	rollback := func() {
+		for i := len(undo) - 1; i >= 0; i-- {
+			undo[i]()
+		}
+		utils.PrintWarning("Rename of [" + oldName + "] failed, the changes were undone")
+	}
+	if loaded {
+		undo = append(undo, func() { agentManager.AddToAgent(sshPath, oldName) })
+	}
+
+	if err := ssh.RenameKey(sshPath, oldName, newName); err != nil {
+		rollback()
+		return err
+	}
+	undo = append(undo, func() { ssh.RenameKey(sshPath, newName, oldName) })
+	utils.PrintSuccess("SSH key [" + oldName + "] renamed to [" + newName + "]")
+
+	replaced, err := ssh.ReplaceIdentityFile(sshPath, oldKeyPath, newKeyPath)
+	if err != nil {
+		rollback()
+		return err
+	}
+	undo = append(undo, func() { ssh.ReplaceIdentityFile(sshPath, newKeyPath, oldKeyPath) })
+	if replaced > 0 {
+		utils.PrintSuccess(fmt.Sprintf("%d IdentityFile entries now use [%s]", replaced, newName))
+	}
+
+	metadata, err := ssh.LoadMetadata(sshPath)
+	if err != nil {
+		rollback()
+		return err
+	}
+	if keyMetadata, exists := metadata.Get(oldName); exists {
+		metadata.Delete(oldName)
+		metadata.Set(newName, keyMetadata)
+		if err := metadata.Save(sshPath); err != nil {
+			// the metadata file is written atomically, so it still describes the old key
+			rollback()
+			return err
+		}
+	}
+
+	if loaded {
+		if err := agentManager.AddToAgent(sshPath, newName); err != nil {
+			utils.PrintWarning("Warning: Failed to add renamed key to ssh-agent: " + err.Error())
+		} else {
+			utils.PrintSuccess("SSH key [" + newName + "] reloaded in ssh-agent")
+		}
+	}
+
+	updateKeyReferences(sshPath, oldName, newName)
+
+	return nil
+}
+
+// updateKeyReferences points the git bindings and profiles using the old key at the new
+// key. .sshman files can be anywhere, so only the one of the current directory is checked.
+func updateKeyReferences(sshPath, oldName, newName string) {
+	oldKeyPath := filepath.Join(sshPath, oldName)
+	newKeyPath := filepath.Join(sshPath, newName)
+
+	bindingManager := git.NewBindingManager(&interfaces.DefaultCommandExecutor{}, filepath.Join(utils.ConfigDir(), "git"))
+	bindings, err := bindingManager.ReplaceKey(oldKeyPath, newKeyPath)
+	for _, binding := range bindings {
+		utils.PrintSuccess("Git binding for " + binding.Dir + " now uses [" + newName + "]")
+	}
+	if err != nil {
+		utils.PrintWarning("Warning: Failed to update git bindings, rebind them with: sshman git bind --dir <dir> --key " + newName + ": " + err.Error())
+	}
+
+	profiles, err := profile.Load(profile.Path())
+	if err != nil {
+		utils.PrintWarning("Warning: Failed to update profiles: " + err.Error())
+	} else if changed := profile.ReplaceKey(profiles, oldName, newName); len(changed) > 0 {
+		if err := profile.Save(profile.Path(), profiles); err != nil {
+			utils.PrintWarning("Warning: Failed to update profiles: " + err.Error())
+		} else {
+			utils.PrintSuccess("Profiles [" + strings.Join(changed, ", ") + "] now use [" + newName + "]")
+		}
+	}
+
+	if config, found, err := git.FindDirConfig("."); err == nil && found && utils.IsSamePath(config.KeyPath(sshPath), oldKeyPath) {
+		utils.PrintWarning(utils.ReplaceHomeDirWithTilde(config.Path) + " still selects [" + oldName + "], update it with: sshman env init " + newName + " --dir " + utils.ReplaceHomeDirWithTilde(filepath.Dir(config.Path)))
+	}
+}
@@ -1,19 +1,39 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"slices"
+	"time"
 
+	"github.com/residwi/sshman/internal/interfaces"
+	"github.com/residwi/sshman/internal/settings"
+	"github.com/residwi/sshman/internal/ssh"
 	"github.com/residwi/sshman/utils"
 	"github.com/spf13/cobra"
 )
 
+const (
+	skipExpiryWarningAnnotation = "sshman/skip-expiry-warning"
+	// createsSSHPathAnnotation marks commands that create the SSH path themselves
+	createsSSHPathAnnotation = "sshman/creates-ssh-path"
+)
+
+// errSilentExit makes sshman exit with a non-zero status without printing an error,
+// for commands whose output must stay machine readable
+var errSilentExit = errors.New("silent exit")
+
 var rootCmdFlags struct {
-	sshPath string
+	sshPath           string
+	expiryWarningDays int
+	json              bool
 }
 
+// userSettings are loaded before any init function, as the flags take their defaults from them
+var userSettings, userSettingsErr = loadSettings()
+
 var rootCmd = &cobra.Command{
 	Use:   "sshman",
 	Short: "manage SSH keys",
@@ -22,27 +42,135 @@ var rootCmd = &cobra.Command{
 		cmd.SilenceUsage = true
 		cmd.SilenceErrors = true
 
+		// completion requests print candidates to stdout, which warnings would corrupt
+		if cmd.Name() == cobra.ShellCompRequestCmd || cmd.Name() == cobra.ShellCompNoDescRequestCmd {
+			return nil
+		}
+
+		if userSettingsErr != nil {
+			return fmt.Errorf("%w. Check the settings with: sshman settings list", userSettingsErr)
+		}
+
 		sshPath, _ := cmd.Flags().GetString("ssh-path")
 		if utils.IsDirectoryNotExist(sshPath) {
+			if _, creates := cmd.Annotations[createsSSHPathAnnotation]; creates {
+				return nil
+			}
 			return fmt.Errorf("SSH path does not exist: %s", sshPath)
 		}
 
+		if _, skip := cmd.Annotations[skipExpiryWarningAnnotation]; !skip && !rootCmdFlags.json {
+			warnExpiringKeys(sshPath, rootCmdFlags.expiryWarningDays)
+		}
+
 		return nil
 	},
 }
 
 func Execute() {
 	if err := rootCmd.Execute(); err != nil {
-		utils.PrintError(err.Error())
+		if !errors.Is(err, errSilentExit) {
+			utils.PrintError(err.Error())
+		}
 		os.Exit(1)
 	}
 }
 
 func init() {
-	homeDir, _ := os.UserHomeDir()
-	sshDefaultPath := filepath.Join(homeDir, ".ssh")
+	ssh.ConfigFileName = userSettings.ConfigFile
+
+	rootCmd.PersistentFlags().StringVar(&rootCmdFlags.sshPath, "ssh-path", utils.ExpandTilde(userSettings.SSHPath), "Path to SSH directory")
+	rootCmd.PersistentFlags().IntVar(&rootCmdFlags.expiryWarningDays, "expiry-warning-days", 14, "Warn about keys expiring within this many days")
+	rootCmd.PersistentFlags().BoolVar(&rootCmdFlags.json, "json", userSettings.Output == settings.OutputJSON, "Print listings as JSON")
+
+	// runs once the flags are parsed, before the PersistentPreRunE of any command
+	cobra.OnInitialize(func() {
+		if rootCmdFlags.json {
+			utils.MessagesToStderr()
+		}
+	})
+}
+
+// loadSettings reads the settings file and SSHMAN_* variables. The defaults are used when
+// they are invalid, and the error is reported when a command runs.
+func loadSettings() (*settings.Settings, error) {
+	userSettings, err := settings.Load(settings.Path(), os.Getenv)
+	if err != nil {
+		return settings.Default(), err
+	}
+	return userSettings, nil
+}
+
+// warnExpiringKeys prints a warning for every key in use, loaded in the agent or used by an
+// IdentityFile, that has expired or expires soon. Keys that are only kept on disk stay quiet.
+func warnExpiringKeys(sshPath string, warningDays int) {
+	metadata, err := ssh.LoadMetadata(sshPath)
+	if err != nil {
+		return
+	}
+
+	now := time.Now()
+	warnWithin := time.Duration(warningDays) * 24 * time.Hour
+
+	var keyNames []string
+	for keyName, keyMetadata := range metadata.Keys {
+		status := keyMetadata.ExpiryStatus(now, warnWithin)
+		if (status != ssh.ExpiryExpired && status != ssh.ExpirySoon) || utils.IsFileNotExist(filepath.Join(sshPath, keyName)) {
+			continue
+		}
+		keyNames = append(keyNames, keyName)
+	}
+	if len(keyNames) == 0 {
+		return
+	}
+	slices.Sort(keyNames)
+
+	inUse := keysInUse(sshPath, keyNames)
+	for _, keyName := range keyNames {
+		if !inUse[keyName] {
+			continue
+		}
+
+		keyMetadata, _ := metadata.Get(keyName)
+		switch keyMetadata.ExpiryStatus(now, warnWithin) {
+		case ssh.ExpiryExpired:
+			utils.PrintWarning("SSH key [" + keyName + "] expired " + utils.FormatAge(now.Sub(keyMetadata.ExpiresAt)) + " ago, rotate it with: sshman rotate " + keyName)
+		case ssh.ExpirySoon:
+			utils.PrintWarning("SSH key [" + keyName + "] expires in " + utils.FormatAge(keyMetadata.ExpiresAt.Sub(now)) + ", rotate it with: sshman rotate " + keyName)
+		}
+	}
+}
+
+// keysInUse reports which of the keys are used by an IdentityFile or loaded in the agent.
+// The agent is only asked about keys that no IdentityFile uses.
+func keysInUse(sshPath string, keyNames []string) map[string]bool {
+	inUse := map[string]bool{}
+
+	entries, _ := ssh.ReadConfig(sshPath)
+	var unusedPaths []string
+	for _, keyName := range keyNames {
+		keyPath := filepath.Join(sshPath, keyName)
+		if len(ssh.FindEntriesByIdentityFile(entries, keyPath)) > 0 {
+			inUse[keyName] = true
+		} else {
+			unusedPaths = append(unusedPaths, keyPath)
+		}
+	}
+	if len(unusedPaths) == 0 {
+		return inUse
+	}
+
+	agentKeys, err := ssh.NewAgentManager(&interfaces.DefaultCommandExecutor{}).ListAgentKeys()
+	if err != nil {
+		return inUse
+	}
+	loaded, _ := ssh.MatchAgentKeys(agentKeys, unusedPaths)
+	for _, keyPath := range loaded {
+		keyName, _ := filepath.Rel(sshPath, keyPath)
+		inUse[keyName] = true
+	}
 
-	rootCmd.PersistentFlags().StringVar(&rootCmdFlags.sshPath, "ssh-path", sshDefaultPath, "Path to SSH directory")
+	return inUse
 }
 
 func isSupportedKeyType(keyType string) error {
@@ -0,0 +1,209 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/residwi/sshman/internal/interfaces"
+	"github.com/residwi/sshman/internal/provider"
+	"github.com/residwi/sshman/internal/ssh"
+	"github.com/residwi/sshman/utils"
+	"github.com/spf13/cobra"
+)
+
+var rotateCmdFlags struct {
+	token string
+}
+
+var rotateCmd = &cobra.Command{
+	Use:   "rotate [key-name]",
+	Short: "Rotate an SSH key",
+	Long: `Replace an SSH key with a new one of the same provider, purpose and type.
+Every IdentityFile, git binding and profile using the old key is pointed at the new
+key, the keys are swapped in the agent and the old key is archived. With a provider API token, the new public
+key is uploaded and the old one is revoked.`,
+	Args: cobra.ExactArgs(1),
+	Example: `sshman rotate id_ed25519_work
+GITHUB_TOKEN=ghp_xxx sshman rotate id_ed25519_work`,
+	RunE:              rotateSSHKey,
+	ValidArgsFunction: completeKeyNames,
+}
+
+func init() {
+	rootCmd.AddCommand(rotateCmd)
+
+	rotateCmd.Flags().StringVarP(&rotateCmdFlags.token, "token", "", "", "Provider API token (defaults to GITHUB_TOKEN or GITLAB_TOKEN)")
+}
+
+func rotateSSHKey(cmd *cobra.Command, args []string) error {
+	sshPath, _ := cmd.Flags().GetString("ssh-path")
+
+	_, err := rotateKey(sshPath, args[0], rotateCmdFlags.token)
+	return err
+}
+
+func rotateKey(sshPath, keyName, token string) (string, error) {
+	oldKeyPath := filepath.Join(sshPath, keyName)
+	if utils.IsFileNotExist(oldKeyPath) {
+		return "", fmt.Errorf("SSH key [%s] does not exist", keyName)
+	}
+
+	metadata, err := ssh.LoadMetadata(sshPath)
+	if err != nil {
+		return "", err
+	}
+
+	keyMetadata, exists := metadata.Get(keyName)
+	if !exists {
+		return "", fmt.Errorf("SSH key [%s] is not managed by sshman, adopt it first with: sshman adopt %s", keyName, keyName)
+	}
+
+	newKeyName := ssh.RotatedKeyName(keyMetadata.Type, keyMetadata.Purpose, time.Now())
+	if newKeyName == keyName {
+		newKeyName += "_" + strconv.FormatInt(time.Now().Unix(), 10)
+	}
+
+	oldPublicKey := readPublicKey(oldKeyPath + ".pub")
+
+	executor := &interfaces.DefaultCommandExecutor{}
+	keyGen := ssh.NewKeyGenerator(executor)
+	keyConfig := ssh.KeyConfig{
+		Name:     newKeyName,
+		Type:     keyMetadata.Type,
+		Email:    keyMetadata.Email,
+		Purpose:  keyMetadata.Purpose,
+		Provider: keyMetadata.Provider,
+		SSHPath:  sshPath,
+		Bits:     userSettings.RSABits,
+	}
+
+	if _, err := keyGen.GenerateKey(keyConfig); err != nil {
+		return "", err
+	}
+	utils.PrintSuccess("SSH key [" + newKeyName + "] created to replace [" + keyName + "]")
+
+	// every step is undone when a later one fails, so a failed rotation can simply be run again
+	newKeyPath := filepath.Join(sshPath, newKeyName)
+	var undo []func()
+	rollback := func() {
+		for i := len(undo) - 1; i >= 0; i-- {
+			undo[i]()
+		}
+		utils.PrintWarning("Rotation of [" + keyName + "] failed, the changes were undone")
+	}
+	undo = append(undo, func() {
+		for _, suffix := range []string{"", ".pub", "-cert.pub"} {
+			os.Remove(newKeyPath + suffix)
+		}
+	})
+
+	providerConfig, _ := provider.GetProviderConfig(keyMetadata.Provider)
+	if token == "" && providerConfig.TokenEnv != "" {
+		token = os.Getenv(providerConfig.TokenEnv)
+	}
+
+	// the new key is uploaded before anything uses it, the old one is revoked at the end
+	var keyAPI *provider.KeyAPI
+	if token != "" {
+		keyAPI, err = uploadProviderKey(keyMetadata.Provider, providerConfig.APIURL, token, newKeyName, newKeyPath)
+		if err != nil {
+			rollback()
+			return "", err
+		}
+		newPublicKey := readPublicKey(newKeyPath + ".pub")
+		undo = append(undo, func() {
+			if err := keyAPI.RemoveKey(newPublicKey); err != nil {
+				utils.PrintWarning("Warning: Failed to remove [" + newKeyName + "] from " + keyMetadata.Provider + ", remove it manually: " + err.Error())
+			}
+		})
+	} else if providerConfig.APIURL != "" {
+		utils.PrintWarning("Remember to upload [" + newKeyName + ".pub] to " + keyMetadata.Provider + " and revoke the old key")
+	}
+
+	replaced, err := ssh.ReplaceIdentityFile(sshPath, oldKeyPath, newKeyPath)
+	if err != nil {
+		rollback()
+		return "", err
+	}
+	undo = append(undo, func() { ssh.ReplaceIdentityFile(sshPath, newKeyPath, oldKeyPath) })
+	utils.PrintSuccess(fmt.Sprintf("%d IdentityFile entries now use [%s]", replaced, newKeyName))
+
+	agentManager := ssh.NewAgentManager(executor)
+	if agentManager.IsKeyLoaded(oldKeyPath) {
+		if err := agentManager.RemoveFromAgent(sshPath, keyName); err != nil {
+			utils.PrintWarning("Warning: Failed to remove old key from ssh-agent: " + err.Error())
+		}
+		if err := agentManager.AddToAgent(sshPath, newKeyName); err != nil {
+			utils.PrintWarning("Warning: Failed to add new key to ssh-agent: " + err.Error())
+		} else {
+			utils.PrintSuccess("SSH key [" + newKeyName + "] replaced [" + keyName + "] in ssh-agent")
+		}
+		undo = append(undo, func() {
+			agentManager.RemoveFromAgent(sshPath, newKeyName)
+			agentManager.AddToAgent(sshPath, keyName)
+		})
+	}
+
+	archivedPath, err := ssh.ArchiveKey(sshPath, keyName)
+	if err != nil {
+		rollback()
+		return "", err
+	}
+	undo = append(undo, func() { ssh.UnarchiveKey(sshPath, keyName, archivedPath) })
+	utils.PrintSuccess("SSH key [" + keyName + "] archived to " + utils.ReplaceHomeDirWithTilde(archivedPath))
+
+	metadata.Delete(keyName)
+	if !keyMetadata.ExpiresAt.IsZero() {
+		// the new key gets the same lifetime as the one it replaces
+		keyMetadata.ExpiresAt = time.Now().Add(keyMetadata.ExpiresAt.Sub(keyMetadata.CreatedAt))
+	}
+	keyMetadata.CreatedAt = time.Now()
+	keyMetadata.RotatedFrom = keyName
+	metadata.Set(newKeyName, keyMetadata)
+	if err := metadata.Save(sshPath); err != nil {
+		// the metadata file is written atomically, so it still describes the old key
+		rollback()
+		return "", err
+	}
+
+	updateKeyReferences(sshPath, keyName, newKeyName)
+
+	if keyAPI != nil {
+		if err := revokeProviderKey(keyAPI, keyMetadata.Provider, oldPublicKey); err != nil {
+			utils.PrintWarning("Warning: " + err.Error())
+		}
+	}
+
+	return newKeyName, nil
+}
+
+func uploadProviderKey(providerName, apiURL, token, newKeyName, newKeyPath string) (*provider.KeyAPI, error) {
+	keyAPI, err := provider.NewKeyAPI(providerName, apiURL, token)
+	if err != nil {
+		return nil, err
+	}
+
+	newPublicKey := readPublicKey(newKeyPath + ".pub")
+	if err := keyAPI.AddKey("sshman "+newKeyName, newPublicKey); err != nil {
+		return nil, err
+	}
+	utils.PrintSuccess("Public key [" + newKeyName + "] uploaded to " + providerName)
+
+	return keyAPI, nil
+}
+
+func revokeProviderKey(keyAPI *provider.KeyAPI, providerName, oldPublicKey string) error {
+	if oldPublicKey == "" {
+		return fmt.Errorf("old public key is unknown, revoke it on %s manually", providerName)
+	}
+
+	if err := keyAPI.RemoveKey(oldPublicKey); err != nil {
+		return err
+	}
+	utils.PrintSuccess("Old public key revoked on " + providerName)
+
+	return nil
+}
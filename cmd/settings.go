@@ -0,0 +1,147 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/residwi/sshman/internal/settings"
+	"github.com/residwi/sshman/utils"
+	"github.com/spf13/cobra"
+)
+
+var settingsCmd = &cobra.Command{
+	Use:   "settings",
+	Short: "Manage sshman settings",
+	Long: `Get and set the defaults sshman uses when a flag is not given. Settings are stored in
+config.yaml in the sshman configuration directory ($XDG_CONFIG_HOME/sshman or
+~/.config/sshman), and every setting can be overridden with an SSHMAN_<SETTING>
+environment variable, e.g. SSHMAN_EMAIL.`,
+	// settings must stay manageable when the settings file is invalid
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		cmd.SilenceErrors = true
+		return nil
+	},
+}
+
+var settingsListCmd = &cobra.Command{
+	Use:     "list",
+	Short:   "List all settings with their values",
+	Aliases: []string{"ls"},
+	Args:    cobra.NoArgs,
+	RunE:    listSettings,
+}
+
+var settingsGetCmd = &cobra.Command{
+	Use:               "get <setting>",
+	Short:             "Print the value of a setting",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeSettingKeys,
+	Example:           `sshman settings get email`,
+	RunE:              getSetting,
+}
+
+var settingsSetCmd = &cobra.Command{
+	Use:   "set <setting> <value>",
+	Short: "Change a setting in the settings file",
+	Long: `Change a setting in the settings file. An empty value resets the setting to its
+default.`,
+	Args:              cobra.ExactArgs(2),
+	ValidArgsFunction: completeSettingKeys,
+	Example: `sshman settings set email alice@example.com
+sshman settings set key_type rsa
+sshman settings set output json
+sshman settings set config_file config.d/sshman
+sshman settings set email ''`,
+	RunE: setSetting,
+}
+
+func init() {
+	rootCmd.AddCommand(settingsCmd)
+	settingsCmd.AddCommand(settingsListCmd, settingsGetCmd, settingsSetCmd)
+}
+
+func listSettings(cmd *cobra.Command, args []string) error {
+	userSettings, problems, err := settings.LoadLenient(settings.Path(), os.Getenv)
+	if err != nil {
+		return err
+	}
+	for _, problem := range problems {
+		utils.PrintWarning("Warning: " + problem.Error() + " (ignored)")
+	}
+
+	headers := []string{"SETTING", "VALUE", "SOURCE", "DESCRIPTION"}
+	var rows [][]string
+	for _, key := range settings.Keys() {
+		value, _ := userSettings.Get(key)
+
+		source := userSettings.Source(key)
+		switch source {
+		case settings.SourceFile:
+			source = utils.ReplaceHomeDirWithTilde(settings.Path())
+		case settings.SourceEnv:
+			source = settings.EnvName(key)
+		}
+
+		rows = append(rows, []string{key, valueOrDash(value), source, settings.Description(key)})
+	}
+
+	return printRows(headers, rows)
+}
+
+func getSetting(cmd *cobra.Command, args []string) error {
+	userSettings, err := settings.Load(settings.Path(), os.Getenv)
+	if err != nil {
+		return err
+	}
+
+	value, err := userSettings.Get(args[0])
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(value)
+	return nil
+}
+
+func setSetting(cmd *cobra.Command, args []string) error {
+	key, value := args[0], args[1]
+
+	// an invalid file must not stop it from being fixed, invalid entries are dropped on save
+	fileSettings, problems, err := settings.LoadFileLenient(settings.Path())
+	if err != nil {
+		return err
+	}
+
+	if err := fileSettings.Set(key, value); err != nil {
+		return err
+	}
+
+	if err := fileSettings.Save(settings.Path()); err != nil {
+		return err
+	}
+	for _, problem := range problems {
+		utils.PrintWarning("Warning: " + problem.Error() + " (removed from the settings file)")
+	}
+
+	newValue, _ := fileSettings.Get(key)
+	utils.PrintSuccess("Setting [" + key + "] set to " + valueOrDash(newValue) + " in " + utils.ReplaceHomeDirWithTilde(settings.Path()))
+
+	if envValue := os.Getenv(settings.EnvName(key)); envValue != "" {
+		utils.PrintWarning(settings.EnvName(key) + " is set and overrides it with " + envValue)
+	}
+
+	return nil
+}
+
+func completeSettingKeys(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	var completions []string
+	for _, key := range settings.Keys() {
+		completions = append(completions, cobra.CompletionWithDesc(key, settings.Description(key)))
+	}
+	return completions, cobra.ShellCompDirectiveNoFileComp
+}
@@ -0,0 +1,131 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/residwi/sshman/internal/interfaces"
+	"github.com/residwi/sshman/internal/ssh"
+	"github.com/residwi/sshman/utils"
+	"github.com/spf13/cobra"
+)
+
+var testCmdFlags struct {
+	all     bool
+	timeout time.Duration
+}
+
+var testCmd = &cobra.Command{
+	Use:   "test [alias|key-name]",
+	Short: "Test SSH connectivity",
+	Long: `Test that a host alias, or every alias using a key, accepts the configured SSH key.
+Provider greetings are parsed to report the authenticated account.`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if testCmdFlags.all {
+			return cobra.NoArgs(cmd, args)
+		}
+		return cobra.ExactArgs(1)(cmd, args)
+	},
+	Example: `sshman test github-work
+sshman test id_ed25519_work
+sshman test --all`,
+	RunE:              testConnection,
+	ValidArgsFunction: completeAliasOrKeyName,
+}
+
+func init() {
+	rootCmd.AddCommand(testCmd)
+
+	testCmd.Flags().BoolVarP(&testCmdFlags.all, "all", "a", false, "Test every host alias in the SSH config")
+	testCmd.Flags().DurationVarP(&testCmdFlags.timeout, "timeout", "", 10*time.Second, "Connection timeout")
+}
+
+func testConnection(cmd *cobra.Command, args []string) error {
+	sshPath, _ := cmd.Flags().GetString("ssh-path")
+
+	entries, err := ssh.ReadConfig(sshPath)
+	if err != nil {
+		return err
+	}
+
+	var hosts []string
+	if testCmdFlags.all {
+		for _, entry := range entries {
+			if !slices.Contains(hosts, entry.Host) {
+				hosts = append(hosts, entry.Host)
+			}
+		}
+
+		if len(hosts) == 0 && !rootCmdFlags.json {
+			utils.PrintSuccess("No host aliases found in SSH config")
+			return nil
+		}
+	} else {
+		hosts, err = resolveTestHosts(sshPath, entries, args[0])
+		if err != nil {
+			return err
+		}
+	}
+
+	executor := &interfaces.DefaultCommandExecutor{}
+	tester := ssh.NewConnectionTester(executor, testCmdFlags.timeout)
+	results := tester.TestAll(hosts)
+
+	headers := []string{"HOST", "STATUS", "DETAILS"}
+	var rows [][]string
+	failed := 0
+	for _, result := range results {
+		details := result.Message
+		if result.Account != "" {
+			details = "authenticated as " + result.Account
+		}
+
+		if result.Status != ssh.StatusSuccess {
+			failed++
+		}
+
+		rows = append(rows, []string{result.Host, result.Status, details})
+	}
+
+	if err := printRows(headers, rows); err != nil {
+		return err
+	}
+
+	if failed > 0 && rootCmdFlags.json {
+		return errSilentExit
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d connection tests failed", failed, len(results))
+	}
+
+	return nil
+}
+
+// resolveTestHosts maps the argument to host aliases: an alias is used as is,
+// while a key name is expanded to every alias using that key
+func resolveTestHosts(sshPath string, entries []ssh.ConfigEntry, target string) ([]string, error) {
+	for _, entry := range entries {
+		if entry.Host == target {
+			return []string{target}, nil
+		}
+	}
+
+	keyPath := filepath.Join(sshPath, target)
+	if utils.IsFileNotExist(keyPath) {
+		// not an alias or key, let ssh resolve it as a hostname
+		return []string{target}, nil
+	}
+
+	var hosts []string
+	for _, entry := range ssh.FindEntriesByIdentityFile(entries, keyPath) {
+		hosts = append(hosts, entry.Host)
+	}
+
+	if len(hosts) == 0 {
+		return nil, fmt.Errorf("no host alias in SSH config uses key [%s]", target)
+	}
+
+	return hosts, nil
+}
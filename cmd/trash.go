@@ -0,0 +1,154 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/residwi/sshman/internal/ssh"
+	"github.com/residwi/sshman/utils"
+	"github.com/spf13/cobra"
+)
+
+var trashPurgeCmdFlags struct {
+	all       bool
+	olderThan string
+	yes       bool
+}
+
+var trashCmd = &cobra.Command{
+	Use:   "trash",
+	Short: "Manage deleted SSH keys",
+	Long:  `List, restore and permanently delete SSH keys removed with 'sshman delete'.`,
+}
+
+var trashListCmd = &cobra.Command{
+	Use:     "list",
+	Short:   "List deleted SSH keys",
+	Args:    cobra.NoArgs,
+	Example: `sshman trash list`,
+	RunE:    listTrash,
+}
+
+var trashRestoreCmd = &cobra.Command{
+	Use:     "restore <id>",
+	Short:   "Restore a deleted SSH key with its Host entries and metadata",
+	Args:    cobra.ExactArgs(1),
+	Example: `sshman trash restore 20240314093000-id_ed25519_work`,
+	RunE:    restoreFromTrash,
+}
+
+var trashPurgeCmd = &cobra.Command{
+	Use:   "purge [id...]",
+	Short: "Permanently delete SSH keys from the trash",
+	Example: `sshman trash purge 20240314093000-id_ed25519_work
+sshman trash purge --older-than 30d
+sshman trash purge --all --yes`,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) == 0 && !trashPurgeCmdFlags.all && trashPurgeCmdFlags.olderThan == "" {
+			return fmt.Errorf("specify trash entries to purge, --older-than or --all")
+		}
+
+		if trashPurgeCmdFlags.olderThan != "" {
+			if _, err := utils.ParseDuration(trashPurgeCmdFlags.olderThan); err != nil {
+				return fmt.Errorf("invalid --older-than value: %s. Use a duration such as 30d or 4w", trashPurgeCmdFlags.olderThan)
+			}
+		}
+
+		return nil
+	},
+	RunE: purgeTrash,
+}
+
+func init() {
+	rootCmd.AddCommand(trashCmd)
+	trashCmd.AddCommand(trashListCmd)
+	trashCmd.AddCommand(trashRestoreCmd)
+	trashCmd.AddCommand(trashPurgeCmd)
+
+	trashPurgeCmd.Flags().BoolVarP(&trashPurgeCmdFlags.all, "all", "", false, "Purge every deleted key")
+	trashPurgeCmd.Flags().StringVarP(&trashPurgeCmdFlags.olderThan, "older-than", "", "", "Purge keys deleted longer ago than this, e.g. 30d")
+	trashPurgeCmd.Flags().BoolVarP(&trashPurgeCmdFlags.yes, "yes", "y", false, "Purge without asking for confirmation")
+}
+
+func listTrash(cmd *cobra.Command, args []string) error {
+	sshPath, _ := cmd.Flags().GetString("ssh-path")
+
+	entries, err := ssh.ListTrash(sshPath)
+	if err != nil {
+		return err
+	}
+
+	if len(entries) == 0 && !rootCmdFlags.json {
+		utils.PrintSuccess("Trash is empty")
+		return nil
+	}
+
+	headers := []string{"ID", "KEY", "DELETED", "HOSTS"}
+	var rows [][]string
+	for _, entry := range entries {
+		rows = append(rows, []string{entry.ID, entry.KeyName, entry.DeletedAt.Format("2006-01-02 15:04"), valueOrDash(strings.Join(entry.Hosts, ", "))})
+	}
+
+	return printRows(headers, rows)
+}
+
+func restoreFromTrash(cmd *cobra.Command, args []string) error {
+	sshPath, _ := cmd.Flags().GetString("ssh-path")
+
+	entry, err := ssh.RestoreFromTrash(sshPath, args[0])
+	if err != nil {
+		return err
+	}
+
+	utils.PrintSuccess("SSH key [" + entry.KeyName + "] restored")
+	if len(entry.Hosts) > 0 {
+		utils.PrintSuccess("SSH config restored for hosts [" + strings.Join(entry.Hosts, ", ") + "]")
+	}
+
+	return nil
+}
+
+func purgeTrash(cmd *cobra.Command, args []string) error {
+	sshPath, _ := cmd.Flags().GetString("ssh-path")
+
+	ids := args
+	if trashPurgeCmdFlags.all || trashPurgeCmdFlags.olderThan != "" {
+		entries, err := ssh.ListTrash(sshPath)
+		if err != nil {
+			return err
+		}
+
+		olderThan, _ := utils.ParseDuration(trashPurgeCmdFlags.olderThan)
+		for _, entry := range entries {
+			if trashPurgeCmdFlags.all || time.Since(entry.DeletedAt) > olderThan {
+				ids = append(ids, entry.ID)
+			}
+		}
+	}
+
+	if len(ids) == 0 {
+		utils.PrintSuccess("Nothing to purge")
+		return nil
+	}
+
+	if !trashPurgeCmdFlags.yes {
+		confirmed, err := utils.Confirm(fmt.Sprintf("Permanently delete %d SSH keys from the trash?", len(ids)), false)
+		if err != nil {
+			return err
+		}
+		if !confirmed {
+			utils.PrintWarning("Aborted, nothing was purged")
+			return nil
+		}
+	}
+
+	for _, id := range ids {
+		if err := ssh.PurgeTrash(sshPath, id); err != nil {
+			return err
+		}
+		utils.PrintSuccess("Trash entry [" + id + "] purged")
+	}
+
+	return nil
+}
@@ -0,0 +1,150 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/residwi/sshman/internal/clipboard"
+	"github.com/residwi/sshman/internal/interfaces"
+	"github.com/residwi/sshman/internal/ssh"
+	"github.com/residwi/sshman/internal/tui"
+	"github.com/residwi/sshman/utils"
+	"github.com/spf13/cobra"
+)
+
+var uiCmd = &cobra.Command{
+	Use:   "ui",
+	Short: "Browse and manage SSH keys in a terminal UI",
+	Long: `Open an interactive terminal UI listing the SSH keys with their type, agent status,
+host aliases and age. Keys can be loaded into or removed from the agent, tested,
+rotated and deleted, and their public key copied to the clipboard.`,
+	Args:        cobra.NoArgs,
+	Annotations: map[string]string{skipExpiryWarningAnnotation: "true"},
+	Example:     `sshman ui`,
+	RunE:        runUI,
+}
+
+func init() {
+	rootCmd.AddCommand(uiCmd)
+}
+
+func runUI(cmd *cobra.Command, args []string) error {
+	sshPath, _ := cmd.Flags().GetString("ssh-path")
+
+	if !utils.IsTerminal() {
+		return fmt.Errorf("sshman ui requires a terminal, use 'sshman list' instead")
+	}
+
+	executor := &interfaces.DefaultCommandExecutor{}
+	actions := &uiActions{
+		sshPath:      sshPath,
+		agentManager: ssh.NewAgentManager(executor),
+		clipboard:    clipboard.NewClipboard(executor),
+		tester:       ssh.NewConnectionTester(executor, 10*time.Second),
+	}
+
+	if _, err := tea.NewProgram(tui.NewModel(actions), tea.WithAltScreen()).Run(); err != nil {
+		return fmt.Errorf("failed to run terminal UI: %w", err)
+	}
+
+	return nil
+}
+
+// uiActions implements the terminal UI's actions with the same code as the CLI commands
+type uiActions struct {
+	sshPath      string
+	agentManager *ssh.AgentManager
+	clipboard    *clipboard.Clipboard
+	tester       *ssh.ConnectionTester
+}
+
+func (a *uiActions) LoadKeys() ([]tui.Key, error) {
+	privateKeys, err := findPrivateKeys(a.sshPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list SSH keys: %w", err)
+	}
+
+	var agentKeys []string
+	if a.agentManager.IsAgentRunning() {
+		agentKeys, _ = a.agentManager.ListAgentKeys()
+	}
+	loadedKeys, _ := ssh.MatchAgentKeys(agentKeys, privateKeys)
+
+	metadata, err := ssh.LoadMetadata(a.sshPath)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := ssh.ReadConfig(a.sshPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var keys []tui.Key
+	for _, privateKey := range privateKeys {
+		keyName, _ := filepath.Rel(a.sshPath, privateKey)
+		key := tui.Key{
+			Name:        keyName,
+			Path:        privateKey,
+			Type:        getKeyInfo(privateKey),
+			Age:         getKeyAge(privateKey, metadata),
+			Fingerprint: ssh.KeyFingerprint(privateKey),
+			Loaded:      slices.Contains(loadedKeys, privateKey),
+		}
+
+		for _, entry := range ssh.FindEntriesByIdentityFile(entries, privateKey) {
+			key.Aliases = append(key.Aliases, entry.Host)
+		}
+
+		if keyMetadata, exists := metadata.Get(keyName); exists {
+			key.Email = keyMetadata.Email
+			key.Provider = keyMetadata.Provider
+			key.Purpose = keyMetadata.Purpose
+			if !keyMetadata.ExpiresAt.IsZero() {
+				status := keyMetadata.ExpiryStatus(time.Now(), time.Duration(rootCmdFlags.expiryWarningDays)*24*time.Hour)
+				key.Expires = keyMetadata.ExpiresAt.Format("2006-01-02") + " (" + status + ")"
+			}
+		} else {
+			key.Email, _ = ssh.ReadPublicKeyComment(privateKey + ".pub")
+		}
+
+		keys = append(keys, key)
+	}
+
+	return keys, nil
+}
+
+func (a *uiActions) CopyPublicKey(key tui.Key) (string, error) {
+	if utils.IsFileNotExist(key.Path + ".pub") {
+		return "", fmt.Errorf("public key of [%s] does not exist", key.Name)
+	}
+
+	return a.clipboard.Copy(readPublicKey(key.Path + ".pub"))
+}
+
+func (a *uiActions) TestKey(key tui.Key) ([]ssh.ConnectionResult, error) {
+	if len(key.Aliases) == 0 {
+		return nil, fmt.Errorf("no Host entries use [%s], nothing to test", key.Name)
+	}
+
+	return a.tester.TestAll(key.Aliases), nil
+}
+
+func (a *uiActions) ToggleAgent(key tui.Key) error {
+	if key.Loaded {
+		return a.agentManager.RemoveFromAgent(a.sshPath, key.Name)
+	}
+	return a.agentManager.AddToAgent(a.sshPath, key.Name)
+}
+
+func (a *uiActions) Rotate(key tui.Key) error {
+	_, err := rotateKey(a.sshPath, key.Name, "")
+	return err
+}
+
+func (a *uiActions) Delete(key tui.Key) error {
+	return deleteKey(a.sshPath, key.Name, false, false)
+}
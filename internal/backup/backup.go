@@ -0,0 +1,354 @@
+package backup
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+	"github.com/residwi/sshman/internal/ssh"
+	"github.com/residwi/sshman/utils"
+)
+
+const (
+	KindPrivateKey = "private-key"
+	KindPublicKey  = "public-key"
+	KindConfig     = "config"
+	KindMetadata   = "metadata"
+	KindHosts      = "hosts"
+)
+
+const (
+	formatVersion = 1
+	manifestName  = "manifest.json"
+	ageHeader     = "age-encryption.org/v1"
+)
+
+var ErrPassphraseRequired = errors.New("archive is encrypted, a passphrase is required")
+
+type File struct {
+	Name string `json:"name"`
+	Kind string `json:"kind"`
+}
+
+type Manifest struct {
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"created_at"`
+	Files     []File    `json:"files"`
+}
+
+// Archive holds SSH files in memory, keyed by their path relative to the SSH directory
+type Archive struct {
+	Manifest Manifest
+	Contents map[string][]byte
+}
+
+type RestoreResult struct {
+	Restored []File
+	Skipped  []File
+}
+
+func NewArchive() *Archive {
+	return &Archive{
+		Manifest: Manifest{Version: formatVersion, CreatedAt: time.Now()},
+		Contents: map[string][]byte{},
+	}
+}
+
+func (a *Archive) Add(name, kind string, data []byte) {
+	if _, exists := a.Contents[name]; !exists {
+		a.Manifest.Files = append(a.Manifest.Files, File{Name: name, Kind: kind})
+	}
+	a.Contents[name] = data
+}
+
+func (a *Archive) AddFile(sshPath, name, kind string) error {
+	data, err := os.ReadFile(filepath.Join(sshPath, name))
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", name, err)
+	}
+
+	a.Add(name, kind, data)
+	return nil
+}
+
+// CollectFiles builds an archive of the private keys with their public keys, the SSH
+// config files and sshman's metadata
+func CollectFiles(sshPath string, privateKeys []string) (*Archive, error) {
+	archive := NewArchive()
+
+	for _, privateKey := range privateKeys {
+		name, err := filepath.Rel(sshPath, privateKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve %s: %w", privateKey, err)
+		}
+
+		if err := archive.AddFile(sshPath, name, KindPrivateKey); err != nil {
+			return nil, err
+		}
+
+		for _, suffix := range []string{".pub", ssh.CertSuffix} {
+			if utils.IsFileNotExist(privateKey + suffix) {
+				continue
+			}
+			if err := archive.AddFile(sshPath, name+suffix, KindPublicKey); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// the main config and the file sshman keeps its Host entries in, when that is another one
+	for _, configPath := range ssh.ConfigFilePaths(sshPath) {
+		if utils.IsFileNotExist(configPath) {
+			continue
+		}
+		name, err := filepath.Rel(sshPath, configPath)
+		if err != nil || !filepath.IsLocal(name) {
+			return nil, fmt.Errorf("SSH config file %s is outside the SSH directory and cannot be backed up, use a config_file relative to it", configPath)
+		}
+		if err := archive.AddFile(sshPath, name, KindConfig); err != nil {
+			return nil, err
+		}
+	}
+
+	if !utils.IsFileNotExist(filepath.Join(sshPath, ssh.MetadataFileName)) {
+		if err := archive.AddFile(sshPath, ssh.MetadataFileName, KindMetadata); err != nil {
+			return nil, err
+		}
+	}
+
+	return archive, nil
+}
+
+// Write writes the archive as a gzipped tarball, encrypted with the passphrase unless it is empty
+func (a *Archive) Write(w io.Writer, passphrase string) error {
+	var armored io.WriteCloser
+	var encrypted io.WriteCloser
+
+	if passphrase != "" {
+		recipient, err := age.NewScryptRecipient(passphrase)
+		if err != nil {
+			return fmt.Errorf("failed to create encryption key: %w", err)
+		}
+
+		armored = armor.NewWriter(w)
+		encrypted, err = age.Encrypt(armored, recipient)
+		if err != nil {
+			return fmt.Errorf("failed to encrypt archive: %w", err)
+		}
+		w = encrypted
+	}
+
+	gzipWriter := gzip.NewWriter(w)
+	tarWriter := tar.NewWriter(gzipWriter)
+
+	manifest, err := json.MarshalIndent(a.Manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode manifest: %w", err)
+	}
+
+	if err := writeTarFile(tarWriter, manifestName, manifest); err != nil {
+		return err
+	}
+
+	for _, file := range a.Manifest.Files {
+		if err := writeTarFile(tarWriter, file.Name, a.Contents[file.Name]); err != nil {
+			return err
+		}
+	}
+
+	if err := tarWriter.Close(); err != nil {
+		return fmt.Errorf("failed to write archive: %w", err)
+	}
+	if err := gzipWriter.Close(); err != nil {
+		return fmt.Errorf("failed to write archive: %w", err)
+	}
+
+	if encrypted != nil {
+		if err := encrypted.Close(); err != nil {
+			return fmt.Errorf("failed to encrypt archive: %w", err)
+		}
+		if err := armored.Close(); err != nil {
+			return fmt.Errorf("failed to encrypt archive: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func ReadArchive(r io.Reader, passphrase string) (*Archive, error) {
+	reader := bufio.NewReader(r)
+
+	if IsEncrypted(reader) {
+		if passphrase == "" {
+			return nil, ErrPassphraseRequired
+		}
+
+		identity, err := age.NewScryptIdentity(passphrase)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create decryption key: %w", err)
+		}
+
+		decrypted, err := age.Decrypt(armor.NewReader(reader), identity)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt archive, is the passphrase correct? %w", err)
+		}
+		reader = bufio.NewReader(decrypted)
+	}
+
+	gzipReader, err := gzip.NewReader(reader)
+	if err != nil {
+		return nil, fmt.Errorf("not an sshman archive: %w", err)
+	}
+	defer gzipReader.Close()
+
+	archive := &Archive{Contents: map[string][]byte{}}
+	var manifest []byte
+
+	tarReader := tar.NewReader(gzipReader)
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read archive: %w", err)
+		}
+
+		data, err := io.ReadAll(tarReader)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s from archive: %w", header.Name, err)
+		}
+
+		if header.Name == manifestName {
+			manifest = data
+			continue
+		}
+		archive.Contents[header.Name] = data
+	}
+
+	if manifest == nil {
+		return nil, fmt.Errorf("not an sshman archive: manifest is missing")
+	}
+
+	if err := json.Unmarshal(manifest, &archive.Manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse manifest: %w", err)
+	}
+
+	if archive.Manifest.Version > formatVersion {
+		return nil, fmt.Errorf("archive version %d is newer than supported version %d", archive.Manifest.Version, formatVersion)
+	}
+
+	for _, file := range archive.Manifest.Files {
+		if !filepath.IsLocal(file.Name) {
+			return nil, fmt.Errorf("archive contains an unsafe path: %s", file.Name)
+		}
+		if _, exists := archive.Contents[file.Name]; !exists {
+			return nil, fmt.Errorf("archive is missing %s", file.Name)
+		}
+	}
+
+	return archive, nil
+}
+
+// IsEncrypted reports whether the archive is encrypted, without consuming the reader
+func IsEncrypted(reader *bufio.Reader) bool {
+	header, _ := reader.Peek(64)
+	return bytes.Contains(header, []byte(ageHeader)) || bytes.HasPrefix(header, []byte(armor.Header))
+}
+
+// Restore writes the archived files to the SSH directory with the permissions ssh
+// expects. Existing files are skipped unless force is set, and metadata is merged
+// key by key so keys already on this machine keep their records.
+func (a *Archive) Restore(sshPath string, force bool) (RestoreResult, error) {
+	var result RestoreResult
+
+	for _, file := range a.Manifest.Files {
+		if file.Kind == KindMetadata || file.Kind == KindHosts {
+			continue
+		}
+
+		filePath := filepath.Join(sshPath, file.Name)
+		if !force && !utils.IsFileNotExist(filePath) {
+			result.Skipped = append(result.Skipped, file)
+			continue
+		}
+
+		if err := os.MkdirAll(filepath.Dir(filePath), 0700); err != nil {
+			return result, fmt.Errorf("failed to create directory for %s: %w", file.Name, err)
+		}
+
+		if err := utils.WriteFileAtomic(filePath, a.Contents[file.Name], fileMode(file.Kind)); err != nil {
+			return result, fmt.Errorf("failed to restore %s: %w", file.Name, err)
+		}
+
+		result.Restored = append(result.Restored, file)
+	}
+
+	for _, file := range a.Manifest.Files {
+		if file.Kind == KindMetadata {
+			if err := a.restoreMetadata(sshPath, file, force); err != nil {
+				return result, err
+			}
+			result.Restored = append(result.Restored, file)
+		}
+	}
+
+	return result, nil
+}
+
+func (a *Archive) restoreMetadata(sshPath string, file File, force bool) error {
+	var archived ssh.Metadata
+	if err := json.Unmarshal(a.Contents[file.Name], &archived); err != nil {
+		return fmt.Errorf("failed to parse archived metadata: %w", err)
+	}
+
+	metadata, err := ssh.LoadMetadata(sshPath)
+	if err != nil {
+		return err
+	}
+
+	for keyName, keyMetadata := range archived.Keys {
+		if _, exists := metadata.Get(keyName); exists && !force {
+			continue
+		}
+		metadata.Set(keyName, keyMetadata)
+	}
+
+	return metadata.Save(sshPath)
+}
+
+func writeTarFile(tarWriter *tar.Writer, name string, data []byte) error {
+	header := &tar.Header{
+		Name:    name,
+		Mode:    0600,
+		Size:    int64(len(data)),
+		ModTime: time.Now(),
+	}
+
+	if err := tarWriter.WriteHeader(header); err != nil {
+		return fmt.Errorf("failed to write %s to archive: %w", name, err)
+	}
+
+	if _, err := tarWriter.Write(data); err != nil {
+		return fmt.Errorf("failed to write %s to archive: %w", name, err)
+	}
+
+	return nil
+}
+
+func fileMode(kind string) os.FileMode {
+	if kind == KindPublicKey {
+		return 0644
+	}
+	return 0600
+}
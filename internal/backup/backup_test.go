@@ -0,0 +1,190 @@
+package backup
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/residwi/sshman/internal/ssh"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupSSHDir(t *testing.T) (string, []string) {
+	t.Helper()
+
+	sshPath := t.TempDir()
+	privateKey := filepath.Join(sshPath, "id_ed25519_work")
+	require.NoError(t, os.WriteFile(privateKey, []byte("private"), 0600))
+	require.NoError(t, os.WriteFile(privateKey+".pub", []byte("ssh-ed25519 AAAA work@company.com"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(sshPath, "config"), []byte("Host github-work\n"), 0600))
+
+	metadata, err := ssh.LoadMetadata(sshPath)
+	require.NoError(t, err)
+	metadata.Set("id_ed25519_work", ssh.KeyMetadata{Type: "ed25519", Provider: "github", CreatedAt: time.Now()})
+	require.NoError(t, metadata.Save(sshPath))
+
+	return sshPath, []string{privateKey}
+}
+
+func TestCollectFiles_Success(t *testing.T) {
+	sshPath, privateKeys := setupSSHDir(t)
+	require.NoError(t, os.WriteFile(filepath.Join(sshPath, "id_ed25519_work"+ssh.CertSuffix), []byte("cert"), 0644))
+
+	archive, err := CollectFiles(sshPath, privateKeys)
+	require.NoError(t, err)
+
+	assert.ElementsMatch(t, []File{
+		{Name: "id_ed25519_work", Kind: KindPrivateKey},
+		{Name: "id_ed25519_work.pub", Kind: KindPublicKey},
+		{Name: "id_ed25519_work" + ssh.CertSuffix, Kind: KindPublicKey},
+		{Name: "config", Kind: KindConfig},
+		{Name: ssh.MetadataFileName, Kind: KindMetadata},
+	}, archive.Manifest.Files)
+	assert.Equal(t, []byte("private"), archive.Contents["id_ed25519_work"])
+}
+
+func TestCollectFiles_IncludedConfigFile_RoundTrip(t *testing.T) {
+	sshPath, privateKeys := setupSSHDir(t)
+	ssh.ConfigFileName = filepath.Join("config.d", "sshman")
+	t.Cleanup(func() { ssh.ConfigFileName = "config" })
+	require.NoError(t, os.MkdirAll(filepath.Join(sshPath, "config.d"), 0700))
+	require.NoError(t, os.WriteFile(filepath.Join(sshPath, "config.d", "sshman"), []byte("Host gitlab-work\n"), 0600))
+
+	archive, err := CollectFiles(sshPath, privateKeys)
+	require.NoError(t, err)
+
+	assert.Contains(t, archive.Manifest.Files, File{Name: "config", Kind: KindConfig})
+	assert.Contains(t, archive.Manifest.Files, File{Name: filepath.Join("config.d", "sshman"), Kind: KindConfig})
+
+	var buffer bytes.Buffer
+	require.NoError(t, archive.Write(&buffer, ""))
+	restored, err := ReadArchive(&buffer, "")
+	require.NoError(t, err)
+
+	targetPath := t.TempDir()
+	_, err = restored.Restore(targetPath, false)
+	require.NoError(t, err)
+
+	content, err := os.ReadFile(filepath.Join(targetPath, "config.d", "sshman"))
+	require.NoError(t, err)
+	assert.Equal(t, "Host gitlab-work\n", string(content))
+	assert.FileExists(t, filepath.Join(targetPath, "config"))
+}
+
+func TestCollectFiles_ConfigFileOutsideSSHPath_Error(t *testing.T) {
+	sshPath, privateKeys := setupSSHDir(t)
+	outside := filepath.Join(t.TempDir(), "sshman.conf")
+	require.NoError(t, os.WriteFile(outside, []byte("Host gitlab-work\n"), 0600))
+	ssh.ConfigFileName = outside
+	t.Cleanup(func() { ssh.ConfigFileName = "config" })
+
+	_, err := CollectFiles(sshPath, privateKeys)
+
+	assert.ErrorContains(t, err, "outside the SSH directory")
+}
+
+func TestArchive_WriteRead_Encrypted_RoundTrip(t *testing.T) {
+	sshPath, privateKeys := setupSSHDir(t)
+	archive, err := CollectFiles(sshPath, privateKeys)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, archive.Write(&buf, "correct horse"))
+	assert.NotContains(t, buf.String(), "private")
+
+	restored, err := ReadArchive(bytes.NewReader(buf.Bytes()), "correct horse")
+	require.NoError(t, err)
+
+	assert.Equal(t, archive.Manifest.Files, restored.Manifest.Files)
+	assert.Equal(t, archive.Contents, restored.Contents)
+}
+
+func TestReadArchive_WrongPassphrase_Error(t *testing.T) {
+	archive := NewArchive()
+	archive.Add("id_ed25519", KindPrivateKey, []byte("private"))
+
+	var buf bytes.Buffer
+	require.NoError(t, archive.Write(&buf, "correct horse"))
+
+	_, err := ReadArchive(&buf, "wrong")
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to decrypt archive")
+}
+
+func TestReadArchive_MissingPassphrase_Error(t *testing.T) {
+	archive := NewArchive()
+	archive.Add("id_ed25519", KindPrivateKey, []byte("private"))
+
+	var buf bytes.Buffer
+	require.NoError(t, archive.Write(&buf, "correct horse"))
+
+	_, err := ReadArchive(&buf, "")
+
+	assert.ErrorIs(t, err, ErrPassphraseRequired)
+}
+
+func TestReadArchive_UnsafePath_Error(t *testing.T) {
+	archive := NewArchive()
+	archive.Add("../authorized_keys", KindPublicKey, []byte("ssh-ed25519 AAAA"))
+
+	var buf bytes.Buffer
+	require.NoError(t, archive.Write(&buf, ""))
+
+	_, err := ReadArchive(&buf, "")
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "unsafe path")
+}
+
+func TestArchive_Restore_PermissionsAndConflicts(t *testing.T) {
+	sshPath, privateKeys := setupSSHDir(t)
+	archive, err := CollectFiles(sshPath, privateKeys)
+	require.NoError(t, err)
+
+	destPath := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(destPath, "config"), []byte("Host existing\n"), 0600))
+
+	result, err := archive.Restore(destPath, false)
+	require.NoError(t, err)
+
+	assert.Equal(t, []File{{Name: "config", Kind: KindConfig}}, result.Skipped)
+	assert.Len(t, result.Restored, 3)
+
+	info, err := os.Stat(filepath.Join(destPath, "id_ed25519_work"))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	info, err = os.Stat(filepath.Join(destPath, "id_ed25519_work.pub"))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0644), info.Mode().Perm())
+
+	config, err := os.ReadFile(filepath.Join(destPath, "config"))
+	require.NoError(t, err)
+	assert.Equal(t, "Host existing\n", string(config))
+
+	metadata, err := ssh.LoadMetadata(destPath)
+	require.NoError(t, err)
+	_, exists := metadata.Get("id_ed25519_work")
+	assert.True(t, exists)
+}
+
+func TestArchive_Restore_Force_Overwrites(t *testing.T) {
+	sshPath, privateKeys := setupSSHDir(t)
+	archive, err := CollectFiles(sshPath, privateKeys)
+	require.NoError(t, err)
+
+	destPath := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(destPath, "config"), []byte("Host existing\n"), 0644))
+
+	result, err := archive.Restore(destPath, true)
+	require.NoError(t, err)
+
+	assert.Empty(t, result.Skipped)
+	config, err := os.ReadFile(filepath.Join(destPath, "config"))
+	require.NoError(t, err)
+	assert.Equal(t, "Host github-work\n", string(config))
+}
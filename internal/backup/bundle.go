@@ -0,0 +1,117 @@
+package backup
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+
+	"github.com/residwi/sshman/internal/ssh"
+	"github.com/residwi/sshman/utils"
+)
+
+const hostsFileName = "hosts.json"
+
+// KeyBundle is a single key pair exported with what sshman knows about it
+type KeyBundle struct {
+	KeyName    string
+	PrivateKey []byte
+	PublicKey  []byte
+	Metadata   *ssh.KeyMetadata
+	Hosts      []ssh.ConfigEntry
+}
+
+// NewKeyBundle builds an archive holding one key pair, its metadata and the Host
+// entries that use it. IdentityFile is stored as the bare key name so the entries
+// can be pointed at the key wherever it is imported.
+func NewKeyBundle(sshPath, keyName string) (*Archive, error) {
+	archive := NewArchive()
+	keyPath := filepath.Join(sshPath, keyName)
+
+	if err := archive.AddFile(sshPath, keyName, KindPrivateKey); err != nil {
+		return nil, err
+	}
+
+	if !utils.IsFileNotExist(keyPath + ".pub") {
+		if err := archive.AddFile(sshPath, keyName+".pub", KindPublicKey); err != nil {
+			return nil, err
+		}
+	}
+
+	metadata, err := ssh.LoadMetadata(sshPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if keyMetadata, exists := metadata.Get(keyName); exists {
+		bundleMetadata := ssh.Metadata{Keys: map[string]ssh.KeyMetadata{keyName: keyMetadata}}
+		content, err := json.MarshalIndent(bundleMetadata, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode metadata: %w", err)
+		}
+		archive.Add(ssh.MetadataFileName, KindMetadata, content)
+	}
+
+	entries, err := ssh.ReadConfig(sshPath)
+	if err != nil {
+		return nil, err
+	}
+
+	hosts := ssh.FindEntriesByIdentityFile(entries, keyPath)
+	if len(hosts) > 0 {
+		for i := range hosts {
+			hosts[i].IdentityFile = keyName
+		}
+
+		content, err := json.MarshalIndent(hosts, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode host entries: %w", err)
+		}
+		archive.Add(hostsFileName, KindHosts, content)
+	}
+
+	return archive, nil
+}
+
+// KeyBundle extracts the key pair exported by NewKeyBundle
+func (a *Archive) KeyBundle() (*KeyBundle, error) {
+	bundle := &KeyBundle{}
+
+	for _, file := range a.Manifest.Files {
+		content := a.Contents[file.Name]
+
+		switch file.Kind {
+		case KindPrivateKey:
+			if bundle.KeyName != "" {
+				return nil, fmt.Errorf("archive holds more than one key, use 'sshman restore' for full backups")
+			}
+			bundle.KeyName = file.Name
+			bundle.PrivateKey = content
+		case KindPublicKey:
+			bundle.PublicKey = content
+		case KindHosts:
+			if err := json.Unmarshal(content, &bundle.Hosts); err != nil {
+				return nil, fmt.Errorf("failed to parse host entries: %w", err)
+			}
+		}
+	}
+
+	if bundle.KeyName == "" {
+		return nil, fmt.Errorf("archive does not contain a private key")
+	}
+
+	for _, file := range a.Manifest.Files {
+		if file.Kind != KindMetadata {
+			continue
+		}
+
+		var metadata ssh.Metadata
+		if err := json.Unmarshal(a.Contents[file.Name], &metadata); err != nil {
+			return nil, fmt.Errorf("failed to parse metadata: %w", err)
+		}
+		if keyMetadata, exists := metadata.Keys[bundle.KeyName]; exists {
+			bundle.Metadata = &keyMetadata
+		}
+	}
+
+	return bundle, nil
+}
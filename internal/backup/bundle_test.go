@@ -0,0 +1,64 @@
+package backup
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewKeyBundle_RoundTrip(t *testing.T) {
+	sshPath, _ := setupSSHDir(t)
+	config := "Host github-work\n\tHostName github.com\n\tUser git\n\tIdentityFile " + filepath.Join(sshPath, "id_ed25519_work") + "\n\nHost other\n\tIdentityFile ~/.ssh/id_rsa\n"
+	require.NoError(t, os.WriteFile(filepath.Join(sshPath, "config"), []byte(config), 0600))
+
+	archive, err := NewKeyBundle(sshPath, "id_ed25519_work")
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, archive.Write(&buf, ""))
+
+	read, err := ReadArchive(&buf, "")
+	require.NoError(t, err)
+
+	bundle, err := read.KeyBundle()
+	require.NoError(t, err)
+
+	assert.Equal(t, "id_ed25519_work", bundle.KeyName)
+	assert.Equal(t, []byte("private"), bundle.PrivateKey)
+	assert.Equal(t, []byte("ssh-ed25519 AAAA work@company.com"), bundle.PublicKey)
+	require.NotNil(t, bundle.Metadata)
+	assert.Equal(t, "github", bundle.Metadata.Provider)
+	require.Len(t, bundle.Hosts, 1)
+	assert.Equal(t, "github-work", bundle.Hosts[0].Host)
+	assert.Equal(t, "id_ed25519_work", bundle.Hosts[0].IdentityFile)
+}
+
+func TestNewKeyBundle_UnmanagedKey_NoMetadata(t *testing.T) {
+	sshPath := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(sshPath, "id_rsa"), []byte("private"), 0600))
+
+	archive, err := NewKeyBundle(sshPath, "id_rsa")
+	require.NoError(t, err)
+
+	bundle, err := archive.KeyBundle()
+	require.NoError(t, err)
+
+	assert.Nil(t, bundle.Metadata)
+	assert.Nil(t, bundle.PublicKey)
+	assert.Empty(t, bundle.Hosts)
+}
+
+func TestArchive_KeyBundle_FullBackup_Error(t *testing.T) {
+	archive := NewArchive()
+	archive.Add("id_ed25519", KindPrivateKey, []byte("private"))
+	archive.Add("id_rsa", KindPrivateKey, []byte("private"))
+
+	_, err := archive.KeyBundle()
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "more than one key")
+}
@@ -0,0 +1,100 @@
+package clipboard
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+
+	"github.com/residwi/sshman/internal/interfaces"
+)
+
+const MethodOSC52 = "osc52"
+
+var ErrNoClipboard = errors.New("no clipboard tool found, install wl-clipboard, xclip or xsel")
+
+type tool struct {
+	name string
+	args []string
+}
+
+// Clipboard copies text with the first clipboard tool available, falling back to
+// the OSC 52 escape sequence, which most terminal emulators support, also over SSH
+type Clipboard struct {
+	executor interfaces.CommandExecutor
+	lookPath func(string) (string, error)
+	getenv   func(string) string
+	goos     string
+	osc52    io.Writer
+}
+
+func NewClipboard(executor interfaces.CommandExecutor) *Clipboard {
+	return &Clipboard{
+		executor: executor,
+		lookPath: exec.LookPath,
+		getenv:   os.Getenv,
+		goos:     runtime.GOOS,
+		osc52:    os.Stderr,
+	}
+}
+
+// WithOSC52 sets where the OSC 52 fallback is written, nil disables it
+func (c *Clipboard) WithOSC52(w io.Writer) *Clipboard {
+	c.osc52 = w
+	return c
+}
+
+// Copy copies text to the clipboard and returns the tool that was used
+func (c *Clipboard) Copy(text string) (string, error) {
+	for _, t := range c.tools() {
+		if _, err := c.lookPath(t.name); err != nil {
+			continue
+		}
+
+		if err := c.executor.ExecuteWithInput([]byte(text), t.name, t.args...); err != nil {
+			return "", fmt.Errorf("failed to copy with %s: %w", t.name, err)
+		}
+		return t.name, nil
+	}
+
+	if c.osc52 == nil {
+		return "", ErrNoClipboard
+	}
+
+	if _, err := io.WriteString(c.osc52, OSC52(text)); err != nil {
+		return "", fmt.Errorf("failed to copy with OSC 52: %w", err)
+	}
+	return MethodOSC52, nil
+}
+
+// OSC52 returns the escape sequence that asks the terminal to set its clipboard
+func OSC52(text string) string {
+	return "\x1b]52;c;" + base64.StdEncoding.EncodeToString([]byte(text)) + "\x07"
+}
+
+func (c *Clipboard) tools() []tool {
+	switch c.goos {
+	case "darwin":
+		return []tool{{name: "pbcopy"}}
+	case "windows":
+		return []tool{{name: "clip.exe"}}
+	}
+
+	var tools []tool
+	if c.getenv("WAYLAND_DISPLAY") != "" {
+		tools = append(tools, tool{name: "wl-copy"})
+	}
+	if c.getenv("DISPLAY") != "" {
+		tools = append(tools,
+			tool{name: "xclip", args: []string{"-selection", "clipboard"}},
+			tool{name: "xsel", args: []string{"--clipboard", "--input"}},
+		)
+	}
+	// WSL can reach the Windows clipboard
+	tools = append(tools, tool{name: "clip.exe"})
+
+	return tools
+}
@@ -0,0 +1,81 @@
+package clipboard
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"slices"
+	"testing"
+
+	"github.com/residwi/sshman/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestClipboard(t *testing.T, env map[string]string, installed ...string) (*Clipboard, *mocks.MockCommandExecutor, *bytes.Buffer) {
+	t.Helper()
+
+	mockExecutor := mocks.NewMockCommandExecutor(t)
+	var osc52 bytes.Buffer
+
+	clipboard := NewClipboard(mockExecutor).WithOSC52(&osc52)
+	clipboard.goos = "linux"
+	clipboard.getenv = func(key string) string { return env[key] }
+	clipboard.lookPath = func(name string) (string, error) {
+		if slices.Contains(installed, name) {
+			return "/usr/bin/" + name, nil
+		}
+		return "", exec.ErrNotFound
+	}
+
+	return clipboard, mockExecutor, &osc52
+}
+
+func TestClipboard_Copy_Wayland(t *testing.T) {
+	clipboard, mockExecutor, _ := newTestClipboard(t, map[string]string{"WAYLAND_DISPLAY": "wayland-0", "DISPLAY": ":0"}, "wl-copy", "xclip")
+	mockExecutor.EXPECT().ExecuteWithInput([]byte("ssh-ed25519 AAAA"), "wl-copy").Return(nil)
+
+	method, err := clipboard.Copy("ssh-ed25519 AAAA")
+
+	require.NoError(t, err)
+	assert.Equal(t, "wl-copy", method)
+}
+
+func TestClipboard_Copy_X11_FallsBackToXsel(t *testing.T) {
+	clipboard, mockExecutor, _ := newTestClipboard(t, map[string]string{"DISPLAY": ":0"}, "xsel")
+	mockExecutor.EXPECT().ExecuteWithInput([]byte("ssh-ed25519 AAAA"), "xsel", []string{"--clipboard", "--input"}).Return(nil)
+
+	method, err := clipboard.Copy("ssh-ed25519 AAAA")
+
+	require.NoError(t, err)
+	assert.Equal(t, "xsel", method)
+}
+
+func TestClipboard_Copy_ToolError(t *testing.T) {
+	clipboard, mockExecutor, _ := newTestClipboard(t, map[string]string{"DISPLAY": ":0"}, "xclip")
+	mockExecutor.EXPECT().ExecuteWithInput([]byte("text"), "xclip", []string{"-selection", "clipboard"}).Return(fmt.Errorf("cannot open display"))
+
+	_, err := clipboard.Copy("text")
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to copy with xclip")
+}
+
+func TestClipboard_Copy_OSC52Fallback(t *testing.T) {
+	clipboard, _, osc52 := newTestClipboard(t, map[string]string{})
+
+	method, err := clipboard.Copy("hello")
+
+	require.NoError(t, err)
+	assert.Equal(t, MethodOSC52, method)
+	assert.Equal(t, "\x1b]52;c;aGVsbG8=\x07", osc52.String())
+}
+
+func TestClipboard_Copy_NoClipboard(t *testing.T) {
+	clipboard, _, _ := newTestClipboard(t, map[string]string{})
+	clipboard.WithOSC52(nil)
+
+	_, err := clipboard.Copy("hello")
+
+	assert.ErrorIs(t, err, ErrNoClipboard)
+}
@@ -0,0 +1,239 @@
+package git
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/residwi/sshman/internal/interfaces"
+	"github.com/residwi/sshman/utils"
+)
+
+const (
+	ModeSSHCommand = "ssh-command"
+	ModeInsteadOf  = "insteadof"
+)
+
+type Binding struct {
+	Dir        string
+	KeyPath    string
+	Email      string
+	Mode       string
+	HostAlias  string
+	User       string
+	Hostname   string
+	ConfigPath string
+}
+
+type BindingManager struct {
+	executor    interfaces.CommandExecutor
+	bindingsDir string
+}
+
+func NewBindingManager(executor interfaces.CommandExecutor, bindingsDir string) *BindingManager {
+	return &BindingManager{
+		executor:    executor,
+		bindingsDir: bindingsDir,
+	}
+}
+
+func GetSupportedModes() []string {
+	return []string{ModeSSHCommand, ModeInsteadOf}
+}
+
+// Bind writes the identity rules for the binding to its own git config file and
+// includes that file from the global git config for every repository under the directory
+func (bm *BindingManager) Bind(binding Binding) (string, error) {
+	gitDir := NormalizeGitDir(binding.Dir)
+	configPath := filepath.Join(bm.bindingsDir, bindingFileName(gitDir))
+
+	if err := os.MkdirAll(bm.bindingsDir, 0700); err != nil {
+		return "", fmt.Errorf("failed to create git bindings directory: %w", err)
+	}
+
+	// start from an empty file so rebinding a directory replaces the old rules
+	if err := os.Remove(configPath); err != nil && !os.IsNotExist(err) {
+		return "", fmt.Errorf("failed to remove existing git binding: %w", err)
+	}
+
+	settings := [][]string{{"sshman.key", binding.KeyPath}}
+
+	switch binding.Mode {
+	case ModeSSHCommand:
+		settings = append(settings, []string{"core.sshCommand", sshCommand(binding.KeyPath)})
+	case ModeInsteadOf:
+		if binding.HostAlias == "" || binding.Hostname == "" {
+			return "", fmt.Errorf("host alias and hostname are required for %s mode", ModeInsteadOf)
+		}
+		rewrite := fmt.Sprintf("url.%s@%s:.insteadOf", binding.User, binding.HostAlias)
+		settings = append(settings, []string{rewrite, fmt.Sprintf("%s@%s:", binding.User, binding.Hostname)})
+	default:
+		return "", fmt.Errorf("unsupported binding mode: %s. Supported modes are: %v", binding.Mode, GetSupportedModes())
+	}
+
+	if binding.Email != "" {
+		settings = append(settings, []string{"user.email", binding.Email})
+	}
+
+	for _, setting := range settings {
+		if err := bm.executor.Execute("git", "config", "--file", configPath, setting[0], setting[1]); err != nil {
+			return "", fmt.Errorf("failed to write %s to git binding: %w", setting[0], err)
+		}
+	}
+
+	includeKey := fmt.Sprintf("includeIf.gitdir:%s.path", gitDir)
+	if err := bm.executor.Execute("git", "config", "--global", includeKey, configPath); err != nil {
+		return "", fmt.Errorf("failed to include git binding in global git config: %w", err)
+	}
+
+	return configPath, nil
+}
+
+// ListBindings returns the bindings written by sshman that are included from the global git config
+func (bm *BindingManager) ListBindings() ([]Binding, error) {
+	includes, err := bm.getRegexp([]string{"--global"}, `^includeif\.gitdir:`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read global git config: %w", err)
+	}
+
+	bindings := []Binding{}
+	for _, include := range includes {
+		gitDir, isPath := strings.CutSuffix(strings.TrimPrefix(include[0], "includeif.gitdir:"), ".path")
+		if !isPath || filepath.Dir(include[1]) != filepath.Clean(bm.bindingsDir) {
+			continue
+		}
+
+		binding := Binding{Dir: gitDir, Mode: ModeSSHCommand, ConfigPath: include[1]}
+
+		settings, err := bm.getRegexp([]string{"--file", include[1]}, ".")
+		if err != nil {
+			return nil, fmt.Errorf("failed to read git binding %s: %w", include[1], err)
+		}
+
+		for _, setting := range settings {
+			switch {
+			case setting[0] == "sshman.key":
+				binding.KeyPath = setting[1]
+			case setting[0] == "user.email":
+				binding.Email = setting[1]
+			case strings.HasPrefix(setting[0], "url.") && strings.HasSuffix(setting[0], ".insteadof"):
+				binding.Mode = ModeInsteadOf
+				binding.User, binding.HostAlias = splitSCPHost(strings.TrimSuffix(strings.TrimPrefix(setting[0], "url."), ".insteadof"))
+				_, binding.Hostname = splitSCPHost(setting[1])
+			}
+		}
+
+		bindings = append(bindings, binding)
+	}
+
+	return bindings, nil
+}
+
+// ReplaceKey points the bindings that use the old key at the new key and returns them
+func (bm *BindingManager) ReplaceKey(oldKeyPath, newKeyPath string) ([]Binding, error) {
+	bindings, err := bm.ListBindings()
+	if err != nil {
+		return nil, err
+	}
+
+	var replaced []Binding
+	for _, binding := range bindings {
+		if !utils.IsSamePath(binding.KeyPath, oldKeyPath) {
+			continue
+		}
+
+		settings := [][]string{{"sshman.key", newKeyPath}}
+		if binding.Mode == ModeSSHCommand {
+			settings = append(settings, []string{"core.sshCommand", sshCommand(newKeyPath)})
+		}
+
+		for _, setting := range settings {
+			if err := bm.executor.Execute("git", "config", "--file", binding.ConfigPath, setting[0], setting[1]); err != nil {
+				return replaced, fmt.Errorf("failed to write %s to git binding: %w", setting[0], err)
+			}
+		}
+
+		binding.KeyPath = newKeyPath
+		replaced = append(replaced, binding)
+	}
+
+	return replaced, nil
+}
+
+// GetUserEmail returns the user.email git would use in the current directory,
+// or an empty string when none is configured
+func GetUserEmail(executor interfaces.CommandExecutor) string {
+	output, err := executor.ExecuteWithOutput("git", "config", "--get", "user.email")
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(output))
+}
+
+// NormalizeGitDir turns a directory into a gitdir pattern that matches every repository below it
+func NormalizeGitDir(dir string) string {
+	dir = utils.ExpandTilde(dir)
+	if absDir, err := filepath.Abs(dir); err == nil {
+		dir = absDir
+	}
+
+	return utils.ReplaceHomeDirWithTilde(dir) + "/"
+}
+
+// getRegexp runs git config --get-regexp and returns name/value pairs
+func (bm *BindingManager) getRegexp(source []string, pattern string) ([][2]string, error) {
+	args := append([]string{"config"}, source...)
+	args = append(args, "--get-regexp", pattern)
+
+	output, err := bm.executor.ExecuteWithOutput("git", args...)
+	if err != nil {
+		// git config --get-regexp returns exit code 1 when nothing matches
+		if exitError, ok := err.(*exec.ExitError); ok && exitError.ExitCode() == 1 {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var pairs [][2]string
+	for line := range strings.SplitSeq(strings.TrimSpace(string(output)), "\n") {
+		name, value, found := strings.Cut(line, " ")
+		if !found {
+			continue
+		}
+		pairs = append(pairs, [2]string{name, value})
+	}
+
+	return pairs, nil
+}
+
+// bindingFileName names the binding file after the directory, with a hash of the gitdir
+// pattern so that directories with the same readable name, e.g. ~/work and /work, do not
+// share a file
+func bindingFileName(gitDir string) string {
+	name := strings.TrimPrefix(gitDir, "~")
+	name = strings.Trim(strings.ReplaceAll(name, "/", "-"), "-")
+	if name == "" {
+		name = "root"
+	}
+	sum := sha256.Sum256([]byte(gitDir))
+	return name + "-" + hex.EncodeToString(sum[:4]) + ".gitconfig"
+}
+
+// sshCommand is run by git through the shell, so the key path is quoted
+func sshCommand(keyPath string) string {
+	return "ssh -i " + utils.ShellQuote(keyPath) + " -o IdentitiesOnly=yes"
+}
+
+// splitSCPHost splits "user@host:" into its user and host
+func splitSCPHost(value string) (string, string) {
+	value = strings.TrimSuffix(value, ":")
+	user, host, found := strings.Cut(value, "@")
+	if !found {
+		return "", user
+	}
+	return user, host
+}
@@ -0,0 +1,234 @@
+package git
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/residwi/sshman/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBindingManager_Bind_SSHCommand_Success(t *testing.T) {
+	homeDir, err := os.UserHomeDir()
+	require.NoError(t, err)
+
+	bindingsDir := t.TempDir()
+	configPath := filepath.Join(bindingsDir, bindingFileName("~/work/"))
+	mockExecutor := mocks.NewMockCommandExecutor(t)
+
+	mockExecutor.EXPECT().Execute("git", []string{"config", "--file", configPath, "sshman.key", "/keys/id_ed25519_work"}).Return(nil)
+	mockExecutor.EXPECT().Execute("git", []string{"config", "--file", configPath, "core.sshCommand", "ssh -i '/keys/id_ed25519_work' -o IdentitiesOnly=yes"}).Return(nil)
+	mockExecutor.EXPECT().Execute("git", []string{"config", "--file", configPath, "user.email", "work@company.com"}).Return(nil)
+	mockExecutor.EXPECT().Execute("git", []string{"config", "--global", "includeIf.gitdir:~/work/.path", configPath}).Return(nil)
+
+	bindingMgr := NewBindingManager(mockExecutor, bindingsDir)
+	path, err := bindingMgr.Bind(Binding{
+		Dir:     filepath.Join(homeDir, "work"),
+		KeyPath: "/keys/id_ed25519_work",
+		Email:   "work@company.com",
+		Mode:    ModeSSHCommand,
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, configPath, path)
+}
+
+func TestBindingManager_Bind_InsteadOf_Success(t *testing.T) {
+	bindingsDir := t.TempDir()
+	configPath := filepath.Join(bindingsDir, bindingFileName("/srv/repos/"))
+	mockExecutor := mocks.NewMockCommandExecutor(t)
+
+	// a binding left over from a previous run is replaced
+	require.NoError(t, os.WriteFile(configPath, []byte("[core]\n\tsshCommand = old\n"), 0600))
+
+	mockExecutor.EXPECT().Execute("git", []string{"config", "--file", configPath, "sshman.key", "/keys/id_ed25519_work"}).Return(nil)
+	mockExecutor.EXPECT().Execute("git", []string{"config", "--file", configPath, "url.git@github-work:.insteadOf", "git@github.com:"}).Return(nil)
+	mockExecutor.EXPECT().Execute("git", []string{"config", "--global", "includeIf.gitdir:/srv/repos/.path", configPath}).Return(nil)
+
+	bindingMgr := NewBindingManager(mockExecutor, bindingsDir)
+	_, err := bindingMgr.Bind(Binding{
+		Dir:       "/srv/repos/",
+		KeyPath:   "/keys/id_ed25519_work",
+		Mode:      ModeInsteadOf,
+		HostAlias: "github-work",
+		User:      "git",
+		Hostname:  "github.com",
+	})
+
+	assert.NoError(t, err)
+	assert.NoFileExists(t, configPath)
+}
+
+func TestBindingManager_Bind_InsteadOfWithoutAlias_Error(t *testing.T) {
+	mockExecutor := mocks.NewMockCommandExecutor(t)
+
+	bindingMgr := NewBindingManager(mockExecutor, t.TempDir())
+	_, err := bindingMgr.Bind(Binding{Dir: "/srv/repos", KeyPath: "/keys/id_ed25519", Mode: ModeInsteadOf})
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "host alias and hostname are required")
+}
+
+func TestBindingManager_Bind_UnsupportedMode_Error(t *testing.T) {
+	mockExecutor := mocks.NewMockCommandExecutor(t)
+
+	bindingMgr := NewBindingManager(mockExecutor, t.TempDir())
+	_, err := bindingMgr.Bind(Binding{Dir: "/srv/repos", KeyPath: "/keys/id_ed25519", Mode: "invalid"})
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "unsupported binding mode: invalid")
+}
+
+func TestBindingManager_Bind_GitFails_Error(t *testing.T) {
+	bindingsDir := t.TempDir()
+	configPath := filepath.Join(bindingsDir, bindingFileName("/srv/"))
+	mockExecutor := mocks.NewMockCommandExecutor(t)
+
+	mockExecutor.EXPECT().Execute("git", []string{"config", "--file", configPath, "sshman.key", "/keys/id_ed25519"}).Return(fmt.Errorf("git failed"))
+
+	bindingMgr := NewBindingManager(mockExecutor, bindingsDir)
+	_, err := bindingMgr.Bind(Binding{Dir: "/srv", KeyPath: "/keys/id_ed25519", Mode: ModeSSHCommand})
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to write sshman.key to git binding")
+	assert.Contains(t, err.Error(), "git failed")
+}
+
+func TestBindingManager_ListBindings_Success(t *testing.T) {
+	bindingsDir := t.TempDir()
+	workConfig := filepath.Join(bindingsDir, "work.gitconfig")
+	ossConfig := filepath.Join(bindingsDir, "oss.gitconfig")
+	mockExecutor := mocks.NewMockCommandExecutor(t)
+
+	includes := fmt.Sprintf("includeif.gitdir:~/work/.path %s\nincludeif.gitdir:~/oss/.path %s\nincludeif.gitdir:~/other/.path /home/user/.gitconfig-other", workConfig, ossConfig)
+	mockExecutor.EXPECT().ExecuteWithOutput("git", []string{"config", "--global", "--get-regexp", `^includeif\.gitdir:`}).Return([]byte(includes), nil)
+
+	workSettings := "sshman.key /keys/id_ed25519_work\ncore.sshcommand ssh -i '/keys/id_ed25519_work' -o IdentitiesOnly=yes\nuser.email work@company.com"
+	mockExecutor.EXPECT().ExecuteWithOutput("git", []string{"config", "--file", workConfig, "--get-regexp", "."}).Return([]byte(workSettings), nil)
+
+	ossSettings := "sshman.key /keys/id_ed25519_oss\nurl.git@github-oss:.insteadof git@github.com:"
+	mockExecutor.EXPECT().ExecuteWithOutput("git", []string{"config", "--file", ossConfig, "--get-regexp", "."}).Return([]byte(ossSettings), nil)
+
+	bindingMgr := NewBindingManager(mockExecutor, bindingsDir)
+	bindings, err := bindingMgr.ListBindings()
+
+	require.NoError(t, err)
+	require.Len(t, bindings, 2)
+
+	assert.Equal(t, Binding{
+		Dir:        "~/work/",
+		KeyPath:    "/keys/id_ed25519_work",
+		Email:      "work@company.com",
+		Mode:       ModeSSHCommand,
+		ConfigPath: workConfig,
+	}, bindings[0])
+
+	assert.Equal(t, Binding{
+		Dir:        "~/oss/",
+		KeyPath:    "/keys/id_ed25519_oss",
+		Mode:       ModeInsteadOf,
+		HostAlias:  "github-oss",
+		User:       "git",
+		Hostname:   "github.com",
+		ConfigPath: ossConfig,
+	}, bindings[1])
+}
+
+func TestBindingManager_ListBindings_NoBindings_Success(t *testing.T) {
+	mockExecutor := mocks.NewMockCommandExecutor(t)
+
+	mockExecutor.EXPECT().ExecuteWithOutput("git", []string{"config", "--global", "--get-regexp", `^includeif\.gitdir:`}).Return([]byte(""), nil)
+
+	bindingMgr := NewBindingManager(mockExecutor, t.TempDir())
+	bindings, err := bindingMgr.ListBindings()
+
+	assert.NoError(t, err)
+	assert.Empty(t, bindings)
+}
+
+func TestBindingManager_ReplaceKey_Success(t *testing.T) {
+	bindingsDir := t.TempDir()
+	workConfig := filepath.Join(bindingsDir, "work.gitconfig")
+	ossConfig := filepath.Join(bindingsDir, "oss.gitconfig")
+	mockExecutor := mocks.NewMockCommandExecutor(t)
+
+	includes := fmt.Sprintf("includeif.gitdir:~/work/.path %s\nincludeif.gitdir:~/oss/.path %s", workConfig, ossConfig)
+	mockExecutor.EXPECT().ExecuteWithOutput("git", []string{"config", "--global", "--get-regexp", `^includeif\.gitdir:`}).Return([]byte(includes), nil)
+	mockExecutor.EXPECT().ExecuteWithOutput("git", []string{"config", "--file", workConfig, "--get-regexp", "."}).
+		Return([]byte("sshman.key /keys/id_ed25519_work\ncore.sshcommand ssh -i '/keys/id_ed25519_work' -o IdentitiesOnly=yes"), nil)
+	mockExecutor.EXPECT().ExecuteWithOutput("git", []string{"config", "--file", ossConfig, "--get-regexp", "."}).
+		Return([]byte("sshman.key /keys/id_ed25519_oss\nurl.git@github-oss:.insteadof git@github.com:"), nil)
+
+	mockExecutor.EXPECT().Execute("git", []string{"config", "--file", workConfig, "sshman.key", "/keys/id_ed25519_client"}).Return(nil)
+	mockExecutor.EXPECT().Execute("git", []string{"config", "--file", workConfig, "core.sshCommand", "ssh -i '/keys/id_ed25519_client' -o IdentitiesOnly=yes"}).Return(nil)
+
+	bindingMgr := NewBindingManager(mockExecutor, bindingsDir)
+	replaced, err := bindingMgr.ReplaceKey("/keys/id_ed25519_work", "/keys/id_ed25519_client")
+
+	require.NoError(t, err)
+	require.Len(t, replaced, 1)
+	assert.Equal(t, "~/work/", replaced[0].Dir)
+	assert.Equal(t, "/keys/id_ed25519_client", replaced[0].KeyPath)
+}
+
+func TestNormalizeGitDir(t *testing.T) {
+	homeDir, err := os.UserHomeDir()
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		dir      string
+		expected string
+	}{
+		{"tilde_dir", "~/work", "~/work/"},
+		{"tilde_dir_with_slash", "~/work/", "~/work/"},
+		{"absolute_home_dir", filepath.Join(homeDir, "projects", "client"), "~/projects/client/"},
+		{"outside_home", "/srv/repos", "/srv/repos/"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, NormalizeGitDir(tt.dir))
+		})
+	}
+}
+
+func TestBindingFileName(t *testing.T) {
+	tests := []struct {
+		name     string
+		gitDir   string
+		expected string
+	}{
+		{"home_subdir", "~/work/", "work-"},
+		{"nested_dir", "~/projects/client/", "projects-client-"},
+		{"absolute_dir", "/srv/repos/", "srv-repos-"},
+		{"home_dir", "~/", "root-"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			name := bindingFileName(tt.gitDir)
+			assert.Regexp(t, "^"+tt.expected+"[0-9a-f]{8}\\.gitconfig$", name)
+			assert.Equal(t, name, bindingFileName(tt.gitDir))
+		})
+	}
+}
+
+func TestBindingFileName_Unambiguous(t *testing.T) {
+	assert.NotEqual(t, bindingFileName("~/work/"), bindingFileName("/work/"))
+	assert.NotEqual(t, bindingFileName("~/a-b/"), bindingFileName("~/a/b/"))
+}
+
+func TestGetUserEmail(t *testing.T) {
+	mockExecutor := mocks.NewMockCommandExecutor(t)
+	mockExecutor.EXPECT().ExecuteWithOutput("git", []string{"config", "--get", "user.email"}).Return([]byte("me@example.com\n"), nil).Once()
+
+	assert.Equal(t, "me@example.com", GetUserEmail(mockExecutor))
+
+	mockExecutor.EXPECT().ExecuteWithOutput("git", []string{"config", "--get", "user.email"}).Return(nil, fmt.Errorf("exit status 1")).Once()
+
+	assert.Empty(t, GetUserEmail(mockExecutor))
+}
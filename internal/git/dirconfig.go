@@ -0,0 +1,91 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/residwi/sshman/utils"
+	"gopkg.in/yaml.v3"
+)
+
+const DirConfigFileName = ".sshman"
+
+// DirConfig selects the SSH key used for git in a directory and its subdirectories
+type DirConfig struct {
+	// Key is the name of a key in the SSH directory, or a path to a key
+	Key string `yaml:"key"`
+	// Path is the .sshman file the config was read from
+	Path string `yaml:"-"`
+}
+
+// FindDirConfig reads the nearest .sshman file in dir or one of its parents
+func FindDirConfig(dir string) (DirConfig, bool, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return DirConfig{}, false, fmt.Errorf("failed to resolve directory: %w", err)
+	}
+
+	for {
+		path := filepath.Join(dir, DirConfigFileName)
+		config, err := ReadDirConfig(path)
+		if err == nil {
+			return config, true, nil
+		}
+		if !errors.Is(err, os.ErrNotExist) {
+			return DirConfig{}, false, err
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return DirConfig{}, false, nil
+		}
+		dir = parent
+	}
+}
+
+func ReadDirConfig(path string) (DirConfig, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return DirConfig{}, err
+	}
+
+	var config DirConfig
+	if err := yaml.Unmarshal(content, &config); err != nil {
+		return DirConfig{}, fmt.Errorf("failed to parse %s: %w", utils.ReplaceHomeDirWithTilde(path), err)
+	}
+	if config.Key == "" {
+		return DirConfig{}, fmt.Errorf("no key set in %s", utils.ReplaceHomeDirWithTilde(path))
+	}
+
+	config.Path = path
+	return config, nil
+}
+
+func WriteDirConfig(path string, config DirConfig) error {
+	content, err := yaml.Marshal(config)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", DirConfigFileName, err)
+	}
+
+	if err := os.WriteFile(path, content, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", utils.ReplaceHomeDirWithTilde(path), err)
+	}
+
+	return nil
+}
+
+// KeyPath resolves the key relative to the SSH directory
+func (dc DirConfig) KeyPath(sshPath string) string {
+	keyPath := utils.ExpandTilde(dc.Key)
+	if filepath.IsAbs(keyPath) {
+		return keyPath
+	}
+	return filepath.Join(sshPath, keyPath)
+}
+
+// SSHCommand returns the GIT_SSH_COMMAND that makes git use only the key
+func (dc DirConfig) SSHCommand(sshPath string) string {
+	return sshCommand(dc.KeyPath(sshPath))
+}
@@ -0,0 +1,57 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindDirConfig_WalksUp(t *testing.T) {
+	root := t.TempDir()
+	nested := filepath.Join(root, "project", "src", "pkg")
+	require.NoError(t, os.MkdirAll(nested, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "project", DirConfigFileName), []byte("key: id_ed25519_work\n"), 0644))
+
+	config, found, err := FindDirConfig(nested)
+
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, "id_ed25519_work", config.Key)
+	assert.Equal(t, filepath.Join(root, "project", DirConfigFileName), config.Path)
+}
+
+func TestFindDirConfig_NotFound(t *testing.T) {
+	_, found, err := FindDirConfig(t.TempDir())
+
+	require.NoError(t, err)
+	assert.False(t, found)
+}
+
+func TestFindDirConfig_Invalid_Error(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, DirConfigFileName), []byte("email: me@example.com\n"), 0644))
+
+	_, _, err := FindDirConfig(dir)
+
+	assert.ErrorContains(t, err, "no key set")
+}
+
+func TestWriteDirConfig_RoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), DirConfigFileName)
+
+	require.NoError(t, WriteDirConfig(path, DirConfig{Key: "id_ed25519_oss"}))
+
+	config, err := ReadDirConfig(path)
+	require.NoError(t, err)
+	assert.Equal(t, "id_ed25519_oss", config.Key)
+}
+
+func TestDirConfig_SSHCommand(t *testing.T) {
+	assert.Equal(t, "ssh -i '/keys/id_ed25519_work' -o IdentitiesOnly=yes", DirConfig{Key: "id_ed25519_work"}.SSHCommand("/keys"))
+	assert.Equal(t, "ssh -i '/opt/keys/deploy' -o IdentitiesOnly=yes", DirConfig{Key: "/opt/keys/deploy"}.SSHCommand("/keys"))
+	assert.Equal(t, "ssh -i '/my keys/id_rsa' -o IdentitiesOnly=yes", DirConfig{Key: "id_rsa"}.SSHCommand("/my keys"))
+	assert.Equal(t, `ssh -i '/keys/bob'\''s key' -o IdentitiesOnly=yes`, DirConfig{Key: "bob's key"}.SSHCommand("/keys"))
+}
@@ -0,0 +1,148 @@
+package git
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/residwi/sshman/internal/interfaces"
+	"github.com/residwi/sshman/internal/ssh"
+)
+
+type RemoteURL struct {
+	Scheme string
+	User   string
+	Host   string
+	Port   string
+	Path   string
+}
+
+type RemoteManager struct {
+	executor interfaces.CommandExecutor
+}
+
+func NewRemoteManager(executor interfaces.CommandExecutor) *RemoteManager {
+	return &RemoteManager{
+		executor: executor,
+	}
+}
+
+func (rm *RemoteManager) GetURL(remote string) (string, error) {
+	output, err := rm.executor.ExecuteWithOutput("git", "remote", "get-url", remote)
+	if err != nil {
+		return "", fmt.Errorf("failed to get URL of remote [%s]: %w", remote, err)
+	}
+
+	return strings.TrimSpace(string(output)), nil
+}
+
+func (rm *RemoteManager) SetURL(remote, remoteURL string) error {
+	if err := rm.executor.Execute("git", "remote", "set-url", remote, remoteURL); err != nil {
+		return fmt.Errorf("failed to set URL of remote [%s]: %w", remote, err)
+	}
+
+	return nil
+}
+
+// ParseRemoteURL parses scp-like (git@host:path), ssh:// and http(s):// remote URLs
+func ParseRemoteURL(remoteURL string) (RemoteURL, error) {
+	if strings.Contains(remoteURL, "://") {
+		parsed, err := url.Parse(remoteURL)
+		if err != nil {
+			return RemoteURL{}, fmt.Errorf("invalid remote URL %s: %w", remoteURL, err)
+		}
+
+		switch parsed.Scheme {
+		case "ssh", "git+ssh", "http", "https":
+		default:
+			return RemoteURL{}, fmt.Errorf("unsupported remote URL scheme: %s", parsed.Scheme)
+		}
+
+		return RemoteURL{
+			Scheme: parsed.Scheme,
+			User:   parsed.User.Username(),
+			Host:   parsed.Hostname(),
+			Port:   parsed.Port(),
+			Path:   strings.TrimPrefix(parsed.Path, "/"),
+		}, nil
+	}
+
+	userHost, path, found := strings.Cut(remoteURL, ":")
+	if !found || path == "" || strings.Contains(userHost, "/") {
+		return RemoteURL{}, fmt.Errorf("invalid remote URL: %s", remoteURL)
+	}
+
+	user, host := splitSCPHost(userHost)
+	return RemoteURL{User: user, Host: host, Path: path}, nil
+}
+
+func (r RemoteURL) String() string {
+	userHost := r.Host
+	if r.User != "" {
+		userHost = r.User + "@" + r.Host
+	}
+
+	if r.Scheme == "" {
+		return userHost + ":" + r.Path
+	}
+
+	if r.Port != "" {
+		userHost += ":" + r.Port
+	}
+	return r.Scheme + "://" + userHost + "/" + r.Path
+}
+
+// WithHostAlias points the remote at a host alias. HTTP remotes are turned into
+// scp-like SSH remotes since host aliases only apply to SSH.
+func (r RemoteURL) WithHostAlias(entry ssh.ConfigEntry) RemoteURL {
+	if r.Scheme == "http" || r.Scheme == "https" {
+		return RemoteURL{User: entry.User, Host: entry.Host, Path: r.Path}
+	}
+
+	r.Host = entry.Host
+	if entry.User != "" {
+		r.User = entry.User
+	}
+	return r
+}
+
+// ResolveHostAlias returns the config entry a remote should use. With a key, the entry
+// using that key for the remote's hostname is chosen; without one, the alias is
+// detected from the hostname and must be unambiguous.
+func ResolveHostAlias(entries []ssh.ConfigEntry, remote RemoteURL, keyPath string) (ssh.ConfigEntry, error) {
+	hostname := remote.Host
+	for _, entry := range entries {
+		if entry.Host == remote.Host && entry.Hostname != "" {
+			hostname = entry.Hostname
+			break
+		}
+	}
+
+	candidates := entries
+	if keyPath != "" {
+		candidates = ssh.FindEntriesByIdentityFile(entries, keyPath)
+		if len(candidates) == 0 {
+			return ssh.ConfigEntry{}, fmt.Errorf("no host alias in SSH config uses key [%s]", keyPath)
+		}
+	}
+
+	var matches []ssh.ConfigEntry
+	var aliases []string
+	for _, entry := range candidates {
+		if entry.Hostname == hostname {
+			matches = append(matches, entry)
+			aliases = append(aliases, entry.Host)
+		}
+	}
+
+	switch {
+	case len(matches) == 0 && keyPath != "":
+		return ssh.ConfigEntry{}, fmt.Errorf("key [%s] is not configured for host %s", keyPath, hostname)
+	case len(matches) == 0:
+		return ssh.ConfigEntry{}, fmt.Errorf("no host alias in SSH config points to %s", hostname)
+	case len(matches) > 1 && keyPath == "":
+		return ssh.ConfigEntry{}, fmt.Errorf("several host aliases point to %s: %s. Use --key to choose one", hostname, strings.Join(aliases, ", "))
+	}
+
+	return matches[0], nil
+}
@@ -0,0 +1,137 @@
+package git
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/residwi/sshman/internal/ssh"
+	"github.com/residwi/sshman/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRemoteURL(t *testing.T) {
+	tests := []struct {
+		name        string
+		remoteURL   string
+		expected    RemoteURL
+		expectError bool
+	}{
+		{"scp_like", "git@github.com:residwi/sshman.git", RemoteURL{User: "git", Host: "github.com", Path: "residwi/sshman.git"}, false},
+		{"scp_like_without_user", "github.com:residwi/sshman.git", RemoteURL{Host: "github.com", Path: "residwi/sshman.git"}, false},
+		{"ssh_scheme", "ssh://git@gitlab.com:2222/group/project.git", RemoteURL{Scheme: "ssh", User: "git", Host: "gitlab.com", Port: "2222", Path: "group/project.git"}, false},
+		{"https_scheme", "https://github.com/residwi/sshman.git", RemoteURL{Scheme: "https", Host: "github.com", Path: "residwi/sshman.git"}, false},
+		{"unsupported_scheme", "file:///srv/repo.git", RemoteURL{}, true},
+		{"local_path", "/srv/repo.git", RemoteURL{}, true},
+		{"relative_path", "../repo.git", RemoteURL{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := ParseRemoteURL(tt.remoteURL)
+			if tt.expectError {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, result)
+			assert.Equal(t, tt.remoteURL, result.String())
+		})
+	}
+}
+
+func TestRemoteURL_WithHostAlias(t *testing.T) {
+	entry := ssh.ConfigEntry{Host: "github-work", User: "git", Hostname: "github.com"}
+
+	tests := []struct {
+		name      string
+		remoteURL string
+		expected  string
+	}{
+		{"scp_like", "git@github.com:company/project.git", "git@github-work:company/project.git"},
+		{"ssh_scheme", "ssh://git@github.com/company/project.git", "ssh://git@github-work/company/project.git"},
+		{"https_scheme", "https://github.com/company/project.git", "git@github-work:company/project.git"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			remote, err := ParseRemoteURL(tt.remoteURL)
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.expected, remote.WithHostAlias(entry).String())
+		})
+	}
+}
+
+func TestResolveHostAlias(t *testing.T) {
+	entries := []ssh.ConfigEntry{
+		{Host: "github-work", User: "git", Hostname: "github.com", IdentityFile: "/keys/id_ed25519_work"},
+		{Host: "github-personal", User: "git", Hostname: "github.com", IdentityFile: "/keys/id_ed25519_personal"},
+		{Host: "gitlab-work", User: "git", Hostname: "gitlab.com", IdentityFile: "/keys/id_ed25519_work"},
+		{Host: "server", User: "deploy", Hostname: "server.example.com", IdentityFile: "/keys/id_ed25519_server"},
+	}
+
+	tests := []struct {
+		name          string
+		remoteHost    string
+		keyPath       string
+		expectedHost  string
+		expectedError string
+	}{
+		{"key_for_github", "github.com", "/keys/id_ed25519_work", "github-work", ""},
+		{"key_for_gitlab", "gitlab.com", "/keys/id_ed25519_work", "gitlab-work", ""},
+		{"key_from_other_alias", "github-personal", "/keys/id_ed25519_work", "github-work", ""},
+		{"detect_unique_alias", "gitlab.com", "", "gitlab-work", ""},
+		{"detect_ambiguous_alias", "github.com", "", "", "several host aliases point to github.com: github-work, github-personal"},
+		{"key_not_for_host", "server.example.com", "/keys/id_ed25519_work", "", "is not configured for host server.example.com"},
+		{"key_not_in_config", "github.com", "/keys/id_ed25519_unknown", "", "no host alias in SSH config uses key"},
+		{"unknown_host", "bitbucket.org", "", "", "no host alias in SSH config points to bitbucket.org"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry, err := ResolveHostAlias(entries, RemoteURL{User: "git", Host: tt.remoteHost, Path: "org/repo.git"}, tt.keyPath)
+			if tt.expectedError != "" {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedError)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedHost, entry.Host)
+		})
+	}
+}
+
+func TestRemoteManager_GetURL_Success(t *testing.T) {
+	mockExecutor := mocks.NewMockCommandExecutor(t)
+	mockExecutor.EXPECT().ExecuteWithOutput("git", []string{"remote", "get-url", "origin"}).Return([]byte("git@github.com:org/repo.git\n"), nil)
+
+	remoteMgr := NewRemoteManager(mockExecutor)
+	remoteURL, err := remoteMgr.GetURL("origin")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "git@github.com:org/repo.git", remoteURL)
+}
+
+func TestRemoteManager_GetURL_Fails_Error(t *testing.T) {
+	mockExecutor := mocks.NewMockCommandExecutor(t)
+	mockExecutor.EXPECT().ExecuteWithOutput("git", []string{"remote", "get-url", "upstream"}).Return(nil, fmt.Errorf("exit status 2"))
+
+	remoteMgr := NewRemoteManager(mockExecutor)
+	_, err := remoteMgr.GetURL("upstream")
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to get URL of remote [upstream]")
+}
+
+func TestRemoteManager_SetURL_Success(t *testing.T) {
+	mockExecutor := mocks.NewMockCommandExecutor(t)
+	mockExecutor.EXPECT().Execute("git", []string{"remote", "set-url", "origin", "git@github-work:org/repo.git"}).Return(nil)
+
+	remoteMgr := NewRemoteManager(mockExecutor)
+	err := remoteMgr.SetURL("origin", "git@github-work:org/repo.git")
+
+	assert.NoError(t, err)
+}
@@ -1,12 +1,19 @@
 package interfaces
 
 import (
+	"bytes"
+	"context"
 	"os/exec"
+	"time"
 )
 
 type CommandExecutor interface {
 	Execute(name string, args ...string) error
 	ExecuteWithOutput(name string, args ...string) ([]byte, error)
+	ExecuteWithCombinedOutput(name string, args ...string) ([]byte, error)
+	// ExecuteWithCombinedOutputContext kills the command when the context is done
+	ExecuteWithCombinedOutputContext(ctx context.Context, name string, args ...string) ([]byte, error)
+	ExecuteWithInput(input []byte, name string, args ...string) error
 }
 
 type DefaultCommandExecutor struct{}
@@ -18,3 +25,20 @@ func (r *DefaultCommandExecutor) Execute(name string, args ...string) error {
 func (r *DefaultCommandExecutor) ExecuteWithOutput(name string, args ...string) ([]byte, error) {
 	return exec.Command(name, args...).Output()
 }
+
+func (r *DefaultCommandExecutor) ExecuteWithCombinedOutput(name string, args ...string) ([]byte, error) {
+	return exec.Command(name, args...).CombinedOutput()
+}
+
+func (r *DefaultCommandExecutor) ExecuteWithCombinedOutputContext(ctx context.Context, name string, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, name, args...)
+	// a child left holding the output open, like a ProxyCommand, must not keep us waiting
+	cmd.WaitDelay = time.Second
+	return cmd.CombinedOutput()
+}
+
+func (r *DefaultCommandExecutor) ExecuteWithInput(input []byte, name string, args ...string) error {
+	cmd := exec.Command(name, args...)
+	cmd.Stdin = bytes.NewReader(input)
+	return cmd.Run()
+}
@@ -0,0 +1,188 @@
+package profile
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/residwi/sshman/internal/ssh"
+	"github.com/residwi/sshman/utils"
+	"gopkg.in/yaml.v3"
+)
+
+const FileName = "profiles.yaml"
+
+var (
+	namePattern    = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+	envNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+)
+
+// Profile is a named group of keys that are loaded in the agent together
+type Profile struct {
+	Name string   `yaml:"-"`
+	Keys []string `yaml:"keys"`
+	// Lifetime is how long the agent keeps the keys, e.g. 8h, forever when empty
+	Lifetime string            `yaml:"lifetime,omitempty"`
+	Env      map[string]string `yaml:"env,omitempty"`
+}
+
+// Path returns the profiles file in the sshman configuration directory
+func Path() string {
+	return filepath.Join(utils.ConfigDir(), FileName)
+}
+
+// Load reads the profiles, sorted by name. A missing file has no profiles.
+func Load(path string) ([]Profile, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return []Profile{}, nil
+		}
+		return nil, fmt.Errorf("failed to read profiles: %w", err)
+	}
+
+	var byName map[string]Profile
+	if err := yaml.Unmarshal(content, &byName); err != nil {
+		return nil, fmt.Errorf("failed to parse profiles %s: %w", utils.ReplaceHomeDirWithTilde(path), err)
+	}
+
+	profiles := make([]Profile, 0, len(byName))
+	for name, profile := range byName {
+		profile.Name = name
+		profiles = append(profiles, profile)
+	}
+	slices.SortFunc(profiles, func(a, b Profile) int { return strings.Compare(a.Name, b.Name) })
+
+	return profiles, nil
+}
+
+func Save(path string, profiles []Profile) error {
+	byName := make(map[string]Profile, len(profiles))
+	for _, profile := range profiles {
+		byName[profile.Name] = profile
+	}
+
+	content, err := yaml.Marshal(byName)
+	if err != nil {
+		return fmt.Errorf("failed to encode profiles: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create profiles directory: %w", err)
+	}
+
+	if err := utils.WriteFileAtomic(path, content, 0600); err != nil {
+		return fmt.Errorf("failed to write profiles: %w", err)
+	}
+
+	return nil
+}
+
+func Find(profiles []Profile, name string) (Profile, bool) {
+	index := slices.IndexFunc(profiles, func(p Profile) bool { return p.Name == name })
+	if index == -1 {
+		return Profile{}, false
+	}
+	return profiles[index], true
+}
+
+// Put adds the profile, replacing a profile with the same name
+func Put(profiles []Profile, profile Profile) []Profile {
+	profiles = Remove(profiles, profile.Name)
+	profiles = append(profiles, profile)
+	slices.SortFunc(profiles, func(a, b Profile) int { return strings.Compare(a.Name, b.Name) })
+	return profiles
+}
+
+func Remove(profiles []Profile, name string) []Profile {
+	return slices.DeleteFunc(slices.Clone(profiles), func(p Profile) bool { return p.Name == name })
+}
+
+// ReplaceKey renames a key in every profile that uses it and returns the names of those profiles
+func ReplaceKey(profiles []Profile, oldName, newName string) []string {
+	var changed []string
+	for _, profile := range profiles {
+		if index := slices.Index(profile.Keys, oldName); index != -1 {
+			profile.Keys[index] = newName
+			changed = append(changed, profile.Name)
+		}
+	}
+	return changed
+}
+
+// Validate checks the profile and that its keys exist in the SSH directory
+func (p Profile) Validate(sshPath string) error {
+	if !namePattern.MatchString(p.Name) {
+		return fmt.Errorf("invalid profile name: %s. Use letters, digits, '.', '_' and '-'", p.Name)
+	}
+
+	if len(p.Keys) == 0 {
+		return fmt.Errorf("profile [%s] has no keys", p.Name)
+	}
+
+	for _, keyName := range p.Keys {
+		if utils.IsFileNotExist(filepath.Join(sshPath, keyName)) {
+			return fmt.Errorf("SSH key [%s] of profile [%s] does not exist", keyName, p.Name)
+		}
+	}
+
+	if _, err := p.LifetimeDuration(); err != nil {
+		return err
+	}
+
+	for name := range p.Env {
+		if !envNamePattern.MatchString(name) {
+			return fmt.Errorf("invalid environment variable name in profile [%s]: %s", p.Name, name)
+		}
+	}
+
+	return nil
+}
+
+// LifetimeDuration parses the lifetime, zero means the keys do not expire
+func (p Profile) LifetimeDuration() (time.Duration, error) {
+	if p.Lifetime == "" {
+		return 0, nil
+	}
+
+	lifetime, err := utils.ParseDuration(p.Lifetime)
+	if err != nil || lifetime < time.Second {
+		return 0, fmt.Errorf("invalid lifetime of profile [%s]: %s", p.Name, p.Lifetime)
+	}
+	return lifetime, nil
+}
+
+// KeyPaths returns the paths of the profile's keys in the SSH directory
+func (p Profile) KeyPaths(sshPath string) []string {
+	keyPaths := make([]string, 0, len(p.Keys))
+	for _, keyName := range p.Keys {
+		keyPaths = append(keyPaths, filepath.Join(sshPath, keyName))
+	}
+	return keyPaths
+}
+
+// EnvNames returns the names of the profile's environment variables, sorted
+func (p Profile) EnvNames() []string {
+	names := make([]string, 0, len(p.Env))
+	for name := range p.Env {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// Current returns the profile whose keys are exactly the keys loaded in the agent
+func Current(profiles []Profile, sshPath string, agentKeys []string) (Profile, bool) {
+	for _, profile := range profiles {
+		loaded, others := ssh.MatchAgentKeys(agentKeys, profile.KeyPaths(sshPath))
+		if len(profile.Keys) > 0 && others == 0 && len(loaded) == len(profile.Keys) {
+			return profile, true
+		}
+	}
+	return Profile{}, false
+}
@@ -0,0 +1,136 @@
+package profile
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeKey(t *testing.T, sshPath, keyName, publicKey string) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(filepath.Join(sshPath, keyName), []byte("private"), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(sshPath, keyName+".pub"), []byte(publicKey+"\n"), 0644))
+}
+
+func TestLoad_NoFile_Empty(t *testing.T) {
+	profiles, err := Load(filepath.Join(t.TempDir(), FileName))
+
+	require.NoError(t, err)
+	assert.Empty(t, profiles)
+}
+
+func TestSave_RoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sshman", FileName)
+
+	profiles := Put(nil, Profile{Name: "personal", Keys: []string{"id_ed25519"}})
+	profiles = Put(profiles, Profile{Name: "clientA", Keys: []string{"id_ed25519_clienta"}, Lifetime: "8h", Env: map[string]string{"AWS_PROFILE": "clienta"}})
+	require.NoError(t, Save(path, profiles))
+
+	loaded, err := Load(path)
+	require.NoError(t, err)
+	require.Len(t, loaded, 2)
+	assert.Equal(t, "clientA", loaded[0].Name)
+	assert.Equal(t, []string{"id_ed25519_clienta"}, loaded[0].Keys)
+	assert.Equal(t, "8h", loaded[0].Lifetime)
+	assert.Equal(t, map[string]string{"AWS_PROFILE": "clienta"}, loaded[0].Env)
+	assert.Equal(t, "personal", loaded[1].Name)
+
+	profile, ok := Find(loaded, "personal")
+	assert.True(t, ok)
+	assert.Equal(t, []string{"id_ed25519"}, profile.Keys)
+
+	loaded = Remove(loaded, "personal")
+	_, ok = Find(loaded, "personal")
+	assert.False(t, ok)
+}
+
+func TestLoad_InvalidYAML_Error(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	require.NoError(t, os.WriteFile(path, []byte("clientA: [\n"), 0600))
+
+	_, err := Load(path)
+
+	assert.ErrorContains(t, err, "failed to parse profiles")
+}
+
+func TestReplaceKey(t *testing.T) {
+	profiles := []Profile{
+		{Name: "clientA", Keys: []string{"id_ed25519_clienta", "id_ed25519_deploy"}},
+		{Name: "personal", Keys: []string{"id_ed25519"}},
+	}
+
+	changed := ReplaceKey(profiles, "id_ed25519_deploy", "id_ed25519_clienta_deploy")
+
+	assert.Equal(t, []string{"clientA"}, changed)
+	assert.Equal(t, []string{"id_ed25519_clienta", "id_ed25519_clienta_deploy"}, profiles[0].Keys)
+	assert.Equal(t, []string{"id_ed25519"}, profiles[1].Keys)
+}
+
+func TestProfile_Validate(t *testing.T) {
+	sshPath := t.TempDir()
+	writeKey(t, sshPath, "id_ed25519_work", "ssh-ed25519 AAAAwork work@example.com")
+
+	tests := []struct {
+		name    string
+		profile Profile
+		message string
+	}{
+		{"valid", Profile{Name: "work", Keys: []string{"id_ed25519_work"}, Lifetime: "8h", Env: map[string]string{"GIT_AUTHOR_EMAIL": "me@work.com"}}, ""},
+		{"invalid_name", Profile{Name: "my work", Keys: []string{"id_ed25519_work"}}, "invalid profile name"},
+		{"no_keys", Profile{Name: "work"}, "has no keys"},
+		{"missing_key", Profile{Name: "work", Keys: []string{"id_rsa"}}, "SSH key [id_rsa] of profile [work] does not exist"},
+		{"invalid_lifetime", Profile{Name: "work", Keys: []string{"id_ed25519_work"}, Lifetime: "soon"}, "invalid lifetime"},
+		{"invalid_env", Profile{Name: "work", Keys: []string{"id_ed25519_work"}, Env: map[string]string{"1X": "y"}}, "invalid environment variable name"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.profile.Validate(sshPath)
+			if tt.message == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorContains(t, err, tt.message)
+			}
+		})
+	}
+}
+
+func TestProfile_LifetimeDuration(t *testing.T) {
+	lifetime, err := Profile{Lifetime: "8h"}.LifetimeDuration()
+	require.NoError(t, err)
+	assert.Equal(t, 8*time.Hour, lifetime)
+
+	lifetime, err = Profile{}.LifetimeDuration()
+	require.NoError(t, err)
+	assert.Zero(t, lifetime)
+}
+
+func TestCurrent(t *testing.T) {
+	sshPath := t.TempDir()
+	writeKey(t, sshPath, "id_ed25519_a", "ssh-ed25519 AAAAa a@example.com")
+	writeKey(t, sshPath, "id_ed25519_b", "ssh-ed25519 AAAAb b@example.com")
+	writeKey(t, sshPath, "id_ed25519_me", "ssh-ed25519 AAAAme me@example.com")
+
+	profiles := []Profile{
+		{Name: "clientA", Keys: []string{"id_ed25519_a", "id_ed25519_b"}},
+		{Name: "personal", Keys: []string{"id_ed25519_me"}},
+	}
+
+	profile, ok := Current(profiles, sshPath, []string{"ssh-ed25519 AAAAb b@example.com", "ssh-ed25519 AAAAa a@example.com"})
+	assert.True(t, ok)
+	assert.Equal(t, "clientA", profile.Name)
+
+	profile, ok = Current(profiles, sshPath, []string{"ssh-ed25519 AAAAme me@example.com", "ssh-ed25519-cert-v01@openssh.com AAAAcert me@example.com"})
+	assert.True(t, ok)
+	assert.Equal(t, "personal", profile.Name)
+
+	_, ok = Current(profiles, sshPath, []string{"ssh-ed25519 AAAAa a@example.com"})
+	assert.False(t, ok)
+
+	_, ok = Current(profiles, sshPath, []string{"ssh-ed25519 AAAAme me@example.com", "ssh-ed25519 AAAAother other@example.com"})
+	assert.False(t, ok)
+}
@@ -0,0 +1,119 @@
+package provider
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+type remoteKey struct {
+	ID    int64  `json:"id"`
+	Title string `json:"title"`
+	Key   string `json:"key"`
+}
+
+// KeyAPI manages the SSH keys of the authenticated user through a provider's REST API
+type KeyAPI struct {
+	provider string
+	baseURL  string
+	token    string
+	client   *http.Client
+}
+
+func NewKeyAPI(provider, baseURL, token string) (*KeyAPI, error) {
+	if provider != "github" && provider != "gitlab" {
+		return nil, fmt.Errorf("managing keys through the API is not supported for provider %s", provider)
+	}
+
+	return &KeyAPI{
+		provider: provider,
+		baseURL:  strings.TrimSuffix(baseURL, "/"),
+		token:    token,
+		client:   &http.Client{Timeout: 30 * time.Second},
+	}, nil
+}
+
+func (api *KeyAPI) AddKey(title, publicKey string) error {
+	body := map[string]string{"title": title, "key": strings.TrimSpace(publicKey)}
+	if err := api.do(http.MethodPost, "/user/keys", body, nil); err != nil {
+		return fmt.Errorf("failed to upload public key to %s: %w", api.provider, err)
+	}
+
+	return nil
+}
+
+// RemoveKey revokes the uploaded key matching the public key, ignoring its comment
+func (api *KeyAPI) RemoveKey(publicKey string) error {
+	var keys []remoteKey
+	if err := api.do(http.MethodGet, "/user/keys", nil, &keys); err != nil {
+		return fmt.Errorf("failed to list public keys on %s: %w", api.provider, err)
+	}
+
+	for _, key := range keys {
+		if stripKeyComment(key.Key) != stripKeyComment(publicKey) {
+			continue
+		}
+
+		if err := api.do(http.MethodDelete, fmt.Sprintf("/user/keys/%d", key.ID), nil, nil); err != nil {
+			return fmt.Errorf("failed to revoke public key on %s: %w", api.provider, err)
+		}
+		return nil
+	}
+
+	return fmt.Errorf("public key not found on %s", api.provider)
+}
+
+func (api *KeyAPI) do(method, path string, body, result any) error {
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequest(method, api.baseURL+path, reader)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	switch api.provider {
+	case "github":
+		req.Header.Set("Accept", "application/vnd.github+json")
+		req.Header.Set("Authorization", "Bearer "+api.token)
+	case "gitlab":
+		req.Header.Set("PRIVATE-TOKEN", api.token)
+	}
+
+	resp, err := api.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("unexpected status %s: %s", resp.Status, strings.TrimSpace(string(message)))
+	}
+
+	if result == nil {
+		return nil
+	}
+
+	return json.NewDecoder(resp.Body).Decode(result)
+}
+
+// stripKeyComment keeps the type and key data of a public key
+func stripKeyComment(publicKey string) string {
+	fields := strings.Fields(publicKey)
+	if len(fields) < 2 {
+		return publicKey
+	}
+	return fields[0] + " " + fields[1]
+}
@@ -0,0 +1,101 @@
+package provider
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewKeyAPI_UnsupportedProvider_Error(t *testing.T) {
+	_, err := NewKeyAPI("bitbucket", "https://api.bitbucket.org", "token")
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "not supported for provider bitbucket")
+}
+
+func TestKeyAPI_AddKey_GitHub_Success(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "/user/keys", r.URL.Path)
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+
+		var body map[string]string
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, "sshman id_ed25519_work", body["title"])
+		assert.Equal(t, "ssh-ed25519 AAAAnew work@company.com", body["key"])
+
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	api, err := NewKeyAPI("github", server.URL, "secret")
+	require.NoError(t, err)
+
+	err = api.AddKey("sshman id_ed25519_work", "ssh-ed25519 AAAAnew work@company.com\n")
+
+	assert.NoError(t, err)
+}
+
+func TestKeyAPI_AddKey_Unauthorized_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"message":"Bad credentials"}`, http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	api, err := NewKeyAPI("github", server.URL, "invalid")
+	require.NoError(t, err)
+
+	err = api.AddKey("title", "ssh-ed25519 AAAAnew")
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to upload public key to github")
+	assert.Contains(t, err.Error(), "Bad credentials")
+}
+
+func TestKeyAPI_RemoveKey_GitLab_Success(t *testing.T) {
+	deleted := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "secret", r.Header.Get("PRIVATE-TOKEN"))
+
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/user/keys":
+			json.NewEncoder(w).Encode([]remoteKey{
+				{ID: 1, Title: "laptop", Key: "ssh-ed25519 AAAAother"},
+				{ID: 2, Title: "work", Key: "ssh-ed25519 AAAAold"},
+			})
+		case r.Method == http.MethodDelete && r.URL.Path == "/user/keys/2":
+			deleted = true
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	api, err := NewKeyAPI("gitlab", server.URL, "secret")
+	require.NoError(t, err)
+
+	err = api.RemoveKey("ssh-ed25519 AAAAold work@company.com")
+
+	assert.NoError(t, err)
+	assert.True(t, deleted)
+}
+
+func TestKeyAPI_RemoveKey_NotFound_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode([]remoteKey{{ID: 1, Key: "ssh-ed25519 AAAAother"}})
+	}))
+	defer server.Close()
+
+	api, err := NewKeyAPI("github", server.URL, "secret")
+	require.NoError(t, err)
+
+	err = api.RemoveKey("ssh-ed25519 AAAAold")
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "public key not found on github")
+}
@@ -0,0 +1,49 @@
+package provider
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// maxPublicKeysSize bounds the response of a public keys URL, which lists only a handful of keys
+const maxPublicKeysSize = 1 << 20
+
+// GetKeysURL returns the URL serving the public keys of a user on the provider
+func GetKeysURL(providerName, username string) (string, error) {
+	config, exists := GetProviderConfig(providerName)
+	if !exists || config.KeysURL == "" {
+		return "", fmt.Errorf("listing public keys of a user is not supported for provider %s", providerName)
+	}
+
+	if username == "" || strings.ContainsAny(username, "/?#") {
+		return "", fmt.Errorf("invalid username: %s", username)
+	}
+
+	return fmt.Sprintf(config.KeysURL, url.PathEscape(username)), nil
+}
+
+// FetchPublicKeys downloads a list of public keys in authorized_keys format
+func FetchPublicKeys(keysURL string) ([]byte, error) {
+	client := &http.Client{Timeout: 30 * time.Second}
+
+	resp, err := client.Get(keysURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch public keys: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch public keys: unexpected status %s", resp.Status)
+	}
+
+	content, err := io.ReadAll(io.LimitReader(resp.Body, maxPublicKeysSize))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch public keys: %w", err)
+	}
+
+	return content, nil
+}
@@ -0,0 +1,63 @@
+package provider
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetKeysURL(t *testing.T) {
+	tests := []struct {
+		name     string
+		provider string
+		username string
+		expected string
+		wantErr  bool
+	}{
+		{"github", "github", "residwi", "https://github.com/residwi.keys", false},
+		{"gitlab", "gitlab", "residwi", "https://gitlab.com/residwi.keys", false},
+		{"bitbucket_unsupported", "bitbucket", "residwi", "", true},
+		{"generic_unsupported", "generic", "residwi", "", true},
+		{"empty_username", "github", "", "", true},
+		{"path_in_username", "github", "../residwi", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keysURL, err := GetKeysURL(tt.provider, tt.username)
+
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, keysURL)
+		})
+	}
+}
+
+func TestFetchPublicKeys(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/residwi.keys", r.URL.Path)
+		w.Write([]byte("ssh-ed25519 AAAAkey1\nssh-rsa AAAAkey2\n"))
+	}))
+	defer server.Close()
+
+	content, err := FetchPublicKeys(server.URL + "/residwi.keys")
+
+	require.NoError(t, err)
+	assert.Equal(t, "ssh-ed25519 AAAAkey1\nssh-rsa AAAAkey2\n", string(content))
+}
+
+func TestFetchPublicKeys_NotFound(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	_, err := FetchPublicKeys(server.URL + "/missing.keys")
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "404")
+}
@@ -1,23 +1,62 @@
 package provider
 
+import (
+	"strings"
+	"time"
+)
+
+// defaultMaxKeyAge is how long keys for the built-in providers stay valid unless --expires is given
+const defaultMaxKeyAge = 365 * 24 * time.Hour
+
 type ProviderConfig struct {
-	User     string
-	Hostname string
+	User      string
+	Hostname  string
+	APIURL    string
+	TokenEnv  string
+	MaxKeyAge time.Duration
+	// KeysURL serves the public keys of a user, with %s standing for the username
+	KeysURL string
+	// HostKeyFingerprints are the SHA256 fingerprints the provider publishes for its SSH host keys
+	HostKeyFingerprints []string
 }
 
 func GetProviderConfig(provider string) (ProviderConfig, bool) {
 	providers := map[string]ProviderConfig{
 		"github": {
-			User:     "git",
-			Hostname: "github.com",
+			User:      "git",
+			Hostname:  "github.com",
+			APIURL:    "https://api.github.com",
+			TokenEnv:  "GITHUB_TOKEN",
+			MaxKeyAge: defaultMaxKeyAge,
+			KeysURL:   "https://github.com/%s.keys",
+			HostKeyFingerprints: []string{
+				"SHA256:+DiY3wvvV6TuJJhbpZisF/zLDA0zPMSvHdkr4UvCOqU",
+				"SHA256:p2QAMXNIC1TJYWeIOttrVc98/R1BUFWu3/LiyKgUfQM",
+				"SHA256:uNiVztksCsDhcc0u9e8BujQXVUpKZIDTMczCvj3tD2s",
+			},
 		},
 		"gitlab": {
-			User:     "git",
-			Hostname: "gitlab.com",
+			User:      "git",
+			Hostname:  "gitlab.com",
+			APIURL:    "https://gitlab.com/api/v4",
+			TokenEnv:  "GITLAB_TOKEN",
+			MaxKeyAge: defaultMaxKeyAge,
+			KeysURL:   "https://gitlab.com/%s.keys",
+			HostKeyFingerprints: []string{
+				"SHA256:eUXGGm1YGsMAS7vkcx6JOJdOGHPem5gQp4taiCfCLB8",
+				"SHA256:HbW3g8zUjNSksFbqTiUWPWg2Bq1x8xdGUrliXFzSnUw",
+				"SHA256:ROQFvPThGrW4RuWLoL9tq9I9zJ42fK4XywyRtbOz/EQ",
+			},
 		},
 		"bitbucket": {
-			User:     "git",
-			Hostname: "bitbucket.org",
+			User:      "git",
+			Hostname:  "bitbucket.org",
+			MaxKeyAge: defaultMaxKeyAge,
+			HostKeyFingerprints: []string{
+				"SHA256:ybgmFkzwOSotHTHLJgHO0QN8L0xErw6vd0VhFA9m3SM",
+				"SHA256:FC73VB6C4OQLSCrjEayhMp9UMxS97caD/Yyi2bhW/J0",
+				"SHA256:46OSHA1Rmj8E8ERTC6xkNcmGOw9oFxYr0WF6zWW8l1E",
+			},
 		},
 	}
 
@@ -25,6 +64,17 @@ func GetProviderConfig(provider string) (ProviderConfig, bool) {
 	return config, exists
 }
 
+// FindProviderByHostname returns the built-in provider serving the hostname
+func FindProviderByHostname(hostname string) (string, bool) {
+	for _, name := range GetSupportedProviders() {
+		config, exists := GetProviderConfig(name)
+		if exists && strings.EqualFold(config.Hostname, hostname) {
+			return name, true
+		}
+	}
+	return "", false
+}
+
 func GetSupportedProviders() []string {
 	return []string{"github", "gitlab", "bitbucket", "generic"}
 }
@@ -2,6 +2,7 @@ package provider
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -36,6 +37,7 @@ func TestGetProviderConfig(t *testing.T) {
 			if tt.shouldExist {
 				assert.Equal(t, tt.expectedUser, config.User)
 				assert.Equal(t, tt.expectedHost, config.Hostname)
+				assert.Equal(t, 365*24*time.Hour, config.MaxKeyAge)
 			} else {
 				assert.Equal(t, "", config.User)
 				assert.Equal(t, "", config.Hostname)
@@ -56,3 +58,25 @@ func TestGetSupportedProviders(t *testing.T) {
 
 	assert.Equal(t, expectedProviders, providers)
 }
+
+func TestFindProviderByHostname(t *testing.T) {
+	tests := []struct {
+		hostname string
+		expected string
+		found    bool
+	}{
+		{"github.com", "github", true},
+		{"GitLab.com", "gitlab", true},
+		{"bitbucket.org", "bitbucket", true},
+		{"server.example.com", "", false},
+		{"", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.hostname, func(t *testing.T) {
+			name, found := FindProviderByHostname(tt.hostname)
+			assert.Equal(t, tt.expected, name)
+			assert.Equal(t, tt.found, found)
+		})
+	}
+}
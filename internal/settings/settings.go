@@ -0,0 +1,352 @@
+package settings
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/residwi/sshman/utils"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	FileName  = "config.yaml"
+	envPrefix = "SSHMAN_"
+)
+
+const (
+	SourceDefault = "default"
+	SourceFile    = "file"
+	SourceEnv     = "env"
+)
+
+const (
+	OutputTable = "table"
+	OutputJSON  = "json"
+)
+
+var (
+	keyTypes = []string{"ed25519", "rsa"}
+	outputs  = []string{OutputTable, OutputJSON}
+)
+
+// Settings holds the defaults sshman uses when a flag is not given
+type Settings struct {
+	KeyType    string
+	RSABits    int
+	Email      string
+	SSHPath    string
+	AddToAgent bool
+	ConfigFile string
+	Output     string
+
+	sources map[string]string
+}
+
+type setting struct {
+	key         string
+	description string
+	get         func(s *Settings) string
+	set         func(s *Settings, value string) error
+}
+
+var settings = []setting{
+	{
+		key:         "key_type",
+		description: "Type of new keys (" + strings.Join(keyTypes, ", ") + ")",
+		get:         func(s *Settings) string { return s.KeyType },
+		set: func(s *Settings, value string) error {
+			if !slices.Contains(keyTypes, value) {
+				return fmt.Errorf("unsupported SSH key type: %s. Supported types are: %v", value, keyTypes)
+			}
+			s.KeyType = value
+			return nil
+		},
+	},
+	{
+		key:         "rsa_bits",
+		description: "Size of new RSA keys in bits",
+		get:         func(s *Settings) string { return strconv.Itoa(s.RSABits) },
+		set: func(s *Settings, value string) error {
+			bits, err := strconv.Atoi(value)
+			if err != nil || bits < 2048 || bits > 16384 {
+				return fmt.Errorf("invalid RSA key size: %s. It must be between 2048 and 16384 bits", value)
+			}
+			s.RSABits = bits
+			return nil
+		},
+	},
+	{
+		key:         "email",
+		description: "Email of new keys",
+		get:         func(s *Settings) string { return s.Email },
+		set: func(s *Settings, value string) error {
+			s.Email = value
+			return nil
+		},
+	},
+	{
+		key:         "ssh_path",
+		description: "Path to the SSH directory",
+		get:         func(s *Settings) string { return s.SSHPath },
+		set: func(s *Settings, value string) error {
+			if value == "" {
+				return fmt.Errorf("ssh_path cannot be empty")
+			}
+			s.SSHPath = value
+			return nil
+		},
+	},
+	{
+		key:         "add_to_agent",
+		description: "Add new keys to the running SSH agent",
+		get:         func(s *Settings) string { return strconv.FormatBool(s.AddToAgent) },
+		set: func(s *Settings, value string) error {
+			addToAgent, err := strconv.ParseBool(value)
+			if err != nil {
+				return fmt.Errorf("invalid value for add_to_agent: %s. Use true or false", value)
+			}
+			s.AddToAgent = addToAgent
+			return nil
+		},
+	},
+	{
+		key:         "config_file",
+		description: "SSH config file for Host entries, relative to the SSH directory",
+		get:         func(s *Settings) string { return s.ConfigFile },
+		set: func(s *Settings, value string) error {
+			if value == "" {
+				return fmt.Errorf("config_file cannot be empty")
+			}
+			s.ConfigFile = value
+			return nil
+		},
+	},
+	{
+		key:         "output",
+		description: "Output format of listings (" + strings.Join(outputs, ", ") + ")",
+		get:         func(s *Settings) string { return s.Output },
+		set: func(s *Settings, value string) error {
+			if !slices.Contains(outputs, value) {
+				return fmt.Errorf("unsupported output format: %s. Supported formats are: %v", value, outputs)
+			}
+			s.Output = value
+			return nil
+		},
+	},
+}
+
+func Default() *Settings {
+	return &Settings{
+		KeyType:    "ed25519",
+		RSABits:    4096,
+		SSHPath:    "~/.ssh",
+		AddToAgent: true,
+		ConfigFile: "config",
+		Output:     OutputTable,
+		sources:    map[string]string{},
+	}
+}
+
+// Path returns the settings file in the sshman configuration directory
+func Path() string {
+	return filepath.Join(utils.ConfigDir(), FileName)
+}
+
+// Keys returns the names of all settings
+func Keys() []string {
+	keys := make([]string, 0, len(settings))
+	for _, setting := range settings {
+		keys = append(keys, setting.key)
+	}
+	return keys
+}
+
+// Description explains what a setting is for
+func Description(key string) string {
+	if setting, err := lookup(key); err == nil {
+		return setting.description
+	}
+	return ""
+}
+
+// EnvName returns the environment variable that overrides a setting
+func EnvName(key string) string {
+	return envPrefix + strings.ToUpper(key)
+}
+
+// LoadFile reads the settings file over the defaults. A missing file leaves the defaults.
+func LoadFile(path string) (*Settings, error) {
+	s, problems, err := LoadFileLenient(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(problems) > 0 {
+		return nil, problems[0]
+	}
+	return s, nil
+}
+
+// LoadFileLenient reads the settings file like LoadFile, but skips unknown settings and
+// invalid values and returns them as problems, so a broken file can still be repaired
+func LoadFileLenient(path string) (*Settings, []error, error) {
+	s := Default()
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return s, nil, nil
+		}
+		return nil, nil, fmt.Errorf("failed to read settings: %w", err)
+	}
+
+	var values map[string]any
+	if err := yaml.Unmarshal(content, &values); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse settings %s: %w", utils.ReplaceHomeDirWithTilde(path), err)
+	}
+
+	var problems []error
+	for _, key := range slices.Sorted(maps.Keys(values)) {
+		value := values[key]
+		if value == nil {
+			continue
+		}
+		if err := s.Set(key, fmt.Sprint(value)); err != nil {
+			problems = append(problems, fmt.Errorf("invalid settings %s: %w", utils.ReplaceHomeDirWithTilde(path), err))
+			continue
+		}
+		s.sources[key] = SourceFile
+	}
+
+	return s, problems, nil
+}
+
+// Load reads the settings file and applies the SSHMAN_* environment variables over it
+func Load(path string, getenv func(string) string) (*Settings, error) {
+	s, problems, err := LoadLenient(path, getenv)
+	if err != nil {
+		return nil, err
+	}
+	if len(problems) > 0 {
+		return nil, problems[0]
+	}
+	return s, nil
+}
+
+// LoadLenient reads the settings like Load, returning invalid entries of the file and the
+// environment as problems instead of failing
+func LoadLenient(path string, getenv func(string) string) (*Settings, []error, error) {
+	s, problems, err := LoadFileLenient(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, setting := range settings {
+		value := getenv(EnvName(setting.key))
+		if value == "" {
+			continue
+		}
+		if err := setting.set(s, value); err != nil {
+			problems = append(problems, fmt.Errorf("invalid %s: %w", EnvName(setting.key), err))
+			continue
+		}
+		s.sources[setting.key] = SourceEnv
+	}
+
+	return s, problems, nil
+}
+
+// Save writes the settings that differ from the defaults
+func (s *Settings) Save(path string) error {
+	defaults := Default()
+	values := map[string]string{}
+	for _, setting := range settings {
+		if value := setting.get(s); value != setting.get(defaults) {
+			values[setting.key] = value
+		}
+	}
+
+	var content []byte
+	if len(values) > 0 {
+		var err error
+		content, err = yaml.Marshal(settingsNode(values))
+		if err != nil {
+			return fmt.Errorf("failed to encode settings: %w", err)
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create settings directory: %w", err)
+	}
+
+	if err := utils.WriteFileAtomic(path, content, 0600); err != nil {
+		return fmt.Errorf("failed to write settings: %w", err)
+	}
+
+	return nil
+}
+
+// settingsNode orders the settings like the settings list, keeping numbers and booleans unquoted
+func settingsNode(values map[string]string) *yaml.Node {
+	node := &yaml.Node{Kind: yaml.MappingNode}
+	for _, setting := range settings {
+		value, changed := values[setting.key]
+		if !changed {
+			continue
+		}
+
+		valueNode := &yaml.Node{Kind: yaml.ScalarNode}
+		valueNode.SetString(value)
+		if setting.key == "rsa_bits" || setting.key == "add_to_agent" {
+			valueNode.Tag = ""
+		}
+
+		node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: setting.key}, valueNode)
+	}
+	return node
+}
+
+func (s *Settings) Get(key string) (string, error) {
+	setting, err := lookup(key)
+	if err != nil {
+		return "", err
+	}
+	return setting.get(s), nil
+}
+
+// Set validates and changes a setting, an empty value resets it to its default
+func (s *Settings) Set(key, value string) error {
+	setting, err := lookup(key)
+	if err != nil {
+		return err
+	}
+
+	value = strings.TrimSpace(value)
+	if value == "" {
+		value = setting.get(Default())
+	}
+
+	return setting.set(s, value)
+}
+
+// Source reports where the value of a setting comes from
+func (s *Settings) Source(key string) string {
+	if source, exists := s.sources[key]; exists {
+		return source
+	}
+	return SourceDefault
+}
+
+func lookup(key string) (setting, error) {
+	for _, setting := range settings {
+		if setting.key == key {
+			return setting, nil
+		}
+	}
+	return setting{}, fmt.Errorf("unknown setting: %s. Available settings are: %v", key, Keys())
+}
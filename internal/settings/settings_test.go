@@ -0,0 +1,136 @@
+package settings
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func noEnv(string) string { return "" }
+
+func TestLoad_NoFile_Defaults(t *testing.T) {
+	s, err := Load(filepath.Join(t.TempDir(), FileName), noEnv)
+
+	require.NoError(t, err)
+	assert.Equal(t, "ed25519", s.KeyType)
+	assert.Equal(t, 4096, s.RSABits)
+	assert.Equal(t, "~/.ssh", s.SSHPath)
+	assert.True(t, s.AddToAgent)
+	assert.Equal(t, "config", s.ConfigFile)
+	assert.Equal(t, OutputTable, s.Output)
+	assert.Equal(t, SourceDefault, s.Source("key_type"))
+}
+
+func TestLoad_FileAndEnv(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	content := "key_type: rsa\nrsa_bits: 3072\nemail: me@example.com\nadd_to_agent: false\n"
+	require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+
+	env := map[string]string{"SSHMAN_EMAIL": "ci@example.com", "SSHMAN_OUTPUT": "json"}
+	s, err := Load(path, func(name string) string { return env[name] })
+
+	require.NoError(t, err)
+	assert.Equal(t, "rsa", s.KeyType)
+	assert.Equal(t, 3072, s.RSABits)
+	assert.False(t, s.AddToAgent)
+	assert.Equal(t, "ci@example.com", s.Email)
+	assert.Equal(t, OutputJSON, s.Output)
+	assert.Equal(t, SourceFile, s.Source("key_type"))
+	assert.Equal(t, SourceEnv, s.Source("email"))
+	assert.Equal(t, SourceDefault, s.Source("ssh_path"))
+}
+
+func TestLoad_Invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		env     map[string]string
+		message string
+	}{
+		{"unknown_setting", "colour: blue\n", nil, "unknown setting: colour"},
+		{"invalid_key_type", "key_type: dsa\n", nil, "unsupported SSH key type: dsa"},
+		{"invalid_yaml", "key_type: [\n", nil, "failed to parse settings"},
+		{"invalid_env", "", map[string]string{"SSHMAN_RSA_BITS": "1024"}, "invalid SSHMAN_RSA_BITS"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), FileName)
+			require.NoError(t, os.WriteFile(path, []byte(tt.content), 0600))
+
+			_, err := Load(path, func(name string) string { return tt.env[name] })
+
+			assert.ErrorContains(t, err, tt.message)
+		})
+	}
+}
+
+func TestLoadLenient_InvalidEntries_Problems(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	require.NoError(t, os.WriteFile(path, []byte("key_type: dsa\ncolour: blue\nemail: me@example.com\n"), 0600))
+	env := map[string]string{"SSHMAN_RSA_BITS": "1024"}
+
+	s, problems, err := LoadLenient(path, func(name string) string { return env[name] })
+
+	require.NoError(t, err)
+	require.Len(t, problems, 3)
+	assert.ErrorContains(t, problems[0], "unknown setting: colour")
+	assert.ErrorContains(t, problems[1], "unsupported SSH key type: dsa")
+	assert.ErrorContains(t, problems[2], "invalid SSHMAN_RSA_BITS")
+	assert.Equal(t, "ed25519", s.KeyType)
+	assert.Equal(t, SourceDefault, s.Source("key_type"))
+	assert.Equal(t, "me@example.com", s.Email)
+	assert.Equal(t, 4096, s.RSABits)
+}
+
+func TestLoadFileLenient_FixInvalidFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	require.NoError(t, os.WriteFile(path, []byte("key_type: dsa\nemail: me@example.com\n"), 0600))
+
+	s, problems, err := LoadFileLenient(path)
+	require.NoError(t, err)
+	require.Len(t, problems, 1)
+	require.NoError(t, s.Set("key_type", "rsa"))
+	require.NoError(t, s.Save(path))
+
+	fixed, err := Load(path, noEnv)
+	require.NoError(t, err)
+	assert.Equal(t, "rsa", fixed.KeyType)
+	assert.Equal(t, "me@example.com", fixed.Email)
+}
+
+func TestSettings_Save_RoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sshman", FileName)
+
+	s := Default()
+	require.NoError(t, s.Set("rsa_bits", "8192"))
+	require.NoError(t, s.Set("email", "me@example.com"))
+	require.NoError(t, s.Set("add_to_agent", "false"))
+	require.NoError(t, s.Save(path))
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "rsa_bits: 8192\nemail: me@example.com\nadd_to_agent: false\n", string(content))
+
+	loaded, err := LoadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, 8192, loaded.RSABits)
+	assert.Equal(t, "me@example.com", loaded.Email)
+	assert.False(t, loaded.AddToAgent)
+}
+
+func TestSettings_Set_EmptyResetsDefault(t *testing.T) {
+	s := Default()
+	require.NoError(t, s.Set("output", "json"))
+	require.NoError(t, s.Set("output", ""))
+
+	value, err := s.Get("output")
+	require.NoError(t, err)
+	assert.Equal(t, OutputTable, value)
+
+	_, err = s.Get("colour")
+	assert.ErrorContains(t, err, "unknown setting")
+}
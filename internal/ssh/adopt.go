@@ -0,0 +1,119 @@
+package ssh
+
+import (
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+
+	gossh "golang.org/x/crypto/ssh"
+)
+
+// KeyGuess is what sshman infers about a key it did not create
+type KeyGuess struct {
+	Metadata KeyMetadata
+	Hosts    []string
+}
+
+// GuessKeyMetadata infers the provider and purpose of an unmanaged key from the Host
+// entries that use it, and its type, email and creation time from the key files.
+// findProvider maps a hostname to the name of a known provider.
+func GuessKeyMetadata(keyPath string, entries []ConfigEntry, findProvider func(hostname string) (string, bool)) KeyGuess {
+	var guess KeyGuess
+
+	if info, err := os.Stat(keyPath); err == nil {
+		guess.Metadata.CreatedAt = info.ModTime()
+	}
+
+	if comment, err := ReadPublicKeyComment(keyPath + ".pub"); err == nil {
+		guess.Metadata.Email = comment
+	}
+
+	if publicKey := readKeyPublicKey(keyPath); publicKey != nil {
+		guess.Metadata.Type = KeyTypeFromPublicKey(publicKey.Type())
+	} else {
+		guess.Metadata.Type = keyTypeFromPEMHeader(keyPath)
+	}
+
+	for _, entry := range FindEntriesByIdentityFile(entries, keyPath) {
+		guess.Hosts = append(guess.Hosts, entry.Host)
+
+		providerName, found := findProvider(entry.Hostname)
+		if !found || guess.Metadata.Provider != "" {
+			continue
+		}
+
+		guess.Metadata.Provider = providerName
+		for _, prefix := range []string{providerName + "-", entry.Hostname + "-"} {
+			if purpose, ok := strings.CutPrefix(entry.Host, prefix); ok && purpose != "" {
+				guess.Metadata.Purpose = purpose
+				break
+			}
+		}
+	}
+
+	if guess.Metadata.Provider == "" && len(guess.Hosts) > 0 {
+		guess.Metadata.Provider = "generic"
+	}
+
+	if guess.Metadata.Purpose == "" && guess.Metadata.Type != "" {
+		// keys named like sshman's own, id_<type>_<purpose>
+		purpose, ok := strings.CutPrefix(filepath.Base(keyPath), "id_"+guess.Metadata.Type+"_")
+		if ok {
+			guess.Metadata.Purpose = purpose
+		}
+	}
+
+	return guess
+}
+
+// keyTypeFromPEMHeader reads the type of a PEM private key from its header, which
+// stays readable when the key is encrypted in the legacy PEM format
+func keyTypeFromPEMHeader(privateKeyPath string) string {
+	content, err := os.ReadFile(privateKeyPath)
+	if err != nil {
+		return ""
+	}
+
+	block, _ := pem.Decode(content)
+	if block == nil {
+		return ""
+	}
+
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		return "rsa"
+	case "EC PRIVATE KEY":
+		return "ecdsa"
+	case "DSA PRIVATE KEY":
+		return "dsa"
+	default:
+		return ""
+	}
+}
+
+// readKeyPublicKey reads the public key from the .pub file, falling back to the
+// private key, which exposes its public key even when encrypted
+func readKeyPublicKey(privateKeyPath string) gossh.PublicKey {
+	if publicKey, err := readAuthorizedKey(privateKeyPath + ".pub"); err == nil {
+		return publicKey
+	}
+
+	content, err := os.ReadFile(privateKeyPath)
+	if err != nil {
+		return nil
+	}
+
+	signer, err := gossh.ParsePrivateKey(content)
+	if err == nil {
+		return signer.PublicKey()
+	}
+
+	var passphraseMissing *gossh.PassphraseMissingError
+	if errors.As(err, &passphraseMissing) {
+		return passphraseMissing.PublicKey
+	}
+
+	return nil
+}
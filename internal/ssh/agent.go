@@ -1,17 +1,39 @@
 package ssh
 
 import (
+	"errors"
 	"fmt"
 	"io/fs"
+	"net"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
+	"strconv"
 	"strings"
+	"syscall"
+	"time"
 
 	"github.com/residwi/sshman/internal/interfaces"
 	"github.com/residwi/sshman/utils"
 )
 
+const (
+	AgentKindOpenSSH   = "ssh-agent"
+	AgentKindGPG       = "gpg-agent"
+	AgentKind1Password = "1password"
+	AgentKindSSHMan    = "sshman"
+)
+
+var agentPIDPattern = regexp.MustCompile(`SSH_AGENT_PID=(\d+)`)
+
+// AgentStatus describes the agent that SSH_AUTH_SOCK points at
+type AgentStatus struct {
+	Running bool   `json:"running"`
+	Kind    string `json:"kind,omitempty"`
+	Socket  string `json:"socket,omitempty"`
+}
+
 type AgentManager struct {
 	executor interfaces.CommandExecutor
 }
@@ -23,13 +45,25 @@ func NewAgentManager(executor interfaces.CommandExecutor) *AgentManager {
 }
 
 func (am *AgentManager) AddToAgent(sshPath, keyName string) error {
+	return am.AddToAgentWithLifetime(sshPath, keyName, 0)
+}
+
+// AddToAgentWithLifetime adds a key that the agent forgets after the lifetime, or keeps
+// until it is removed when the lifetime is zero
+func (am *AgentManager) AddToAgentWithLifetime(sshPath, keyName string, lifetime time.Duration) error {
 	keyPath := filepath.Join(sshPath, keyName)
 
 	if utils.IsFileNotExist(keyPath) {
 		return fmt.Errorf("SSH key [%s] does not exist", keyName)
 	}
 
-	if err := am.executor.Execute("ssh-add", keyPath); err != nil {
+	var args []string
+	if lifetime > 0 {
+		args = append(args, "-t", strconv.Itoa(int(lifetime.Seconds())))
+	}
+	args = append(args, keyPath)
+
+	if err := am.executor.Execute("ssh-add", args...); err != nil {
 		return fmt.Errorf("failed to add key to agent: %w", err)
 	}
 
@@ -112,6 +146,150 @@ func (am *AgentManager) IsAgentRunning() bool {
 	return err == nil
 }
 
+// Status reports whether an agent is running, which kind of agent it is and its socket
+func (am *AgentManager) Status() AgentStatus {
+	socket := os.Getenv("SSH_AUTH_SOCK")
+	status := AgentStatus{Running: am.IsAgentRunning(), Socket: socket}
+	if status.Running {
+		status.Kind = agentKind(socket)
+	}
+	return status
+}
+
+// StartAgent launches an ssh-agent listening on socket, or reuses the agent that already
+// listens on it. It returns the PID of the agent and whether it was started.
+func (am *AgentManager) StartAgent(socket string) (int, bool, error) {
+	if AgentSocketAlive(socket) {
+		// the PID is unknown when the agent on the socket was not started by sshman
+		pid, err := readAgentPID(socket)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return 0, false, err
+		}
+		return pid, false, nil
+	}
+
+	if err := utils.EnsurePrivateDir(filepath.Dir(socket)); err != nil {
+		return 0, false, fmt.Errorf("failed to prepare agent directory: %w", err)
+	}
+
+	// a socket left behind by an agent that died would make ssh-agent fail to bind
+	if err := os.Remove(socket); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return 0, false, fmt.Errorf("failed to remove stale agent socket: %w", err)
+	}
+
+	output, err := am.executor.ExecuteWithOutput("ssh-agent", "-s", "-a", socket)
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to start ssh-agent: %w", err)
+	}
+
+	match := agentPIDPattern.FindStringSubmatch(string(output))
+	if match == nil {
+		return 0, false, fmt.Errorf("failed to read PID of ssh-agent from its output")
+	}
+	pid, _ := strconv.Atoi(match[1])
+
+	if err := os.WriteFile(agentPIDPath(socket), []byte(strconv.Itoa(pid)+"\n"), 0600); err != nil {
+		return 0, false, fmt.Errorf("failed to write agent PID file: %w", err)
+	}
+
+	return pid, true, nil
+}
+
+// StopAgent terminates the agent started on socket by StartAgent
+func (am *AgentManager) StopAgent(socket string) error {
+	pid, err := readAgentPID(socket)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("no agent started by sshman is running")
+		}
+		return err
+	}
+
+	if !AgentSocketAlive(socket) {
+		// the agent is gone and its PID may belong to another process by now
+		os.Remove(socket)
+		os.Remove(agentPIDPath(socket))
+		return fmt.Errorf("no agent started by sshman is running")
+	}
+
+	if !isAgentProcess(pid) {
+		return fmt.Errorf("process %d is not an ssh-agent or sshman agent, not stopping it", pid)
+	}
+
+	process, err := os.FindProcess(pid)
+	if err == nil {
+		err = process.Signal(syscall.SIGTERM)
+	}
+	if err != nil && !errors.Is(err, os.ErrProcessDone) {
+		return fmt.Errorf("failed to stop agent with PID %d: %w", pid, err)
+	}
+
+	os.Remove(socket)
+	os.Remove(agentPIDPath(socket))
+	return nil
+}
+
+// ManagedAgentSocket returns the socket of the agent started by sshman
+func ManagedAgentSocket() string {
+	return filepath.Join(utils.RuntimeDir(), "agent.sock")
+}
+
+// AgentSocketAlive reports whether an agent accepts connections on socket
+func AgentSocketAlive(socket string) bool {
+	conn, err := net.DialTimeout("unix", socket, time.Second)
+	if err != nil {
+		return false
+	}
+	conn.Close()
+	return true
+}
+
+func agentKind(socket string) string {
+	switch {
+	case socket == ManagedAgentSocket():
+		return AgentKindSSHMan
+	case strings.Contains(socket, "gpg-agent"):
+		return AgentKindGPG
+	case strings.Contains(strings.ToLower(socket), "1password"):
+		return AgentKind1Password
+	default:
+		return AgentKindOpenSSH
+	}
+}
+
+// isAgentProcess reports whether pid runs ssh-agent or sshman. Without /proc, there is no
+// cheap way to tell, and the live socket next to the PID file has to do.
+func isAgentProcess(pid int) bool {
+	if utils.IsDirectoryNotExist("/proc/self") {
+		return true
+	}
+
+	comm, err := os.ReadFile(filepath.Join("/proc", strconv.Itoa(pid), "comm"))
+	if err != nil {
+		return false
+	}
+
+	name := strings.TrimSpace(string(comm))
+	return name == "ssh-agent" || name == "sshman"
+}
+
+func agentPIDPath(socket string) string {
+	return strings.TrimSuffix(socket, filepath.Ext(socket)) + ".pid"
+}
+
+func readAgentPID(socket string) (int, error) {
+	content, err := os.ReadFile(agentPIDPath(socket))
+	if err != nil {
+		return 0, fmt.Errorf("failed to read agent PID file: %w", err)
+	}
+
+	pid, err := strconv.Atoi(strings.TrimSpace(string(content)))
+	if err != nil {
+		return 0, fmt.Errorf("invalid agent PID file %s", agentPIDPath(socket))
+	}
+	return pid, nil
+}
+
 func (am *AgentManager) removeFromGPGAgent(publicKeyPath string) error {
 	output, err := am.executor.ExecuteWithOutput("ssh-keygen", "-lf", publicKeyPath)
 	if err != nil {
@@ -140,6 +318,48 @@ func (am *AgentManager) removeFromGPGAgent(publicKeyPath string) error {
 	return nil
 }
 
+// IsKeyLoaded reports whether the key is loaded in the agent. The public keys are compared,
+// as the agent may list the key with another comment than its .pub file.
+func (am *AgentManager) IsKeyLoaded(keyPath string) bool {
+	agentKeys, err := am.ListAgentKeys()
+	if err != nil {
+		return false
+	}
+
+	loaded, _ := MatchAgentKeys(agentKeys, []string{keyPath})
+	return len(loaded) > 0
+}
+
+// MatchAgentKeys compares the keys listed by ListAgentKeys with key files. It returns the
+// keys whose public key is loaded, and how many loaded keys are none of them. Certificates
+// are loaded along with their key and are not counted.
+func MatchAgentKeys(agentKeys, keyPaths []string) ([]string, int) {
+	loadedBlobs := map[string]bool{}
+	for _, agentKey := range agentKeys {
+		blob, err := publicKeyBlob(agentKey)
+		if err != nil || strings.Contains(strings.Fields(blob)[0], "-cert-") {
+			continue
+		}
+		loadedBlobs[blob] = true
+	}
+
+	var loaded []string
+	for _, keyPath := range keyPaths {
+		content, err := os.ReadFile(keyPath + ".pub")
+		if err != nil {
+			continue
+		}
+		blob, err := publicKeyBlob(string(content))
+		if err != nil || !loadedBlobs[blob] {
+			continue
+		}
+		loaded = append(loaded, keyPath)
+		delete(loadedBlobs, blob)
+	}
+
+	return loaded, len(loadedBlobs)
+}
+
 func isGPGAgentRunning() bool {
 	agentPath := os.Getenv("SSH_AUTH_SOCK")
 	return strings.Contains(agentPath, "gpg-agent")
@@ -153,6 +373,10 @@ func findPublicKeys(sshPath string) ([]string, error) {
 			return err
 		}
 
+		if entry.IsDir() && IsSSHManDir(entry.Name()) {
+			return filepath.SkipDir
+		}
+
 		if entry.IsDir() || !strings.HasSuffix(path, ".pub") {
 			return nil
 		}
@@ -2,11 +2,16 @@ package ssh
 
 import (
 	"fmt"
+	"net"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"syscall"
 	"testing"
+	"time"
 
 	"github.com/residwi/sshman/mocks"
+	"github.com/residwi/sshman/utils"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -27,6 +32,20 @@ func TestAgentManager_AddToAgent_Success(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestAgentManager_AddToAgentWithLifetime_Success(t *testing.T) {
+	tempDir := t.TempDir()
+	mockExecutor := mocks.NewMockCommandExecutor(t)
+
+	keyPath := filepath.Join(tempDir, "id_ed25519_test")
+	require.NoError(t, os.WriteFile(keyPath, []byte("test key"), 0600))
+
+	mockExecutor.EXPECT().Execute("ssh-add", []string{"-t", "28800", keyPath}).Return(nil)
+
+	err := NewAgentManager(mockExecutor).AddToAgentWithLifetime(tempDir, "id_ed25519_test", 8*time.Hour)
+
+	assert.NoError(t, err)
+}
+
 func TestAgentManager_AddToAgent_KeyNotExists_Error(t *testing.T) {
 	tempDir := t.TempDir()
 	mockExecutor := mocks.NewMockCommandExecutor(t)
@@ -224,6 +243,41 @@ func TestAgentManager_IsAgentRunning(t *testing.T) {
 	assert.True(t, isRunning)
 }
 
+func TestMatchAgentKeys(t *testing.T) {
+	tempDir := t.TempDir()
+	workKey := filepath.Join(tempDir, "id_ed25519_work")
+	personalKey := filepath.Join(tempDir, "id_ed25519_personal")
+	require.NoError(t, os.WriteFile(workKey+".pub", []byte("ssh-ed25519 AAAAwork work@example.com\n"), 0644))
+	require.NoError(t, os.WriteFile(personalKey+".pub", []byte("ssh-ed25519 AAAApersonal me@example.com\n"), 0644))
+
+	agentKeys := []string{
+		"ssh-ed25519 AAAAwork /home/me/.ssh/id_ed25519_work",
+		"ssh-ed25519-cert-v01@openssh.com AAAAcert /home/me/.ssh/id_ed25519_work",
+		"ssh-rsa AAAAother other@example.com",
+	}
+
+	loaded, others := MatchAgentKeys(agentKeys, []string{workKey, personalKey, filepath.Join(tempDir, "missing")})
+
+	assert.Equal(t, []string{workKey}, loaded)
+	assert.Equal(t, 1, others)
+}
+
+func TestAgentManager_IsKeyLoaded(t *testing.T) {
+	tempDir := t.TempDir()
+	keyPath := filepath.Join(tempDir, "id_rsa")
+	require.NoError(t, os.WriteFile(keyPath+".pub", []byte("ssh-rsa AAAArsa me@example.com\n"), 0644))
+
+	mockExecutor := mocks.NewMockCommandExecutor(t)
+	mockExecutor.EXPECT().Execute("ssh-add", []string{"-l"}).Return(nil)
+	// PEM keys are listed with their path as comment instead of the one in the .pub file
+	mockExecutor.EXPECT().ExecuteWithOutput("ssh-add", []string{"-L"}).Return([]byte("ssh-rsa AAAArsa "+keyPath+"\n"), nil)
+
+	agentMgr := NewAgentManager(mockExecutor)
+
+	assert.True(t, agentMgr.IsKeyLoaded(keyPath))
+	assert.False(t, agentMgr.IsKeyLoaded(filepath.Join(tempDir, "id_ed25519")))
+}
+
 func TestIsGPGAgentRunning(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -311,3 +365,118 @@ func TestFindPublicKeys_NonexistentDirectory(t *testing.T) {
 	assert.Error(t, err)
 	assert.Nil(t, publicKeys)
 }
+
+func TestAgentManager_Status(t *testing.T) {
+	t.Setenv("XDG_RUNTIME_DIR", "/run/user/1000")
+
+	tests := []struct {
+		socket string
+		kind   string
+	}{
+		{"/tmp/ssh-XXXXabcd/agent.1234", AgentKindOpenSSH},
+		{"/run/user/1000/gnupg/S.gpg-agent.ssh", AgentKindGPG},
+		{"/home/me/.1password/agent.sock", AgentKind1Password},
+		{"/run/user/1000/sshman/agent.sock", AgentKindSSHMan},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.kind, func(t *testing.T) {
+			t.Setenv("SSH_AUTH_SOCK", tt.socket)
+			mockExecutor := mocks.NewMockCommandExecutor(t)
+			mockExecutor.EXPECT().Execute("ssh-add", []string{"-l"}).Return(nil)
+
+			status := NewAgentManager(mockExecutor).Status()
+
+			assert.Equal(t, AgentStatus{Running: true, Kind: tt.kind, Socket: tt.socket}, status)
+		})
+	}
+}
+
+func TestAgentManager_StartAgent_Success(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "sshman", "agent.sock")
+	mockExecutor := mocks.NewMockCommandExecutor(t)
+	mockExecutor.EXPECT().ExecuteWithOutput("ssh-agent", []string{"-s", "-a", socket}).
+		Return([]byte("SSH_AUTH_SOCK="+socket+"; export SSH_AUTH_SOCK;\nSSH_AGENT_PID=4242; export SSH_AGENT_PID;\necho Agent pid 4242;\n"), nil)
+
+	pid, started, err := NewAgentManager(mockExecutor).StartAgent(socket)
+
+	require.NoError(t, err)
+	assert.True(t, started)
+	assert.Equal(t, 4242, pid)
+
+	content, err := os.ReadFile(filepath.Join(filepath.Dir(socket), "agent.pid"))
+	require.NoError(t, err)
+	assert.Equal(t, "4242\n", string(content))
+}
+
+func TestAgentManager_StartAgent_ReusesLiveSocket(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "agent.sock")
+	listener, err := net.Listen("unix", socket)
+	require.NoError(t, err)
+	defer listener.Close()
+	require.NoError(t, os.WriteFile(filepath.Join(filepath.Dir(socket), "agent.pid"), []byte("4242\n"), 0600))
+
+	pid, started, err := NewAgentManager(mocks.NewMockCommandExecutor(t)).StartAgent(socket)
+
+	require.NoError(t, err)
+	assert.False(t, started)
+	assert.Equal(t, 4242, pid)
+}
+
+func TestAgentManager_StopAgent_Success(t *testing.T) {
+	if _, err := exec.LookPath("ssh-agent"); err != nil {
+		t.Skip("ssh-agent is not installed")
+	}
+
+	socket := filepath.Join(t.TempDir(), "agent.sock")
+	agent := exec.Command("ssh-agent", "-D", "-a", socket)
+	require.NoError(t, agent.Start())
+	require.Eventually(t, func() bool { return AgentSocketAlive(socket) }, 5*time.Second, 10*time.Millisecond)
+	require.NoError(t, os.WriteFile(filepath.Join(filepath.Dir(socket), "agent.pid"), []byte(fmt.Sprintf("%d\n", agent.Process.Pid)), 0600))
+
+	err := NewAgentManager(mocks.NewMockCommandExecutor(t)).StopAgent(socket)
+
+	require.NoError(t, err)
+	agent.Wait()
+	assert.NoFileExists(t, filepath.Join(filepath.Dir(socket), "agent.pid"))
+}
+
+func TestAgentManager_StopAgent_DeadSocket_CleansUp(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "agent.sock")
+	other := exec.Command("sleep", "30")
+	require.NoError(t, other.Start())
+	t.Cleanup(func() { other.Process.Kill(); other.Wait() })
+	require.NoError(t, os.WriteFile(filepath.Join(filepath.Dir(socket), "agent.pid"), []byte(fmt.Sprintf("%d\n", other.Process.Pid)), 0600))
+
+	err := NewAgentManager(mocks.NewMockCommandExecutor(t)).StopAgent(socket)
+
+	assert.ErrorContains(t, err, "no agent started by sshman is running")
+	assert.NoError(t, other.Process.Signal(syscall.Signal(0)), "the process behind a stale PID file must not be signalled")
+	assert.NoFileExists(t, filepath.Join(filepath.Dir(socket), "agent.pid"))
+}
+
+func TestAgentManager_StopAgent_NotAgentProcess_Error(t *testing.T) {
+	if utils.IsDirectoryNotExist("/proc/self") {
+		t.Skip("/proc is not available")
+	}
+
+	socket := filepath.Join(t.TempDir(), "agent.sock")
+	listener, err := net.Listen("unix", socket)
+	require.NoError(t, err)
+	defer listener.Close()
+	other := exec.Command("sleep", "30")
+	require.NoError(t, other.Start())
+	t.Cleanup(func() { other.Process.Kill(); other.Wait() })
+	require.NoError(t, os.WriteFile(filepath.Join(filepath.Dir(socket), "agent.pid"), []byte(fmt.Sprintf("%d\n", other.Process.Pid)), 0600))
+
+	err = NewAgentManager(mocks.NewMockCommandExecutor(t)).StopAgent(socket)
+
+	assert.ErrorContains(t, err, "is not an ssh-agent")
+	assert.NoError(t, other.Process.Signal(syscall.Signal(0)))
+}
+
+func TestAgentManager_StopAgent_NotStarted_Error(t *testing.T) {
+	err := NewAgentManager(mocks.NewMockCommandExecutor(t)).StopAgent(filepath.Join(t.TempDir(), "agent.sock"))
+
+	assert.ErrorContains(t, err, "no agent started by sshman is running")
+}
@@ -0,0 +1,248 @@
+package ssh
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	gossh "golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+// ConfirmFunc decides whether a key added with confirmation (ssh-add -c) may sign a
+// request from the client process pid, which is 0 when unknown
+type ConfirmFunc func(key *agent.Key, pid int) bool
+
+// AgentServer is an in-process ssh-agent for systems without the ssh-agent binary
+type AgentServer struct {
+	keyring agent.ExtendedAgent
+	// Lifetime applies to keys added without a lifetime, zero keeps them until removed
+	Lifetime time.Duration
+	Confirm  ConfirmFunc
+	Logger   *log.Logger
+
+	mu sync.Mutex
+	// confirmKeys holds the public key blobs of keys that need confirmation before use
+	confirmKeys map[string]bool
+}
+
+func NewAgentServer(logger *log.Logger) *AgentServer {
+	return &AgentServer{
+		keyring:     agent.NewKeyring().(agent.ExtendedAgent),
+		Logger:      logger,
+		confirmKeys: map[string]bool{},
+	}
+}
+
+// ListenAndServe serves the agent protocol on socket until ctx is done. Like StartAgent,
+// it records its PID next to the socket, so StopAgent can stop it.
+func (s *AgentServer) ListenAndServe(ctx context.Context, socket string) error {
+	if AgentSocketAlive(socket) {
+		return fmt.Errorf("an agent is already listening on %s", socket)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(socket), 0700); err != nil {
+		return fmt.Errorf("failed to create agent directory: %w", err)
+	}
+	if err := os.Remove(socket); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove stale agent socket: %w", err)
+	}
+
+	listener, err := listenPrivate(socket)
+	if err != nil {
+		return fmt.Errorf("failed to listen on agent socket: %w", err)
+	}
+	defer os.Remove(socket)
+
+	if err := os.WriteFile(agentPIDPath(socket), []byte(strconv.Itoa(os.Getpid())+"\n"), 0600); err != nil {
+		listener.Close()
+		return fmt.Errorf("failed to write agent PID file: %w", err)
+	}
+	defer os.Remove(agentPIDPath(socket))
+
+	go func() {
+		<-ctx.Done()
+		listener.Close()
+	}()
+
+	return s.Serve(listener)
+}
+
+// Serve accepts agent connections on listener until it is closed
+func (s *AgentServer) Serve(listener net.Listener) error {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return fmt.Errorf("failed to accept agent connection: %w", err)
+		}
+
+		pid, uid := peerCred(conn)
+		if !allowedPeer(uid) {
+			s.logf("connection from UID %d (%s) refused", uid, pidText(pid))
+			conn.Close()
+			continue
+		}
+
+		go func() {
+			defer conn.Close()
+			agent.ServeAgent(&agentConn{server: s, pid: pid}, conn)
+		}()
+	}
+}
+
+// allowedPeer reports whether a client with the given UID may use the agent: the current
+// user and root, which can read the keys anyway. An unknown UID, -1, relies on the socket
+// permissions.
+func allowedPeer(uid int) bool {
+	return uid == -1 || uid == 0 || uid == os.Getuid()
+}
+
+func (s *AgentServer) logf(format string, args ...any) {
+	if s.Logger != nil {
+		s.Logger.Printf(format, args...)
+	}
+}
+
+// agentConn serves the requests of one client connection with the keyring of the server
+type agentConn struct {
+	server *AgentServer
+	pid    int
+}
+
+func (c *agentConn) List() ([]*agent.Key, error) {
+	return c.server.keyring.List()
+}
+
+func (c *agentConn) Sign(key gossh.PublicKey, data []byte) (*gossh.Signature, error) {
+	return c.SignWithFlags(key, data, 0)
+}
+
+func (c *agentConn) SignWithFlags(key gossh.PublicKey, data []byte, flags agent.SignatureFlags) (*gossh.Signature, error) {
+	s := c.server
+	agentKey := c.findKey(key)
+	name := gossh.FingerprintSHA256(key)
+	if agentKey != nil && agentKey.Comment != "" {
+		name = agentKey.Comment + " " + name
+	}
+
+	s.mu.Lock()
+	needsConfirm := s.confirmKeys[string(key.Marshal())]
+	s.mu.Unlock()
+
+	if needsConfirm && agentKey != nil && (s.Confirm == nil || !s.Confirm(agentKey, c.pid)) {
+		s.logf("sign request for %s from %s denied", name, pidText(c.pid))
+		return nil, fmt.Errorf("agent: signing with %s was not confirmed", name)
+	}
+
+	signature, err := s.keyring.SignWithFlags(key, data, flags)
+	if err != nil {
+		s.logf("sign request for %s from %s failed: %v", name, pidText(c.pid), err)
+		return nil, err
+	}
+
+	s.logf("sign request for %s from %s", name, pidText(c.pid))
+	return signature, nil
+}
+
+func (c *agentConn) Add(key agent.AddedKey) error {
+	s := c.server
+	if key.LifetimeSecs == 0 && s.Lifetime > 0 {
+		key.LifetimeSecs = uint32(s.Lifetime / time.Second)
+	}
+
+	if err := s.keyring.Add(key); err != nil {
+		return err
+	}
+
+	signer, err := gossh.NewSignerFromKey(key.PrivateKey)
+	if err != nil {
+		return err
+	}
+	kind, blob := "key", signer.PublicKey().Marshal()
+	if key.Certificate != nil {
+		kind, blob = "certificate", key.Certificate.Marshal()
+	}
+
+	s.mu.Lock()
+	s.confirmKeys[string(blob)] = key.ConfirmBeforeUse
+	s.mu.Unlock()
+
+	s.logf("%s %s %s added by %s", kind, key.Comment, gossh.FingerprintSHA256(signer.PublicKey()), pidText(c.pid))
+	return nil
+}
+
+func (c *agentConn) Remove(key gossh.PublicKey) error {
+	s := c.server
+	if err := s.keyring.Remove(key); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	delete(s.confirmKeys, string(key.Marshal()))
+	s.mu.Unlock()
+
+	s.logf("key %s removed by %s", gossh.FingerprintSHA256(key), pidText(c.pid))
+	return nil
+}
+
+func (c *agentConn) RemoveAll() error {
+	s := c.server
+	if err := s.keyring.RemoveAll(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	clear(s.confirmKeys)
+	s.mu.Unlock()
+
+	s.logf("all keys removed by %s", pidText(c.pid))
+	return nil
+}
+
+func (c *agentConn) Lock(passphrase []byte) error {
+	return c.server.keyring.Lock(passphrase)
+}
+
+func (c *agentConn) Unlock(passphrase []byte) error {
+	return c.server.keyring.Unlock(passphrase)
+}
+
+func (c *agentConn) Signers() ([]gossh.Signer, error) {
+	return c.server.keyring.Signers()
+}
+
+func (c *agentConn) Extension(extensionType string, contents []byte) ([]byte, error) {
+	return nil, agent.ErrExtensionUnsupported
+}
+
+func (c *agentConn) findKey(key gossh.PublicKey) *agent.Key {
+	keys, err := c.server.keyring.List()
+	if err != nil {
+		return nil
+	}
+
+	blob := string(key.Marshal())
+	for _, agentKey := range keys {
+		if string(agentKey.Marshal()) == blob {
+			return agentKey
+		}
+	}
+	return nil
+}
+
+func pidText(pid int) string {
+	if pid == 0 {
+		return "unknown process"
+	}
+	return "PID " + strconv.Itoa(pid)
+}
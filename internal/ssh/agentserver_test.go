@@ -0,0 +1,177 @@
+package ssh
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/residwi/sshman/internal/interfaces"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	gossh "golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+func startTestAgentServer(t *testing.T, server *AgentServer) string {
+	t.Helper()
+
+	socket := filepath.Join(t.TempDir(), "agent.sock")
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- server.ListenAndServe(ctx, socket) }()
+
+	require.Eventually(t, func() bool { return AgentSocketAlive(socket) }, 5*time.Second, 10*time.Millisecond)
+	t.Cleanup(func() {
+		cancel()
+		require.NoError(t, <-done)
+	})
+	return socket
+}
+
+func newTestAgentClient(t *testing.T, socket string) agent.ExtendedAgent {
+	t.Helper()
+
+	conn, err := net.Dial("unix", socket)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return agent.NewClient(conn)
+}
+
+func TestAgentServer_AddListSign(t *testing.T) {
+	var logs bytes.Buffer
+	socket := startTestAgentServer(t, NewAgentServer(log.New(&logs, "", 0)))
+	client := newTestAgentClient(t, socket)
+
+	privateKey := newTestPrivateKey(t)
+	require.NoError(t, client.Add(agent.AddedKey{PrivateKey: privateKey, Comment: "test@example.com"}))
+
+	keys, err := client.List()
+	require.NoError(t, err)
+	require.Len(t, keys, 1)
+	assert.Equal(t, "test@example.com", keys[0].Comment)
+
+	signature, err := client.Sign(keys[0], []byte("data"))
+	require.NoError(t, err)
+	assert.NoError(t, keys[0].Verify([]byte("data"), signature))
+
+	assert.Contains(t, logs.String(), "sign request for test@example.com "+gossh.FingerprintSHA256(keys[0]))
+	assert.Contains(t, logs.String(), "PID "+strconv.Itoa(os.Getpid()))
+}
+
+func TestAgentServer_WritesPIDFile(t *testing.T) {
+	socket := startTestAgentServer(t, NewAgentServer(nil))
+
+	pid, err := readAgentPID(socket)
+
+	require.NoError(t, err)
+	assert.Equal(t, os.Getpid(), pid)
+}
+
+func TestAgentServer_SocketOnlyForOwner(t *testing.T) {
+	socket := startTestAgentServer(t, NewAgentServer(nil))
+
+	info, err := os.Stat(socket)
+
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+}
+
+func TestAllowedPeer(t *testing.T) {
+	assert.True(t, allowedPeer(os.Getuid()))
+	assert.True(t, allowedPeer(0))
+	assert.True(t, allowedPeer(-1))
+	assert.False(t, allowedPeer(os.Getuid()+1000))
+}
+
+func TestAgentServer_SocketInUse_Error(t *testing.T) {
+	socket := startTestAgentServer(t, NewAgentServer(nil))
+
+	err := NewAgentServer(nil).ListenAndServe(context.Background(), socket)
+
+	assert.ErrorContains(t, err, "an agent is already listening")
+}
+
+func TestAgentServer_Confirm(t *testing.T) {
+	tests := []struct {
+		name    string
+		confirm ConfirmFunc
+		allowed bool
+	}{
+		{name: "allowed", confirm: func(*agent.Key, int) bool { return true }, allowed: true},
+		{name: "denied", confirm: func(*agent.Key, int) bool { return false }, allowed: false},
+		{name: "no confirm func", confirm: nil, allowed: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := NewAgentServer(nil)
+			server.Confirm = tt.confirm
+			client := newTestAgentClient(t, startTestAgentServer(t, server))
+
+			require.NoError(t, client.Add(agent.AddedKey{PrivateKey: newTestPrivateKey(t), Comment: "confirm", ConfirmBeforeUse: true}))
+			keys, err := client.List()
+			require.NoError(t, err)
+
+			_, err = client.Sign(keys[0], []byte("data"))
+
+			if tt.allowed {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
+
+func TestAgentServer_DefaultLifetime(t *testing.T) {
+	server := NewAgentServer(nil)
+	server.Lifetime = time.Second
+	client := newTestAgentClient(t, startTestAgentServer(t, server))
+
+	require.NoError(t, client.Add(agent.AddedKey{PrivateKey: newTestPrivateKey(t)}))
+	keys, err := client.List()
+	require.NoError(t, err)
+	require.Len(t, keys, 1)
+
+	assert.Eventually(t, func() bool {
+		keys, err := client.List()
+		return err == nil && len(keys) == 0
+	}, 5*time.Second, 100*time.Millisecond)
+}
+
+func TestAgentServer_WithAgentManager(t *testing.T) {
+	if _, err := exec.LookPath("ssh-add"); err != nil {
+		t.Skip("ssh-add is not installed")
+	}
+
+	socket := startTestAgentServer(t, NewAgentServer(nil))
+	t.Setenv("SSH_AUTH_SOCK", socket)
+
+	sshPath := t.TempDir()
+	privateKey := newTestPrivateKey(t)
+	writeTestKeyPair(t, sshPath, "id_ed25519_test", privateKey, "", true)
+	publicKey, err := gossh.NewPublicKey(privateKey.Public())
+	require.NoError(t, err)
+
+	agentMgr := NewAgentManager(&interfaces.DefaultCommandExecutor{})
+	assert.True(t, agentMgr.IsAgentRunning())
+
+	require.NoError(t, agentMgr.AddToAgentWithLifetime(sshPath, "id_ed25519_test", time.Hour))
+	keys, err := agentMgr.ListAgentKeys()
+	require.NoError(t, err)
+	require.Len(t, keys, 1)
+	assert.Contains(t, keys[0], strings.TrimSpace(string(gossh.MarshalAuthorizedKey(publicKey))))
+
+	require.NoError(t, agentMgr.RemoveFromAgent(sshPath, "id_ed25519_test"))
+	keys, err = agentMgr.ListAgentKeys()
+	require.NoError(t, err)
+	assert.Empty(t, keys)
+}
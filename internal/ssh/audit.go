@@ -0,0 +1,223 @@
+package ssh
+
+import (
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/residwi/sshman/utils"
+	gossh "golang.org/x/crypto/ssh"
+)
+
+const (
+	SeverityCritical = "critical"
+	SeverityHigh     = "high"
+	SeverityMedium   = "medium"
+	SeverityLow      = "low"
+)
+
+const minRSAKeyBits = 3072
+
+type Finding struct {
+	Severity string      `json:"severity"`
+	Check    string      `json:"check"`
+	Path     string      `json:"path"`
+	Message  string      `json:"message"`
+	Fixable  bool        `json:"fixable"`
+	Fixed    bool        `json:"fixed"`
+	FixMode  os.FileMode `json:"-"`
+}
+
+func GetSeverities() []string {
+	return []string{SeverityCritical, SeverityHigh, SeverityMedium, SeverityLow}
+}
+
+// SeverityRank orders severities from most (0) to least severe, unknown severities last
+func SeverityRank(severity string) int {
+	rank := slices.Index(GetSeverities(), severity)
+	if rank == -1 {
+		return len(GetSeverities())
+	}
+	return rank
+}
+
+// AuditSSHDir checks the SSH directory, its config and the given private keys for
+// insecure permissions, weak or unencrypted keys and unpaired key files
+func AuditSSHDir(sshPath string, privateKeys []string) ([]Finding, error) {
+	var findings []Finding
+
+	info, err := os.Stat(sshPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read SSH directory: %w", err)
+	}
+	if info.Mode().Perm()&0077 != 0 {
+		findings = append(findings, permissionFinding(SeverityMedium, "ssh-dir-permissions", sshPath, info.Mode().Perm(), 0700))
+	}
+
+	for _, configPath := range ConfigFilePaths(sshPath) {
+		if info, err := os.Stat(configPath); err == nil && info.Mode().Perm()&0022 != 0 {
+			findings = append(findings, permissionFinding(SeverityHigh, "config-permissions", configPath, info.Mode().Perm(), 0600))
+		}
+	}
+
+	for _, privateKey := range privateKeys {
+		keyFindings, err := auditPrivateKey(privateKey)
+		if err != nil {
+			return nil, err
+		}
+		findings = append(findings, keyFindings...)
+	}
+
+	publicKeys, err := findPublicKeys(sshPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find public keys: %w", err)
+	}
+
+	for _, publicKey := range publicKeys {
+		if strings.HasSuffix(publicKey, "-cert.pub") {
+			continue
+		}
+		if utils.IsFileNotExist(strings.TrimSuffix(publicKey, ".pub")) {
+			findings = append(findings, Finding{
+				Severity: SeverityLow,
+				Check:    "orphaned-public-key",
+				Path:     publicKey,
+				Message:  "public key has no matching private key",
+			})
+		}
+	}
+
+	slices.SortStableFunc(findings, func(a, b Finding) int {
+		return SeverityRank(a.Severity) - SeverityRank(b.Severity)
+	})
+
+	return findings, nil
+}
+
+// FixFinding corrects the permissions reported by a fixable finding
+func FixFinding(finding *Finding) error {
+	if !finding.Fixable {
+		return fmt.Errorf("finding %s for %s cannot be fixed automatically", finding.Check, finding.Path)
+	}
+
+	if err := os.Chmod(finding.Path, finding.FixMode); err != nil {
+		return fmt.Errorf("failed to fix permissions of %s: %w", finding.Path, err)
+	}
+
+	finding.Fixed = true
+	return nil
+}
+
+func auditPrivateKey(privateKeyPath string) ([]Finding, error) {
+	var findings []Finding
+
+	info, err := os.Stat(privateKeyPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read private key: %w", err)
+	}
+	if info.Mode().Perm()&0077 != 0 {
+		findings = append(findings, permissionFinding(SeverityCritical, "private-key-permissions", privateKeyPath, info.Mode().Perm(), 0600))
+	}
+
+	content, err := os.ReadFile(privateKeyPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read private key: %w", err)
+	}
+
+	var publicKey gossh.PublicKey
+	_, err = gossh.ParseRawPrivateKey(content)
+
+	var passphraseMissing *gossh.PassphraseMissingError
+	switch {
+	case err == nil:
+		findings = append(findings, Finding{
+			Severity: SeverityMedium,
+			Check:    "unencrypted-private-key",
+			Path:     privateKeyPath,
+			Message:  "private key is not protected by a passphrase",
+		})
+	case errors.As(err, &passphraseMissing):
+		publicKey = passphraseMissing.PublicKey
+	}
+
+	publicKeyPath := privateKeyPath + ".pub"
+	if utils.IsFileNotExist(publicKeyPath) {
+		findings = append(findings, Finding{
+			Severity: SeverityLow,
+			Check:    "missing-public-key",
+			Path:     privateKeyPath,
+			Message:  "private key has no matching .pub file",
+		})
+	} else if parsed, err := readAuthorizedKey(publicKeyPath); err == nil {
+		publicKey = parsed
+	}
+
+	if publicKey == nil {
+		// fall back to deriving the public key from an unencrypted private key
+		if signer, err := gossh.ParsePrivateKey(content); err == nil {
+			publicKey = signer.PublicKey()
+		}
+	}
+
+	if publicKey != nil {
+		if finding, weak := auditKeyStrength(privateKeyPath, publicKey); weak {
+			findings = append(findings, finding)
+		}
+	}
+
+	return findings, nil
+}
+
+func auditKeyStrength(privateKeyPath string, publicKey gossh.PublicKey) (Finding, bool) {
+	switch publicKey.Type() {
+	case gossh.KeyAlgoDSA:
+		return Finding{
+			Severity: SeverityCritical,
+			Check:    "weak-key",
+			Path:     privateKeyPath,
+			Message:  "DSA keys are deprecated and disabled by OpenSSH, rotate to ed25519",
+		}, true
+	case gossh.KeyAlgoRSA:
+		cryptoKey, ok := publicKey.(gossh.CryptoPublicKey)
+		if !ok {
+			return Finding{}, false
+		}
+
+		rsaKey, ok := cryptoKey.CryptoPublicKey().(*rsa.PublicKey)
+		if ok && rsaKey.N.BitLen() < minRSAKeyBits {
+			return Finding{
+				Severity: SeverityHigh,
+				Check:    "weak-key",
+				Path:     privateKeyPath,
+				Message:  fmt.Sprintf("RSA key has %d bits, at least %d are recommended", rsaKey.N.BitLen(), minRSAKeyBits),
+			}, true
+		}
+	}
+
+	return Finding{}, false
+}
+
+func readAuthorizedKey(publicKeyPath string) (gossh.PublicKey, error) {
+	content, err := os.ReadFile(publicKeyPath)
+	if err != nil {
+		return nil, err
+	}
+
+	publicKey, _, _, _, err := gossh.ParseAuthorizedKey(content)
+	return publicKey, err
+}
+
+func permissionFinding(severity, check, path string, mode, fixMode os.FileMode) Finding {
+	return Finding{
+		Severity: severity,
+		Check:    check,
+		Path:     path,
+		Message:  fmt.Sprintf("%s has mode %04o, expected %04o", filepath.Base(path), mode, fixMode),
+		Fixable:  true,
+		FixMode:  fixMode,
+	}
+}
@@ -0,0 +1,172 @@
+package ssh
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/residwi/sshman/utils"
+	gossh "golang.org/x/crypto/ssh"
+)
+
+const AuthorizedKeysFileName = "authorized_keys"
+
+// AuthorizedKey is an entry of the authorized_keys file
+type AuthorizedKey struct {
+	Type        string
+	Fingerprint string
+	Comment     string
+	Options     []string
+	Line        string
+}
+
+// ParseAuthorizedKeys parses the keys of authorized_keys content, skipping comments,
+// blank lines and lines that do not hold a valid key
+func ParseAuthorizedKeys(content []byte) []AuthorizedKey {
+	var keys []AuthorizedKey
+	for line := range strings.SplitSeq(string(content), "\n") {
+		if key, ok := parseAuthorizedKeyLine(line); ok {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// ReadAuthorizedKeys returns the keys allowed to log in to this machine
+func ReadAuthorizedKeys(sshPath string) ([]AuthorizedKey, error) {
+	content, err := readAuthorizedKeysFile(sshPath)
+	if err != nil {
+		return nil, err
+	}
+	return ParseAuthorizedKeys(content), nil
+}
+
+// AddAuthorizedKeys appends the keys of authorized_keys content that are not authorized yet.
+// When options are given, they replace the options of every added key.
+func AddAuthorizedKeys(sshPath string, content []byte, options string) ([]AuthorizedKey, error) {
+	if strings.ContainsAny(options, "\r\n") {
+		return nil, fmt.Errorf("invalid authorized_keys options: %s", options)
+	}
+
+	existingContent, err := readAuthorizedKeysFile(sshPath)
+	if err != nil {
+		return nil, err
+	}
+
+	known := make(map[string]bool)
+	for _, key := range ParseAuthorizedKeys(existingContent) {
+		known[key.Fingerprint] = true
+	}
+
+	var added []AuthorizedKey
+	var newLines []string
+	for _, key := range ParseAuthorizedKeys(content) {
+		if known[key.Fingerprint] {
+			continue
+		}
+
+		if options != "" {
+			publicKey, comment, _, _, _ := gossh.ParseAuthorizedKey([]byte(key.Line))
+			line := options + " " + strings.TrimSpace(string(gossh.MarshalAuthorizedKey(publicKey)))
+			if comment != "" {
+				line += " " + comment
+			}
+
+			var ok bool
+			if key, ok = parseAuthorizedKeyLine(line); !ok {
+				return nil, fmt.Errorf("invalid authorized_keys options: %s", options)
+			}
+		}
+
+		known[key.Fingerprint] = true
+		added = append(added, key)
+		newLines = append(newLines, key.Line)
+	}
+
+	if len(added) == 0 {
+		return nil, nil
+	}
+
+	newContent := string(existingContent)
+	if newContent != "" && !strings.HasSuffix(newContent, "\n") {
+		newContent += "\n"
+	}
+	newContent += strings.Join(newLines, "\n") + "\n"
+
+	if err := writeAuthorizedKeysFile(sshPath, newContent); err != nil {
+		return nil, err
+	}
+
+	return added, nil
+}
+
+// RemoveAuthorizedKey removes every entry of the key with the given SHA256 fingerprint
+func RemoveAuthorizedKey(sshPath, fingerprint string) ([]AuthorizedKey, error) {
+	if !strings.HasPrefix(fingerprint, "SHA256:") {
+		fingerprint = "SHA256:" + fingerprint
+	}
+
+	content, err := readAuthorizedKeysFile(sshPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var removed []AuthorizedKey
+	var remaining []string
+	for line := range strings.SplitSeq(string(content), "\n") {
+		if key, ok := parseAuthorizedKeyLine(line); ok && key.Fingerprint == fingerprint {
+			removed = append(removed, key)
+			continue
+		}
+		remaining = append(remaining, line)
+	}
+
+	if len(removed) == 0 {
+		return nil, fmt.Errorf("no authorized key with fingerprint %s", fingerprint)
+	}
+
+	if err := writeAuthorizedKeysFile(sshPath, strings.Join(remaining, "\n")); err != nil {
+		return nil, err
+	}
+
+	return removed, nil
+}
+
+func parseAuthorizedKeyLine(line string) (AuthorizedKey, bool) {
+	line = strings.TrimSpace(line)
+	if line == "" || strings.HasPrefix(line, "#") {
+		return AuthorizedKey{}, false
+	}
+
+	publicKey, comment, options, rest, err := gossh.ParseAuthorizedKey([]byte(line))
+	if err != nil || len(rest) > 0 {
+		return AuthorizedKey{}, false
+	}
+
+	return AuthorizedKey{
+		Type:        KeyTypeFromPublicKey(publicKey.Type()),
+		Fingerprint: gossh.FingerprintSHA256(publicKey),
+		Comment:     comment,
+		Options:     options,
+		Line:        line,
+	}, true
+}
+
+func readAuthorizedKeysFile(sshPath string) ([]byte, error) {
+	content, err := os.ReadFile(filepath.Join(sshPath, AuthorizedKeysFileName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read authorized_keys: %w", err)
+	}
+	return content, nil
+}
+
+func writeAuthorizedKeysFile(sshPath, content string) error {
+	if err := utils.WriteFileAtomic(filepath.Join(sshPath, AuthorizedKeysFileName), []byte(content), 0600); err != nil {
+		return fmt.Errorf("failed to write authorized_keys: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+package ssh
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseAuthorizedKeys(t *testing.T) {
+	first, firstFingerprint := newAuthorizedKeyLine(t, "alice@example.com")
+	second, secondFingerprint := newAuthorizedKeyLine(t, "bob@example.com")
+	content := "# team keys\n" + first + "\n\nnot a key\n" + `from="10.0.0.0/8",no-agent-forwarding ` + second + "\n"
+
+	keys := ParseAuthorizedKeys([]byte(content))
+
+	require.Len(t, keys, 2)
+	assert.Equal(t, "ed25519", keys[0].Type)
+	assert.Equal(t, firstFingerprint, keys[0].Fingerprint)
+	assert.Equal(t, "alice@example.com", keys[0].Comment)
+	assert.Empty(t, keys[0].Options)
+	assert.Equal(t, secondFingerprint, keys[1].Fingerprint)
+	assert.Equal(t, []string{`from="10.0.0.0/8"`, "no-agent-forwarding"}, keys[1].Options)
+}
+
+func TestReadAuthorizedKeys_NoFile(t *testing.T) {
+	keys, err := ReadAuthorizedKeys(t.TempDir())
+
+	require.NoError(t, err)
+	assert.Empty(t, keys)
+}
+
+func TestAddAuthorizedKeys_SkipsExistingKeys(t *testing.T) {
+	sshPath := t.TempDir()
+	existing, _ := newAuthorizedKeyLine(t, "alice@example.com")
+	newKey, newFingerprint := newAuthorizedKeyLine(t, "bob@example.com")
+	authorizedKeysPath := filepath.Join(sshPath, AuthorizedKeysFileName)
+	require.NoError(t, os.WriteFile(authorizedKeysPath, []byte("# keep me\n"+existing), 0600))
+
+	added, err := AddAuthorizedKeys(sshPath, []byte(existing+"\n"+newKey+"\n"+newKey+"\n"), "")
+
+	require.NoError(t, err)
+	require.Len(t, added, 1)
+	assert.Equal(t, newFingerprint, added[0].Fingerprint)
+
+	content, err := os.ReadFile(authorizedKeysPath)
+	require.NoError(t, err)
+	assert.Equal(t, "# keep me\n"+existing+"\n"+newKey+"\n", string(content))
+
+	info, err := os.Stat(authorizedKeysPath)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	added, err = AddAuthorizedKeys(sshPath, []byte(newKey), "")
+	require.NoError(t, err)
+	assert.Empty(t, added)
+}
+
+func TestAddAuthorizedKeys_WithOptions(t *testing.T) {
+	sshPath := t.TempDir()
+	newKey, _ := newAuthorizedKeyLine(t, "ci@example.com")
+
+	added, err := AddAuthorizedKeys(sshPath, []byte("no-pty "+newKey), `from="10.0.0.0/8",no-agent-forwarding`)
+
+	require.NoError(t, err)
+	require.Len(t, added, 1)
+	assert.Equal(t, []string{`from="10.0.0.0/8"`, "no-agent-forwarding"}, added[0].Options)
+	assert.Equal(t, "ci@example.com", added[0].Comment)
+
+	content, err := os.ReadFile(filepath.Join(sshPath, AuthorizedKeysFileName))
+	require.NoError(t, err)
+	assert.Equal(t, `from="10.0.0.0/8",no-agent-forwarding `+newKey+"\n", string(content))
+}
+
+func TestAddAuthorizedKeys_InvalidOptions(t *testing.T) {
+	newKey, _ := newAuthorizedKeyLine(t, "ci@example.com")
+
+	_, err := AddAuthorizedKeys(t.TempDir(), []byte(newKey), "no-pty\nssh-rsa AAAA")
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid authorized_keys options")
+}
+
+func TestRemoveAuthorizedKey(t *testing.T) {
+	sshPath := t.TempDir()
+	kept, _ := newAuthorizedKeyLine(t, "alice@example.com")
+	revoked, revokedFingerprint := newAuthorizedKeyLine(t, "bob@example.com")
+	authorizedKeysPath := filepath.Join(sshPath, AuthorizedKeysFileName)
+	require.NoError(t, os.WriteFile(authorizedKeysPath, []byte("# team\n"+kept+"\nno-pty "+revoked+"\n"+revoked+"\n"), 0600))
+
+	removed, err := RemoveAuthorizedKey(sshPath, strings.TrimPrefix(revokedFingerprint, "SHA256:"))
+
+	require.NoError(t, err)
+	assert.Len(t, removed, 2)
+
+	content, err := os.ReadFile(authorizedKeysPath)
+	require.NoError(t, err)
+	assert.Equal(t, "# team\n"+kept+"\n", string(content))
+}
+
+func TestRemoveAuthorizedKey_NotFound(t *testing.T) {
+	sshPath := t.TempDir()
+	existing, _ := newAuthorizedKeyLine(t, "alice@example.com")
+	require.NoError(t, os.WriteFile(filepath.Join(sshPath, AuthorizedKeysFileName), []byte(existing+"\n"), 0600))
+
+	_, err := RemoveAuthorizedKey(sshPath, "SHA256:unknown")
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "no authorized key with fingerprint")
+}
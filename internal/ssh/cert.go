@@ -0,0 +1,169 @@
+package ssh
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/residwi/sshman/internal/interfaces"
+	"github.com/residwi/sshman/utils"
+	gossh "golang.org/x/crypto/ssh"
+)
+
+// CertSuffix is appended to a private key path to find its certificate, which is where ssh and ssh-add look for it
+const CertSuffix = "-cert.pub"
+
+type CertConfig struct {
+	KeyPath    string
+	CAKeyPath  string
+	Identity   string
+	Principals []string
+	// Validity is passed to ssh-keygen -V, e.g. +8h or 20240101:20240201
+	Validity string
+	HostCert bool
+	// Options are passed to ssh-keygen -O, e.g. clear or source-address=10.0.0.0/8
+	Options []string
+}
+
+// CertInfo describes an OpenSSH certificate
+type CertInfo struct {
+	Path            string
+	Type            string
+	KeyID           string
+	Serial          uint64
+	Principals      []string
+	ValidAfter      time.Time
+	ValidBefore     time.Time
+	SignedBy        string
+	SignedByType    string
+	CriticalOptions map[string]string
+	Extensions      []string
+	// ExtensionValues holds the contents of the extensions that have any
+	ExtensionValues map[string]string
+}
+
+// IsExpired reports whether the certificate is no longer valid at the given time
+func (c CertInfo) IsExpired(now time.Time) bool {
+	return !c.ValidBefore.IsZero() && !now.Before(c.ValidBefore)
+}
+
+// IsForever reports whether the certificate has no expiry
+func (c CertInfo) IsForever() bool {
+	return c.ValidBefore.IsZero()
+}
+
+// CertSigner signs public keys with a certificate authority key using ssh-keygen -s
+type CertSigner struct {
+	executor interfaces.CommandExecutor
+}
+
+func NewCertSigner(executor interfaces.CommandExecutor) *CertSigner {
+	return &CertSigner{
+		executor: executor,
+	}
+}
+
+// Sign writes the certificate of a key next to it and returns the certificate path
+func (cs *CertSigner) Sign(config CertConfig) (string, error) {
+	publicKeyPath := config.KeyPath + ".pub"
+	if utils.IsFileNotExist(publicKeyPath) {
+		return "", fmt.Errorf("public key [%s] does not exist", publicKeyPath)
+	}
+
+	if utils.IsFileNotExist(config.CAKeyPath) {
+		return "", fmt.Errorf("CA key [%s] does not exist", config.CAKeyPath)
+	}
+
+	if len(config.Principals) == 0 {
+		return "", fmt.Errorf("at least one principal is required")
+	}
+
+	args := []string{"-s", config.CAKeyPath, "-I", config.Identity, "-n", strings.Join(config.Principals, ",")}
+	if config.Validity != "" {
+		args = append(args, "-V", config.Validity)
+	}
+	if config.HostCert {
+		args = append(args, "-h")
+	}
+	for _, option := range config.Options {
+		args = append(args, "-O", option)
+	}
+	args = append(args, publicKeyPath)
+
+	if output, err := cs.executor.ExecuteWithCombinedOutput("ssh-keygen", args...); err != nil {
+		return "", fmt.Errorf("failed to sign SSH key: %w: %s", err, strings.TrimSpace(string(output)))
+	}
+
+	return config.KeyPath + CertSuffix, nil
+}
+
+// ReadCert parses the certificate at the given path
+func ReadCert(certPath string) (*CertInfo, error) {
+	content, err := os.ReadFile(certPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read certificate: %w", err)
+	}
+
+	return ParseCert(certPath, content)
+}
+
+// ParseCert parses a certificate in authorized_keys format
+func ParseCert(certPath string, content []byte) (*CertInfo, error) {
+	publicKey, _, _, _, err := gossh.ParseAuthorizedKey(content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse certificate: %w", err)
+	}
+
+	cert, ok := publicKey.(*gossh.Certificate)
+	if !ok {
+		return nil, fmt.Errorf("%s is a public key, not a certificate", certPath)
+	}
+
+	info := &CertInfo{
+		Path:            certPath,
+		Type:            "user",
+		KeyID:           cert.KeyId,
+		Serial:          cert.Serial,
+		Principals:      cert.ValidPrincipals,
+		SignedBy:        gossh.FingerprintSHA256(cert.SignatureKey),
+		SignedByType:    KeyTypeFromPublicKey(cert.SignatureKey.Type()),
+		CriticalOptions: cert.CriticalOptions,
+	}
+	if cert.CertType == gossh.HostCert {
+		info.Type = "host"
+	}
+	if cert.ValidAfter != 0 {
+		info.ValidAfter = time.Unix(int64(cert.ValidAfter), 0)
+	}
+	if cert.ValidBefore != gossh.CertTimeInfinity {
+		info.ValidBefore = time.Unix(int64(cert.ValidBefore), 0)
+	}
+	for extension, value := range cert.Extensions {
+		info.Extensions = append(info.Extensions, extension)
+		if value != "" {
+			if info.ExtensionValues == nil {
+				info.ExtensionValues = make(map[string]string)
+			}
+			info.ExtensionValues[extension] = value
+		}
+	}
+	slices.Sort(info.Extensions)
+
+	return info, nil
+}
+
+// FindCert returns the certificate of a private key, or nil when it has none
+func FindCert(keyPath string) *CertInfo {
+	certPath := keyPath + CertSuffix
+	if utils.IsFileNotExist(certPath) {
+		return nil
+	}
+
+	info, err := ReadCert(certPath)
+	if err != nil {
+		return nil
+	}
+	return info
+}
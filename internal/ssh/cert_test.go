@@ -0,0 +1,156 @@
+package ssh
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/residwi/sshman/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	gossh "golang.org/x/crypto/ssh"
+)
+
+func TestParseCert(t *testing.T) {
+	validAfter := time.Date(2026, 10, 19, 8, 0, 0, 0, time.UTC)
+	content, caKey := newTestCert(t, &gossh.Certificate{
+		CertType:        gossh.UserCert,
+		KeyId:           "alice",
+		Serial:          42,
+		ValidPrincipals: []string{"alice", "deploy"},
+		ValidAfter:      uint64(validAfter.Unix()),
+		ValidBefore:     uint64(validAfter.Add(8 * time.Hour).Unix()),
+		Permissions: gossh.Permissions{
+			CriticalOptions: map[string]string{"source-address": "10.0.0.0/8"},
+			Extensions:      map[string]string{"permit-pty": "", "permit-agent-forwarding": ""},
+		},
+	})
+
+	info, err := ParseCert("id_ed25519-cert.pub", content)
+
+	require.NoError(t, err)
+	assert.Equal(t, "user", info.Type)
+	assert.Equal(t, "alice", info.KeyID)
+	assert.Equal(t, uint64(42), info.Serial)
+	assert.Equal(t, []string{"alice", "deploy"}, info.Principals)
+	assert.True(t, validAfter.Equal(info.ValidAfter))
+	assert.True(t, validAfter.Add(8*time.Hour).Equal(info.ValidBefore))
+	assert.Equal(t, gossh.FingerprintSHA256(caKey), info.SignedBy)
+	assert.Equal(t, "ed25519", info.SignedByType)
+	assert.Equal(t, map[string]string{"source-address": "10.0.0.0/8"}, info.CriticalOptions)
+	assert.Equal(t, []string{"permit-agent-forwarding", "permit-pty"}, info.Extensions)
+
+	assert.False(t, info.IsExpired(validAfter.Add(time.Hour)))
+	assert.True(t, info.IsExpired(validAfter.Add(8*time.Hour)))
+	assert.False(t, info.IsForever())
+}
+
+func TestParseCert_HostCertForever(t *testing.T) {
+	content, _ := newTestCert(t, &gossh.Certificate{
+		CertType:        gossh.HostCert,
+		ValidPrincipals: []string{"server.example.com"},
+		ValidBefore:     gossh.CertTimeInfinity,
+	})
+
+	info, err := ParseCert("ssh_host_ed25519_key-cert.pub", content)
+
+	require.NoError(t, err)
+	assert.Equal(t, "host", info.Type)
+	assert.True(t, info.IsForever())
+	assert.False(t, info.IsExpired(time.Now()))
+}
+
+func TestParseCert_PublicKey(t *testing.T) {
+	_, err := ParseCert("id_ed25519.pub", gossh.MarshalAuthorizedKey(newTestPublicKey(t)))
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "not a certificate")
+}
+
+func TestFindCert(t *testing.T) {
+	sshPath := t.TempDir()
+	keyPath := filepath.Join(sshPath, "id_ed25519_work")
+	assert.Nil(t, FindCert(keyPath))
+
+	content, _ := newTestCert(t, &gossh.Certificate{CertType: gossh.UserCert, KeyId: "work", ValidBefore: gossh.CertTimeInfinity})
+	require.NoError(t, os.WriteFile(keyPath+CertSuffix, content, 0644))
+
+	info := FindCert(keyPath)
+	require.NotNil(t, info)
+	assert.Equal(t, "work", info.KeyID)
+	assert.Equal(t, keyPath+CertSuffix, info.Path)
+}
+
+func TestCertSigner_Sign(t *testing.T) {
+	sshPath := t.TempDir()
+	keyPath := filepath.Join(sshPath, "id_ed25519_work")
+	caKeyPath := filepath.Join(sshPath, "ca")
+	require.NoError(t, os.WriteFile(keyPath+".pub", []byte("ssh-ed25519 AAAA"), 0644))
+	require.NoError(t, os.WriteFile(caKeyPath, []byte("ca"), 0600))
+
+	mockExecutor := mocks.NewMockCommandExecutor(t)
+	expectedArgs := []string{"-s", caKeyPath, "-I", "alice", "-n", "alice,deploy", "-V", "+8h", keyPath + ".pub"}
+	mockExecutor.EXPECT().ExecuteWithCombinedOutput("ssh-keygen", expectedArgs).Return(nil, nil)
+
+	certPath, err := NewCertSigner(mockExecutor).Sign(CertConfig{
+		KeyPath:    keyPath,
+		CAKeyPath:  caKeyPath,
+		Identity:   "alice",
+		Principals: []string{"alice", "deploy"},
+		Validity:   "+8h",
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, keyPath+CertSuffix, certPath)
+}
+
+func TestCertSigner_Sign_HostCert(t *testing.T) {
+	sshPath := t.TempDir()
+	keyPath := filepath.Join(sshPath, "ssh_host_ed25519_key")
+	caKeyPath := filepath.Join(sshPath, "ca")
+	require.NoError(t, os.WriteFile(keyPath+".pub", []byte("ssh-ed25519 AAAA"), 0644))
+	require.NoError(t, os.WriteFile(caKeyPath, []byte("ca"), 0600))
+
+	mockExecutor := mocks.NewMockCommandExecutor(t)
+	expectedArgs := []string{"-s", caKeyPath, "-I", "server", "-n", "server.example.com", "-h", keyPath + ".pub"}
+	mockExecutor.EXPECT().ExecuteWithCombinedOutput("ssh-keygen", expectedArgs).Return(nil, nil)
+
+	_, err := NewCertSigner(mockExecutor).Sign(CertConfig{
+		KeyPath:    keyPath,
+		CAKeyPath:  caKeyPath,
+		Identity:   "server",
+		Principals: []string{"server.example.com"},
+		HostCert:   true,
+	})
+
+	require.NoError(t, err)
+}
+
+func TestCertSigner_Sign_Errors(t *testing.T) {
+	sshPath := t.TempDir()
+	keyPath := filepath.Join(sshPath, "id_ed25519_work")
+	caKeyPath := filepath.Join(sshPath, "ca")
+
+	signer := NewCertSigner(mocks.NewMockCommandExecutor(t))
+	config := CertConfig{KeyPath: keyPath, CAKeyPath: caKeyPath, Identity: "alice", Principals: []string{"alice"}}
+
+	_, err := signer.Sign(config)
+	assert.ErrorContains(t, err, "public key")
+
+	require.NoError(t, os.WriteFile(keyPath+".pub", []byte("ssh-ed25519 AAAA"), 0644))
+	_, err = signer.Sign(config)
+	assert.ErrorContains(t, err, "CA key")
+
+	require.NoError(t, os.WriteFile(caKeyPath, []byte("ca"), 0600))
+	config.Principals = nil
+	_, err = signer.Sign(config)
+	assert.ErrorContains(t, err, "at least one principal is required")
+
+	mockExecutor := mocks.NewMockCommandExecutor(t)
+	mockExecutor.EXPECT().ExecuteWithCombinedOutput("ssh-keygen", []string{"-s", caKeyPath, "-I", "alice", "-n", "alice", keyPath + ".pub"}).Return([]byte("Load key: incorrect passphrase\n"), fmt.Errorf("exit status 255"))
+	config.Principals = []string{"alice"}
+	_, err = NewCertSigner(mockExecutor).Sign(config)
+	assert.ErrorContains(t, err, "incorrect passphrase")
+}
@@ -1,25 +1,40 @@
 package ssh
 
 import (
+	"bufio"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/residwi/sshman/utils"
 )
 
+// ConfigFileName is the SSH config file sshman keeps Host entries in, relative to the SSH
+// directory unless it is absolute. Any other file than config is included from config.
+var ConfigFileName = "config"
+
 type ConfigEntry struct {
-	Host         string
-	User         string
-	Hostname     string
-	IdentityFile string
+	Host         string `json:"host"`
+	User         string `json:"user"`
+	Hostname     string `json:"hostname"`
+	IdentityFile string `json:"identity_file"`
 }
 
 func AddToConfig(sshPath string, entry ConfigEntry) error {
-	configFilePath := filepath.Join(sshPath, "config")
+	configFilePath := configFilePath(sshPath)
+
+	if err := ensureConfigInclude(sshPath); err != nil {
+		return err
+	}
 
 	if utils.IsFileNotExist(configFilePath) {
+		if configDir := filepath.Dir(configFilePath); !utils.IsSamePath(configDir, sshPath) {
+			if err := os.MkdirAll(configDir, 0700); err != nil {
+				return fmt.Errorf("failed to create SSH config directory: %w", err)
+			}
+		}
 		if err := os.WriteFile(configFilePath, []byte{}, 0600); err != nil {
 			return fmt.Errorf("failed to create SSH config file: %w", err)
 		}
@@ -44,3 +59,318 @@ func AddToConfig(sshPath string, entry ConfigEntry) error {
 
 	return nil
 }
+
+// ReadConfig parses the Host blocks of the main SSH config and, when it is another file,
+// the config file sshman writes to. A Host line with several patterns yields one entry
+// per pattern, and wildcard patterns are skipped.
+func ReadConfig(sshPath string) ([]ConfigEntry, error) {
+	entries := []ConfigEntry{}
+	for _, path := range ConfigFilePaths(sshPath) {
+		fileEntries, err := readConfigEntries(path)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, fileEntries...)
+	}
+	return entries, nil
+}
+
+func readConfigEntries(path string) ([]ConfigEntry, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to open SSH config file: %w", err)
+	}
+	defer file.Close()
+
+	var entries []ConfigEntry
+	var current []ConfigEntry
+
+	flush := func() {
+		entries = append(entries, current...)
+		current = nil
+	}
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		keyword, value := parseConfigLine(scanner.Text())
+		if keyword == "" {
+			continue
+		}
+
+		switch keyword {
+		case "host":
+			flush()
+			for pattern := range strings.FieldsSeq(value) {
+				if strings.ContainsAny(pattern, "*?!") {
+					continue
+				}
+				current = append(current, ConfigEntry{Host: pattern})
+			}
+		case "match":
+			flush()
+		default:
+			for i := range current {
+				switch keyword {
+				case "user":
+					current[i].User = value
+				case "hostname":
+					current[i].Hostname = value
+				case "identityfile":
+					// ssh uses the first IdentityFile when several are given
+					if current[i].IdentityFile == "" {
+						current[i].IdentityFile = value
+					}
+				}
+			}
+		}
+	}
+	flush()
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read SSH config file: %w", err)
+	}
+
+	return entries, nil
+}
+
+// FindEntriesByIdentityFile returns the config entries that use the given key
+func FindEntriesByIdentityFile(entries []ConfigEntry, keyPath string) []ConfigEntry {
+	var matches []ConfigEntry
+	for _, entry := range entries {
+		if entry.IdentityFile != "" && utils.IsSamePath(entry.IdentityFile, keyPath) {
+			matches = append(matches, entry)
+		}
+	}
+	return matches
+}
+
+// ReplaceIdentityFile points every IdentityFile that uses the old key at the new key in
+// all config files sshman reads, and returns the number of lines changed
+func ReplaceIdentityFile(sshPath, oldKeyPath, newKeyPath string) (int, error) {
+	originals := map[string]string{}
+	replaced := 0
+	for _, path := range ConfigFilePaths(sshPath) {
+		content, err := readConfigFile(path)
+		if err != nil {
+			restoreConfigFiles(originals)
+			return 0, err
+		}
+
+		newContent, count := replaceIdentityFileLines(content, oldKeyPath, newKeyPath)
+		if count == 0 {
+			continue
+		}
+
+		if err := utils.WriteFileAtomic(path, []byte(newContent), 0600); err != nil {
+			restoreConfigFiles(originals)
+			return 0, fmt.Errorf("failed to write SSH config file: %w", err)
+		}
+		originals[path] = content
+		replaced += count
+	}
+
+	return replaced, nil
+}
+
+func replaceIdentityFileLines(content, oldKeyPath, newKeyPath string) (string, int) {
+	lines := strings.Split(content, "\n")
+	replaced := 0
+	for i, line := range lines {
+		keyword, value := parseConfigLine(line)
+		if keyword != "identityfile" || !utils.IsSamePath(value, oldKeyPath) {
+			continue
+		}
+
+		newValue := newKeyPath
+		if strings.HasPrefix(value, "~") {
+			newValue = utils.ReplaceHomeDirWithTilde(newKeyPath)
+		}
+
+		lines[i] = strings.Replace(line, value, newValue, 1)
+		replaced++
+	}
+
+	return strings.Join(lines, "\n"), replaced
+}
+
+func configFilePath(sshPath string) string {
+	if filepath.IsAbs(ConfigFileName) {
+		return ConfigFileName
+	}
+	return filepath.Join(sshPath, ConfigFileName)
+}
+
+// ConfigFilePaths returns the SSH config files sshman reads: the main config and, when
+// ConfigFileName points elsewhere, the file it writes Host entries to
+func ConfigFilePaths(sshPath string) []string {
+	mainConfigPath := filepath.Join(sshPath, "config")
+	paths := []string{mainConfigPath}
+	if target := configFilePath(sshPath); !utils.IsSamePath(target, mainConfigPath) {
+		paths = append(paths, target)
+	}
+	return paths
+}
+
+func readConfigFile(path string) (string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to read SSH config file: %w", err)
+	}
+	return string(content), nil
+}
+
+// restoreConfigFiles writes back the content config files had before a failed change
+func restoreConfigFiles(originals map[string]string) {
+	for path, content := range originals {
+		utils.WriteFileAtomic(path, []byte(content), 0600)
+	}
+}
+
+// ensureConfigInclude adds an Include of the config file sshman writes to at the top of
+// the main SSH config, where ssh applies it before any Host block
+func ensureConfigInclude(sshPath string) error {
+	mainConfigPath := filepath.Join(sshPath, "config")
+	target := configFilePath(sshPath)
+	if utils.IsSamePath(target, mainConfigPath) {
+		return nil
+	}
+
+	content, err := os.ReadFile(mainConfigPath)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read SSH config file: %w", err)
+	}
+
+	for line := range strings.SplitSeq(string(content), "\n") {
+		keyword, value := parseConfigLine(line)
+		if keyword != "include" {
+			continue
+		}
+		for pattern := range strings.FieldsSeq(value) {
+			if !filepath.IsAbs(utils.ExpandTilde(pattern)) {
+				pattern = filepath.Join(sshPath, pattern)
+			}
+			if matched, _ := filepath.Match(utils.ExpandTilde(pattern), target); matched {
+				return nil
+			}
+		}
+	}
+
+	include := fmt.Sprintf("# Added by sshman\nInclude %s\n\n", utils.ReplaceHomeDirWithTilde(target))
+	if err := utils.WriteFileAtomic(mainConfigPath, append([]byte(include), content...), 0600); err != nil {
+		return fmt.Errorf("failed to write SSH config file: %w", err)
+	}
+
+	return nil
+}
+
+// parseConfigLine splits a config line into a lowercased keyword and its value,
+// supporting both "Keyword value" and "Keyword=value" forms
+func parseConfigLine(line string) (string, string) {
+	line = strings.TrimSpace(line)
+	if line == "" || strings.HasPrefix(line, "#") {
+		return "", ""
+	}
+
+	index := strings.IndexAny(line, " \t=")
+	if index == -1 {
+		return strings.ToLower(line), ""
+	}
+
+	keyword := strings.ToLower(line[:index])
+	value := strings.TrimSpace(line[index:])
+	value = strings.TrimSpace(strings.TrimPrefix(value, "="))
+	value = strings.Trim(value, `"`)
+
+	return keyword, value
+}
+
+// removeHostBlocks removes the Host blocks whose only IdentityFile is the given key,
+// along with the comment lines directly above them. It returns the new config, the
+// removed text and the aliases of blocks that were kept because they list other keys too.
+func removeHostBlocks(content, keyPath string) (string, string, []string) {
+	lines := strings.Split(content, "\n")
+
+	type block struct {
+		start, end int
+		alias      string
+	}
+	var blocks []block
+	for i, line := range lines {
+		keyword, value := parseConfigLine(line)
+		if keyword == "host" || keyword == "match" {
+			if len(blocks) > 0 {
+				blocks[len(blocks)-1].end = i
+			}
+			blocks = append(blocks, block{start: i, end: len(lines), alias: value})
+		}
+	}
+
+	var kept []string
+	remove := make([]bool, len(lines))
+	removedAny := false
+	for _, b := range blocks {
+		matches, others := 0, 0
+		for _, line := range lines[b.start:b.end] {
+			if keyword, value := parseConfigLine(line); keyword == "identityfile" {
+				if utils.IsSamePath(value, keyPath) {
+					matches++
+				} else {
+					others++
+				}
+			}
+		}
+
+		if matches == 0 {
+			continue
+		}
+		if others > 0 {
+			kept = append(kept, b.alias)
+			continue
+		}
+
+		// the block's own trailing comments belong to the next block
+		end := b.end
+		for end > b.start+1 && isCommentOrBlank(lines[end-1]) {
+			end--
+		}
+
+		start := b.start
+		for start > 0 && strings.HasPrefix(strings.TrimSpace(lines[start-1]), "#") {
+			start--
+		}
+		if start > 0 && strings.TrimSpace(lines[start-1]) == "" {
+			start--
+		}
+
+		for i := start; i < end; i++ {
+			remove[i] = true
+		}
+		removedAny = true
+	}
+
+	if !removedAny {
+		return content, "", kept
+	}
+
+	var remaining, removed []string
+	for i, line := range lines {
+		if remove[i] {
+			removed = append(removed, line)
+		} else {
+			remaining = append(remaining, line)
+		}
+	}
+
+	return strings.Join(remaining, "\n"), strings.Join(removed, "\n") + "\n", kept
+}
+
+func isCommentOrBlank(line string) bool {
+	line = strings.TrimSpace(line)
+	return line == "" || strings.HasPrefix(line, "#")
+}
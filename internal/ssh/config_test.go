@@ -132,6 +132,93 @@ func TestAddToConfig_MultipleEntries_Success(t *testing.T) {
 	assert.Equal(t, 3, generatedCount)
 }
 
+func TestAddToConfig_IncludedConfigFile_Success(t *testing.T) {
+	tempDir := t.TempDir()
+	configPath := filepath.Join(tempDir, "config")
+	require.NoError(t, os.WriteFile(configPath, []byte("Host existing\n\tHostName existing.com\n"), 0600))
+
+	ConfigFileName = filepath.Join("config.d", "sshman")
+	t.Cleanup(func() { ConfigFileName = "config" })
+
+	entry := ConfigEntry{Host: "github-work", User: "git", Hostname: "github.com", IdentityFile: "/path/to/key"}
+	require.NoError(t, AddToConfig(tempDir, entry))
+	require.NoError(t, AddToConfig(tempDir, ConfigEntry{Host: "gitlab-work", User: "git", Hostname: "gitlab.com", IdentityFile: "/path/to/key"}))
+
+	mainContent, err := os.ReadFile(configPath)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(mainContent), "# Added by sshman\nInclude "+filepath.Join(tempDir, "config.d", "sshman")+"\n"))
+	assert.Equal(t, 1, strings.Count(string(mainContent), "\nInclude "))
+	assert.NotContains(t, string(mainContent), "github-work")
+
+	entries, err := ReadConfig(tempDir)
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+	assert.Equal(t, "existing", entries[0].Host)
+	assert.Equal(t, "github-work", entries[1].Host)
+}
+
+func setupIncludedConfigTest(t *testing.T) (string, string) {
+	t.Helper()
+
+	tempDir := t.TempDir()
+	ConfigFileName = filepath.Join("config.d", "sshman")
+	t.Cleanup(func() { ConfigFileName = "config" })
+
+	mainConfig := "Include config.d/sshman\n\nHost server\n\tHostName server.com\n\tIdentityFile " + filepath.Join(tempDir, "id_ed25519_work") + "\n"
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, "config"), []byte(mainConfig), 0600))
+	require.NoError(t, os.MkdirAll(filepath.Join(tempDir, "config.d"), 0700))
+	includedConfig := "Host github-work\n\tHostName github.com\n\tIdentityFile " + filepath.Join(tempDir, "id_ed25519_work") + "\n"
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, "config.d", "sshman"), []byte(includedConfig), 0600))
+
+	return tempDir, filepath.Join(tempDir, "config.d", "sshman")
+}
+
+func TestReadConfig_IncludedConfigFile_ReadsBoth(t *testing.T) {
+	tempDir, _ := setupIncludedConfigTest(t)
+
+	entries, err := ReadConfig(tempDir)
+
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, "server", entries[0].Host)
+	assert.Equal(t, "github-work", entries[1].Host)
+}
+
+func TestReplaceIdentityFile_IncludedConfigFile_ReplacesBoth(t *testing.T) {
+	tempDir, includedPath := setupIncludedConfigTest(t)
+	newKeyPath := filepath.Join(tempDir, "id_ed25519_new")
+
+	replaced, err := ReplaceIdentityFile(tempDir, filepath.Join(tempDir, "id_ed25519_work"), newKeyPath)
+
+	require.NoError(t, err)
+	assert.Equal(t, 2, replaced)
+	for _, path := range []string{filepath.Join(tempDir, "config"), includedPath} {
+		content, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Contains(t, string(content), "IdentityFile "+newKeyPath+"\n")
+	}
+}
+
+func TestReplaceIdentityFile_SymlinkedConfig_KeepsLink(t *testing.T) {
+	tempDir := t.TempDir()
+	dotfilesPath := filepath.Join(t.TempDir(), "ssh_config")
+	oldKeyPath := filepath.Join(tempDir, "id_ed25519_work")
+	newKeyPath := filepath.Join(tempDir, "id_ed25519_new")
+	require.NoError(t, os.WriteFile(dotfilesPath, []byte("Host server\n\tIdentityFile "+oldKeyPath+"\n"), 0600))
+	require.NoError(t, os.Symlink(dotfilesPath, filepath.Join(tempDir, "config")))
+
+	replaced, err := ReplaceIdentityFile(tempDir, oldKeyPath, newKeyPath)
+
+	require.NoError(t, err)
+	assert.Equal(t, 1, replaced)
+	info, err := os.Lstat(filepath.Join(tempDir, "config"))
+	require.NoError(t, err)
+	assert.Equal(t, os.ModeSymlink, info.Mode().Type())
+	content, err := os.ReadFile(dotfilesPath)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "IdentityFile "+newKeyPath+"\n")
+}
+
 func TestAddToConfig_InvalidSSHPath_Error(t *testing.T) {
 	invalidPath := "/nonexistent/path"
 
@@ -147,3 +234,193 @@ func TestAddToConfig_InvalidSSHPath_Error(t *testing.T) {
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "failed to create SSH config file")
 }
+
+func TestReadConfig_Success(t *testing.T) {
+	tempDir := t.TempDir()
+	configPath := filepath.Join(tempDir, "config")
+
+	content := `# Global options
+Host *
+	AddKeysToAgent yes
+
+Host github-work gh-work
+	User git
+	HostName github.com
+	IdentityFile ~/.ssh/id_ed25519_work
+	IdentityFile ~/.ssh/id_rsa_fallback
+
+host=server
+  user = deploy
+  hostname="server.example.com"
+  identityfile /keys/id_ed25519_server
+
+Match host other
+	User ignored
+`
+	err := os.WriteFile(configPath, []byte(content), 0600)
+	require.NoError(t, err)
+
+	entries, err := ReadConfig(tempDir)
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+
+	assert.Equal(t, ConfigEntry{Host: "github-work", User: "git", Hostname: "github.com", IdentityFile: "~/.ssh/id_ed25519_work"}, entries[0])
+	assert.Equal(t, ConfigEntry{Host: "gh-work", User: "git", Hostname: "github.com", IdentityFile: "~/.ssh/id_ed25519_work"}, entries[1])
+	assert.Equal(t, ConfigEntry{Host: "server", User: "deploy", Hostname: "server.example.com", IdentityFile: "/keys/id_ed25519_server"}, entries[2])
+}
+
+func TestReadConfig_RoundTrip_Success(t *testing.T) {
+	tempDir := t.TempDir()
+
+	entry := ConfigEntry{
+		Host:         "gitlab-personal",
+		User:         "git",
+		Hostname:     "gitlab.com",
+		IdentityFile: "/path/to/key",
+	}
+	require.NoError(t, AddToConfig(tempDir, entry))
+
+	entries, err := ReadConfig(tempDir)
+	require.NoError(t, err)
+	assert.Equal(t, []ConfigEntry{entry}, entries)
+}
+
+func TestReadConfig_NoFile_Empty(t *testing.T) {
+	entries, err := ReadConfig(t.TempDir())
+
+	assert.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestFindEntriesByIdentityFile(t *testing.T) {
+	homeDir, err := os.UserHomeDir()
+	require.NoError(t, err)
+
+	entries := []ConfigEntry{
+		{Host: "github-work", IdentityFile: "~/.ssh/id_ed25519_work"},
+		{Host: "gitlab-work", IdentityFile: filepath.Join(homeDir, ".ssh", "id_ed25519_work")},
+		{Host: "server", IdentityFile: "/keys/id_ed25519_server"},
+		{Host: "no-identity"},
+	}
+
+	matches := FindEntriesByIdentityFile(entries, filepath.Join(homeDir, ".ssh", "id_ed25519_work"))
+
+	require.Len(t, matches, 2)
+	assert.Equal(t, "github-work", matches[0].Host)
+	assert.Equal(t, "gitlab-work", matches[1].Host)
+
+	assert.Empty(t, FindEntriesByIdentityFile(entries, "/keys/unknown"))
+}
+
+func TestReplaceIdentityFile_Success(t *testing.T) {
+	homeDir, err := os.UserHomeDir()
+	require.NoError(t, err)
+
+	tempDir := t.TempDir()
+	configPath := filepath.Join(tempDir, "config")
+	oldKeyPath := filepath.Join(homeDir, ".ssh", "id_ed25519_work")
+	newKeyPath := filepath.Join(homeDir, ".ssh", "id_ed25519_work_20250101")
+
+	content := "Host github-work\n" +
+		"\tIdentityFile ~/.ssh/id_ed25519_work\n" +
+		"Host gitlab-work\n" +
+		"\tIdentityFile " + oldKeyPath + "\n" +
+		"Host other\n" +
+		"\tIdentityFile ~/.ssh/id_ed25519_work_other\n"
+	require.NoError(t, os.WriteFile(configPath, []byte(content), 0600))
+
+	replaced, err := ReplaceIdentityFile(tempDir, oldKeyPath, newKeyPath)
+	require.NoError(t, err)
+	assert.Equal(t, 2, replaced)
+
+	updated, err := os.ReadFile(configPath)
+	require.NoError(t, err)
+
+	expected := "Host github-work\n" +
+		"\tIdentityFile ~/.ssh/id_ed25519_work_20250101\n" +
+		"Host gitlab-work\n" +
+		"\tIdentityFile " + newKeyPath + "\n" +
+		"Host other\n" +
+		"\tIdentityFile ~/.ssh/id_ed25519_work_other\n"
+	assert.Equal(t, expected, string(updated))
+}
+
+func TestReplaceIdentityFile_NoMatch_Unchanged(t *testing.T) {
+	tempDir := t.TempDir()
+	configPath := filepath.Join(tempDir, "config")
+
+	content := "Host server\n\tIdentityFile /keys/id_ed25519_server\n"
+	require.NoError(t, os.WriteFile(configPath, []byte(content), 0600))
+
+	replaced, err := ReplaceIdentityFile(tempDir, "/keys/id_ed25519_other", "/keys/id_ed25519_new")
+
+	assert.NoError(t, err)
+	assert.Equal(t, 0, replaced)
+
+	updated, err := os.ReadFile(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, content, string(updated))
+}
+
+func TestReplaceIdentityFile_NoConfig_Success(t *testing.T) {
+	replaced, err := ReplaceIdentityFile(t.TempDir(), "/keys/old", "/keys/new")
+
+	assert.NoError(t, err)
+	assert.Equal(t, 0, replaced)
+}
+
+func TestRemoveHostBlocks(t *testing.T) {
+	content := `Host *
+	AddKeysToAgent yes
+
+# Generated by sshman - 2024-01-01 00:00:00
+Host github-work
+	User git
+	HostName github.com
+	IdentityFile ~/.ssh/id_ed25519_work
+
+# Generated by sshman - 2024-01-01 00:00:00
+Host github-personal
+	HostName github.com
+	IdentityFile ~/.ssh/id_ed25519_personal
+
+Host shared
+	IdentityFile ~/.ssh/id_ed25519_work
+	IdentityFile ~/.ssh/id_rsa
+`
+	home, err := os.UserHomeDir()
+	require.NoError(t, err)
+
+	newContent, removed, kept := removeHostBlocks(content, filepath.Join(home, ".ssh", "id_ed25519_work"))
+
+	assert.Equal(t, `Host *
+	AddKeysToAgent yes
+
+# Generated by sshman - 2024-01-01 00:00:00
+Host github-personal
+	HostName github.com
+	IdentityFile ~/.ssh/id_ed25519_personal
+
+Host shared
+	IdentityFile ~/.ssh/id_ed25519_work
+	IdentityFile ~/.ssh/id_rsa
+`, newContent)
+	assert.Equal(t, `
+# Generated by sshman - 2024-01-01 00:00:00
+Host github-work
+	User git
+	HostName github.com
+	IdentityFile ~/.ssh/id_ed25519_work
+`, removed)
+	assert.Equal(t, []string{"shared"}, kept)
+}
+
+func TestRemoveHostBlocks_NoMatch_Unchanged(t *testing.T) {
+	content := "Host github-work\n\tIdentityFile ~/.ssh/id_ed25519_work\n"
+
+	newContent, removed, kept := removeHostBlocks(content, "/keys/id_rsa")
+
+	assert.Equal(t, content, newContent)
+	assert.Empty(t, removed)
+	assert.Empty(t, kept)
+}
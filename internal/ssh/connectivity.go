@@ -0,0 +1,135 @@
+package ssh
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/residwi/sshman/internal/interfaces"
+)
+
+const (
+	StatusSuccess         = "success"
+	StatusAuthFailed      = "auth failed"
+	StatusHostKeyMismatch = "host key mismatch"
+	StatusUnknownHostKey  = "unknown host key"
+	StatusTimeout         = "timeout"
+	StatusUnreachable     = "unreachable"
+	StatusError           = "error"
+)
+
+// greetings printed by git hosting providers after a successful authentication
+var providerGreetings = []*regexp.Regexp{
+	regexp.MustCompile(`Hi (?:there, )?([^!\s]+)! You've successfully authenticated`),
+	regexp.MustCompile(`Welcome to GitLab, @([^!\s]+)!`),
+	regexp.MustCompile(`logged in as ([^\s]+?)\.`),
+	regexp.MustCompile(`authenticated via (?:an? )?ssh key`),
+}
+
+var connectionFailures = []struct {
+	status   string
+	patterns []string
+}{
+	{StatusHostKeyMismatch, []string{"REMOTE HOST IDENTIFICATION HAS CHANGED"}},
+	{StatusUnknownHostKey, []string{"Host key verification failed", "No ED25519 host key is known", "No RSA host key is known", "No ECDSA host key is known"}},
+	{StatusAuthFailed, []string{"Permission denied"}},
+	{StatusTimeout, []string{"timed out"}},
+	{StatusUnreachable, []string{"Could not resolve hostname", "Connection refused", "No route to host", "Network is unreachable"}},
+}
+
+type ConnectionResult struct {
+	Host    string
+	Status  string
+	Account string
+	Message string
+}
+
+type ConnectionTester struct {
+	executor interfaces.CommandExecutor
+	timeout  time.Duration
+}
+
+func NewConnectionTester(executor interfaces.CommandExecutor, timeout time.Duration) *ConnectionTester {
+	return &ConnectionTester{
+		executor: executor,
+		timeout:  timeout,
+	}
+}
+
+func (ct *ConnectionTester) Test(host string) ConnectionResult {
+	// ssh reads ConnectTimeout=0 as no timeout, so round up to whole seconds
+	seconds := max(int(math.Ceil(ct.timeout.Seconds())), 1)
+	connectTimeout := fmt.Sprintf("ConnectTimeout=%d", seconds)
+
+	// ConnectTimeout only covers the TCP connection, a server that stalls afterwards is
+	// cut off once the connection and the authentication both used up the timeout
+	deadline := 2 * time.Duration(seconds) * time.Second
+	ctx, cancel := context.WithTimeout(context.Background(), deadline)
+	defer cancel()
+
+	output, err := ct.executor.ExecuteWithCombinedOutputContext(ctx, "ssh", "-T", "-o", "BatchMode=yes", "-o", connectTimeout, host)
+	if ctx.Err() != nil {
+		return ConnectionResult{Host: host, Status: StatusTimeout, Message: "no response from ssh within " + deadline.String()}
+	}
+
+	result := parseConnectionOutput(string(output), err)
+	result.Host = host
+	return result
+}
+
+// TestAll tests the hosts concurrently and returns the results in the same order
+func (ct *ConnectionTester) TestAll(hosts []string) []ConnectionResult {
+	results := make([]ConnectionResult, len(hosts))
+
+	var wg sync.WaitGroup
+	for i, host := range hosts {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = ct.Test(host)
+		}()
+	}
+	wg.Wait()
+
+	return results
+}
+
+func parseConnectionOutput(output string, err error) ConnectionResult {
+	// providers refuse shell access with a non-zero exit code, so check greetings first
+	for _, greeting := range providerGreetings {
+		if match := greeting.FindStringSubmatch(output); match != nil {
+			result := ConnectionResult{Status: StatusSuccess}
+			if len(match) > 1 {
+				result.Account = match[1]
+			}
+			return result
+		}
+	}
+
+	for _, failure := range connectionFailures {
+		for _, pattern := range failure.patterns {
+			if strings.Contains(output, pattern) {
+				return ConnectionResult{Status: failure.status, Message: lastLine(output)}
+			}
+		}
+	}
+
+	if err == nil {
+		return ConnectionResult{Status: StatusSuccess}
+	}
+
+	message := lastLine(output)
+	if message == "" {
+		message = err.Error()
+	}
+	return ConnectionResult{Status: StatusError, Message: message}
+}
+
+func lastLine(output string) string {
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	return strings.TrimSpace(lines[len(lines)-1])
+}
@@ -0,0 +1,106 @@
+package ssh
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/residwi/sshman/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestParseConnectionOutput(t *testing.T) {
+	exitError := fmt.Errorf("exit status 1")
+
+	tests := []struct {
+		name            string
+		output          string
+		err             error
+		expectedStatus  string
+		expectedAccount string
+	}{
+		{"github_greeting", "Hi residwi! You've successfully authenticated, but GitHub does not provide shell access.", exitError, StatusSuccess, "residwi"},
+		{"github_deploy_key", "Hi residwi/sshman! You've successfully authenticated, but GitHub does not provide shell access.", exitError, StatusSuccess, "residwi/sshman"},
+		{"gitlab_greeting", "Welcome to GitLab, @residwi!", nil, StatusSuccess, "residwi"},
+		{"bitbucket_greeting", "logged in as residwi.\n\nYou can use git or hg to connect to Bitbucket. Shell access is disabled.", exitError, StatusSuccess, "residwi"},
+		{"bitbucket_new_greeting", "authenticated via ssh key.\n\nYou can use git to connect to Bitbucket. Shell access is disabled.", exitError, StatusSuccess, ""},
+		{"gitea_greeting", "Hi there, residwi! You've successfully authenticated with the key named work, but Gitea does not provide shell access.", exitError, StatusSuccess, "residwi"},
+		{"generic_success", "", nil, StatusSuccess, ""},
+		{"auth_failed", "git@github.com: Permission denied (publickey).", fmt.Errorf("exit status 255"), StatusAuthFailed, ""},
+		{"host_key_changed", "@@@@@\n@    WARNING: REMOTE HOST IDENTIFICATION HAS CHANGED!     @\n@@@@@\nHost key verification failed.", fmt.Errorf("exit status 255"), StatusHostKeyMismatch, ""},
+		{"unknown_host_key", "No ED25519 host key is known for server.example.com and you have requested strict checking.\r\nHost key verification failed.", fmt.Errorf("exit status 255"), StatusUnknownHostKey, ""},
+		{"timeout", "ssh: connect to host 10.0.0.1 port 22: Connection timed out", fmt.Errorf("exit status 255"), StatusTimeout, ""},
+		{"unresolved_host", "ssh: Could not resolve hostname nope: Name or service not known", fmt.Errorf("exit status 255"), StatusUnreachable, ""},
+		{"unknown_error", "something unexpected happened", fmt.Errorf("exit status 255"), StatusError, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := parseConnectionOutput(tt.output, tt.err)
+
+			assert.Equal(t, tt.expectedStatus, result.Status)
+			assert.Equal(t, tt.expectedAccount, result.Account)
+			if tt.expectedStatus != StatusSuccess {
+				assert.NotEmpty(t, result.Message)
+			}
+		})
+	}
+}
+
+func TestConnectionTester_Test_Success(t *testing.T) {
+	mockExecutor := mocks.NewMockCommandExecutor(t)
+
+	greeting := "Hi residwi! You've successfully authenticated, but GitHub does not provide shell access.\n"
+	mockExecutor.EXPECT().ExecuteWithCombinedOutputContext(mock.Anything, "ssh", []string{"-T", "-o", "BatchMode=yes", "-o", "ConnectTimeout=10", "github-work"}).Return([]byte(greeting), fmt.Errorf("exit status 1"))
+
+	tester := NewConnectionTester(mockExecutor, 10*time.Second)
+	result := tester.Test("github-work")
+
+	assert.Equal(t, ConnectionResult{Host: "github-work", Status: StatusSuccess, Account: "residwi"}, result)
+}
+
+func TestConnectionTester_TestAll_KeepsOrder(t *testing.T) {
+	mockExecutor := mocks.NewMockCommandExecutor(t)
+
+	mockExecutor.EXPECT().ExecuteWithCombinedOutputContext(mock.Anything, "ssh", []string{"-T", "-o", "BatchMode=yes", "-o", "ConnectTimeout=5", "github-work"}).Return([]byte("Hi residwi! You've successfully authenticated"), fmt.Errorf("exit status 1"))
+	mockExecutor.EXPECT().ExecuteWithCombinedOutputContext(mock.Anything, "ssh", []string{"-T", "-o", "BatchMode=yes", "-o", "ConnectTimeout=5", "server"}).Return([]byte("deploy@server.example.com: Permission denied (publickey)."), fmt.Errorf("exit status 255"))
+	mockExecutor.EXPECT().ExecuteWithCombinedOutputContext(mock.Anything, "ssh", []string{"-T", "-o", "BatchMode=yes", "-o", "ConnectTimeout=5", "gitlab-work"}).Return([]byte("Welcome to GitLab, @residwi!"), nil)
+
+	tester := NewConnectionTester(mockExecutor, 5*time.Second)
+	results := tester.TestAll([]string{"github-work", "server", "gitlab-work"})
+
+	assert.Len(t, results, 3)
+	assert.Equal(t, "github-work", results[0].Host)
+	assert.Equal(t, StatusSuccess, results[0].Status)
+	assert.Equal(t, "server", results[1].Host)
+	assert.Equal(t, StatusAuthFailed, results[1].Status)
+	assert.Equal(t, "gitlab-work", results[2].Host)
+	assert.Equal(t, StatusSuccess, results[2].Status)
+}
+
+func TestConnectionTester_Test_SubSecondTimeout(t *testing.T) {
+	mockExecutor := mocks.NewMockCommandExecutor(t)
+
+	mockExecutor.EXPECT().ExecuteWithCombinedOutputContext(mock.Anything, "ssh", []string{"-T", "-o", "BatchMode=yes", "-o", "ConnectTimeout=1", "server"}).Return(nil, nil)
+
+	result := NewConnectionTester(mockExecutor, 300*time.Millisecond).Test("server")
+
+	assert.Equal(t, StatusSuccess, result.Status)
+}
+
+func TestConnectionTester_Test_Deadline(t *testing.T) {
+	mockExecutor := mocks.NewMockCommandExecutor(t)
+
+	mockExecutor.EXPECT().ExecuteWithCombinedOutputContext(mock.Anything, "ssh", []string{"-T", "-o", "BatchMode=yes", "-o", "ConnectTimeout=1", "stalled"}).
+		RunAndReturn(func(ctx context.Context, name string, args ...string) ([]byte, error) {
+			// the process is killed when the deadline passes
+			<-ctx.Done()
+			return nil, fmt.Errorf("signal: killed")
+		})
+
+	result := NewConnectionTester(mockExecutor, time.Second).Test("stalled")
+
+	assert.Equal(t, ConnectionResult{Host: "stalled", Status: StatusTimeout, Message: "no response from ssh within 2s"}, result)
+}
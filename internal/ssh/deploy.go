@@ -0,0 +1,105 @@
+package ssh
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/residwi/sshman/internal/interfaces"
+	"github.com/residwi/sshman/utils"
+)
+
+const (
+	DeployAdded   = "added"
+	DeployPresent = "present"
+	DeployRemoved = "removed"
+	DeployAbsent  = "absent"
+)
+
+// the key being deployed is usually not authorized yet, so password logins must stay possible
+// even when the host's config only allows public key authentication
+const deployAuthentications = "PreferredAuthentications=publickey,keyboard-interactive,password"
+
+// Deployer adds public keys to and removes them from the authorized_keys file of a remote host
+type Deployer struct {
+	executor interfaces.CommandExecutor
+}
+
+func NewDeployer(executor interfaces.CommandExecutor) *Deployer {
+	return &Deployer{
+		executor: executor,
+	}
+}
+
+// Deploy appends the public key, prefixed with the authorized_keys options if given, unless
+// the host already authorizes it. It returns DeployAdded or DeployPresent.
+func (d *Deployer) Deploy(host, publicKey, options string) (string, error) {
+	blob, err := publicKeyBlob(publicKey)
+	if err != nil {
+		return "", err
+	}
+
+	if strings.ContainsAny(options, "\r\n") {
+		return "", fmt.Errorf("invalid authorized_keys options: %s", options)
+	}
+
+	line := strings.TrimSpace(publicKey)
+	if options != "" {
+		line = options + " " + line
+	}
+
+	script := fmt.Sprintf(`umask 077 && mkdir -p ~/.ssh && touch ~/.ssh/authorized_keys || exit 1
+if grep -qF %[1]s ~/.ssh/authorized_keys; then echo %[3]s; else printf '%%s\n' %[2]s >> ~/.ssh/authorized_keys && echo %[4]s; fi`,
+		utils.ShellQuote(blob), utils.ShellQuote(line), DeployPresent, DeployAdded)
+
+	return d.run(host, script)
+}
+
+// Revoke removes every line authorizing the public key. It returns DeployRemoved or DeployAbsent.
+func (d *Deployer) Revoke(host, publicKey string) (string, error) {
+	blob, err := publicKeyBlob(publicKey)
+	if err != nil {
+		return "", err
+	}
+
+	// grep -v exits with 1 when no line is left, only a higher status is an error
+	script := fmt.Sprintf(`umask 077 && f=~/.ssh/authorized_keys
+if [ -f "$f" ] && grep -qF %[1]s "$f"; then
+grep -vF %[1]s "$f" > "$f.sshman"; [ $? -le 1 ] || { rm -f "$f.sshman"; echo "failed to filter $f" >&2; exit 1; }
+mv "$f.sshman" "$f" && echo %[2]s; else echo %[3]s; fi`,
+		utils.ShellQuote(blob), DeployRemoved, DeployAbsent)
+
+	return d.run(host, script)
+}
+
+func (d *Deployer) run(host, script string) (string, error) {
+	// the remote login shell may not be a POSIX shell, so the script is run by sh like ssh-copy-id does
+	command := "exec sh -c " + utils.ShellQuote(script)
+	output, err := d.executor.ExecuteWithOutput("ssh", "-o", deployAuthentications, host, command)
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+			return "", fmt.Errorf("failed to update authorized_keys on %s: %s", host, strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return "", fmt.Errorf("failed to update authorized_keys on %s: %w", host, err)
+	}
+
+	result := strings.TrimSpace(string(output))
+	if result == "" {
+		return "", fmt.Errorf("failed to update authorized_keys on %s: no response from host", host)
+	}
+
+	lines := strings.Split(result, "\n")
+	return lines[len(lines)-1], nil
+}
+
+// publicKeyBlob returns the algorithm and base64 key of a public key, which identify it
+// regardless of its comment or options
+func publicKeyBlob(publicKey string) (string, error) {
+	fields := strings.Fields(publicKey)
+	if len(fields) < 2 {
+		return "", fmt.Errorf("invalid public key")
+	}
+	return fields[0] + " " + fields[1], nil
+}
@@ -0,0 +1,139 @@
+package ssh
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/residwi/sshman/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const deployTestPublicKey = "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIKey deploy@example.com"
+
+// newRemoteExecutor runs the scripts sent to the host with a local shell, using home as the remote home directory
+func newRemoteExecutor(t *testing.T, home string) *mocks.MockCommandExecutor {
+	mockExecutor := mocks.NewMockCommandExecutor(t)
+	mockExecutor.EXPECT().ExecuteWithOutput("ssh", mock.Anything).RunAndReturn(func(name string, args ...string) ([]byte, error) {
+		require.Len(t, args, 4)
+		assert.Equal(t, []string{"-o", deployAuthentications, "server"}, args[:3])
+		assert.True(t, strings.HasPrefix(args[3], "exec sh -c '"))
+
+		cmd := exec.Command("sh", "-c", args[3])
+		cmd.Env = append(os.Environ(), "HOME="+home)
+		return cmd.Output()
+	})
+	return mockExecutor
+}
+
+func TestDeployer_Deploy_AddsKeyOnce(t *testing.T) {
+	home := t.TempDir()
+	deployer := NewDeployer(newRemoteExecutor(t, home))
+
+	result, err := deployer.Deploy("server", deployTestPublicKey+"\n", "")
+	require.NoError(t, err)
+	assert.Equal(t, DeployAdded, result)
+
+	result, err = deployer.Deploy("server", deployTestPublicKey, `from="10.0.0.0/8"`)
+	require.NoError(t, err)
+	assert.Equal(t, DeployPresent, result)
+
+	authorizedKeysPath := filepath.Join(home, ".ssh", "authorized_keys")
+	content, err := os.ReadFile(authorizedKeysPath)
+	require.NoError(t, err)
+	assert.Equal(t, deployTestPublicKey+"\n", string(content))
+
+	info, err := os.Stat(authorizedKeysPath)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+}
+
+func TestDeployer_Deploy_WithOptions(t *testing.T) {
+	home := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(home, ".ssh"), 0700))
+	require.NoError(t, os.WriteFile(filepath.Join(home, ".ssh", "authorized_keys"), []byte("ssh-rsa AAAAother other@example.com\n"), 0600))
+
+	result, err := NewDeployer(newRemoteExecutor(t, home)).Deploy("server", deployTestPublicKey, `from="10.0.0.0/8",no-agent-forwarding`)
+	require.NoError(t, err)
+	assert.Equal(t, DeployAdded, result)
+
+	content, err := os.ReadFile(filepath.Join(home, ".ssh", "authorized_keys"))
+	require.NoError(t, err)
+	assert.Equal(t, "ssh-rsa AAAAother other@example.com\n"+`from="10.0.0.0/8",no-agent-forwarding `+deployTestPublicKey+"\n", string(content))
+}
+
+func TestDeployer_Deploy_InvalidInput(t *testing.T) {
+	deployer := NewDeployer(mocks.NewMockCommandExecutor(t))
+
+	_, err := deployer.Deploy("server", "not-a-key", "")
+	assert.Error(t, err)
+
+	_, err = deployer.Deploy("server", deployTestPublicKey, "no-pty\nssh-rsa AAAA")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid authorized_keys options")
+}
+
+func TestDeployer_Revoke(t *testing.T) {
+	home := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(home, ".ssh"), 0700))
+	authorizedKeys := "ssh-rsa AAAAother other@example.com\n" + `no-pty ` + deployTestPublicKey + "\n"
+	require.NoError(t, os.WriteFile(filepath.Join(home, ".ssh", "authorized_keys"), []byte(authorizedKeys), 0600))
+	deployer := NewDeployer(newRemoteExecutor(t, home))
+
+	result, err := deployer.Revoke("server", deployTestPublicKey)
+	require.NoError(t, err)
+	assert.Equal(t, DeployRemoved, result)
+
+	result, err = deployer.Revoke("server", deployTestPublicKey)
+	require.NoError(t, err)
+	assert.Equal(t, DeployAbsent, result)
+
+	content, err := os.ReadFile(filepath.Join(home, ".ssh", "authorized_keys"))
+	require.NoError(t, err)
+	assert.Equal(t, "ssh-rsa AAAAother other@example.com\n", string(content))
+}
+
+func TestDeployer_Revoke_GrepFails_KeepsAuthorizedKeys(t *testing.T) {
+	home := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(home, ".ssh"), 0700))
+	authorizedKeys := deployTestPublicKey + "\n"
+	require.NoError(t, os.WriteFile(filepath.Join(home, ".ssh", "authorized_keys"), []byte(authorizedKeys), 0600))
+
+	// a grep that fails to filter the file, like on a read error
+	realGrep, err := exec.LookPath("grep")
+	require.NoError(t, err)
+	binDir := t.TempDir()
+	fakeGrep := "#!/bin/sh\n[ \"$1\" = -vF ] && exit 2\nexec " + realGrep + " \"$@\"\n"
+	require.NoError(t, os.WriteFile(filepath.Join(binDir, "grep"), []byte(fakeGrep), 0755))
+	t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	_, err = NewDeployer(newRemoteExecutor(t, home)).Revoke("server", deployTestPublicKey)
+
+	assert.ErrorContains(t, err, "failed to filter")
+	content, err := os.ReadFile(filepath.Join(home, ".ssh", "authorized_keys"))
+	require.NoError(t, err)
+	assert.Equal(t, authorizedKeys, string(content))
+	assert.NoFileExists(t, filepath.Join(home, ".ssh", "authorized_keys.sshman"))
+}
+
+func TestDeployer_Revoke_NoAuthorizedKeys(t *testing.T) {
+	result, err := NewDeployer(newRemoteExecutor(t, t.TempDir())).Revoke("server", deployTestPublicKey)
+
+	require.NoError(t, err)
+	assert.Equal(t, DeployAbsent, result)
+}
+
+func TestDeployer_Deploy_ConnectionError(t *testing.T) {
+	mockExecutor := mocks.NewMockCommandExecutor(t)
+	mockExecutor.EXPECT().ExecuteWithOutput("ssh", mock.Anything).Return(nil, fmt.Errorf("exit status 255"))
+
+	_, err := NewDeployer(mockExecutor).Deploy("server", deployTestPublicKey, "")
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to update authorized_keys on server")
+}
@@ -0,0 +1,86 @@
+package ssh
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	gossh "golang.org/x/crypto/ssh"
+)
+
+func newTestPrivateKey(t *testing.T) ed25519.PrivateKey {
+	t.Helper()
+
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	return privateKey
+}
+
+func newTestSigner(t *testing.T) gossh.Signer {
+	t.Helper()
+
+	signer, err := gossh.NewSignerFromKey(newTestPrivateKey(t))
+	require.NoError(t, err)
+	return signer
+}
+
+func newTestPublicKey(t *testing.T) gossh.PublicKey {
+	t.Helper()
+
+	return newTestSigner(t).PublicKey()
+}
+
+// newAuthorizedKeyLine returns a new ed25519 public key in authorized_keys format and its fingerprint
+func newAuthorizedKeyLine(t *testing.T, comment string) (string, string) {
+	t.Helper()
+
+	publicKey := newTestPublicKey(t)
+	line := strings.TrimSpace(string(gossh.MarshalAuthorizedKey(publicKey))) + " " + comment
+	return line, gossh.FingerprintSHA256(publicKey)
+}
+
+// newTestCert signs a new ed25519 key with a new CA and returns the certificate in authorized_keys format
+func newTestCert(t *testing.T, cert *gossh.Certificate) ([]byte, gossh.PublicKey) {
+	t.Helper()
+
+	caSigner := newTestSigner(t)
+	cert.Key = newTestPublicKey(t)
+	require.NoError(t, cert.SignCert(rand.Reader, caSigner))
+
+	return gossh.MarshalAuthorizedKey(cert), caSigner.PublicKey()
+}
+
+func writeTestKeyPair(t *testing.T, dir, name string, privateKey any, passphrase string, withPublicKey bool) string {
+	t.Helper()
+
+	var block *pem.Block
+	var err error
+	if passphrase != "" {
+		block, err = gossh.MarshalPrivateKeyWithPassphrase(privateKey, "", []byte(passphrase))
+	} else {
+		block, err = gossh.MarshalPrivateKey(privateKey, "")
+	}
+	require.NoError(t, err)
+
+	keyPath := filepath.Join(dir, name)
+	require.NoError(t, os.WriteFile(keyPath, pem.EncodeToMemory(block), 0600))
+
+	if withPublicKey {
+		signer, err := gossh.NewSignerFromKey(privateKey)
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(keyPath+".pub", gossh.MarshalAuthorizedKey(signer.PublicKey()), 0644))
+	}
+
+	return keyPath
+}
+
+func writeTestPublicKey(t *testing.T, keyPath string) {
+	t.Helper()
+
+	require.NoError(t, os.WriteFile(keyPath+".pub", gossh.MarshalAuthorizedKey(newTestPublicKey(t)), 0644))
+}
@@ -0,0 +1,303 @@
+package ssh
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/residwi/sshman/utils"
+	gossh "golang.org/x/crypto/ssh"
+)
+
+// maxCertSize bounds the response of a certificate issuer
+const maxCertSize = 64 << 10
+
+// CertRequest asks an issuer for a certificate of a public key
+type CertRequest struct {
+	PublicKey  string   `json:"public_key"`
+	Identity   string   `json:"identity"`
+	Principals []string `json:"principals"`
+	Validity   string   `json:"validity,omitempty"`
+	Type       string   `json:"type"`
+	// Options are in the format of ssh-keygen -O, e.g. clear or force-command=uptime
+	Options []string `json:"options,omitempty"`
+}
+
+// CertIssuer issues certificates for public keys, returning them in authorized_keys format
+type CertIssuer interface {
+	Issue(request CertRequest) ([]byte, error)
+}
+
+// LocalCertIssuer signs certificates with a CA key on this machine
+type LocalCertIssuer struct {
+	signer    *CertSigner
+	caKeyPath string
+}
+
+func NewLocalCertIssuer(signer *CertSigner, caKeyPath string) *LocalCertIssuer {
+	return &LocalCertIssuer{
+		signer:    signer,
+		caKeyPath: caKeyPath,
+	}
+}
+
+func (i *LocalCertIssuer) Issue(request CertRequest) ([]byte, error) {
+	// ssh-keygen signs a public key file and writes the certificate next to it
+	tempDir, err := os.MkdirTemp("", "sshman-cert-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	keyPath := filepath.Join(tempDir, "key")
+	if err := os.WriteFile(keyPath+".pub", []byte(request.PublicKey+"\n"), 0644); err != nil {
+		return nil, fmt.Errorf("failed to write public key: %w", err)
+	}
+
+	certPath, err := i.signer.Sign(CertConfig{
+		KeyPath:    keyPath,
+		CAKeyPath:  i.caKeyPath,
+		Identity:   request.Identity,
+		Principals: request.Principals,
+		Validity:   request.Validity,
+		HostCert:   request.Type == "host",
+		Options:    request.Options,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := os.ReadFile(certPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read certificate: %w", err)
+	}
+	return content, nil
+}
+
+// HTTPCertIssuer requests certificates from a signing service, posting the CertRequest as JSON.
+// The service answers with the certificate, either as text or as {"certificate": "..."}.
+type HTTPCertIssuer struct {
+	url    string
+	token  string
+	client *http.Client
+}
+
+func NewHTTPCertIssuer(url, token string) *HTTPCertIssuer {
+	return &HTTPCertIssuer{
+		url:    url,
+		token:  token,
+		client: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+func (i *HTTPCertIssuer) Issue(request CertRequest) ([]byte, error) {
+	payload, err := json.Marshal(request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode certificate request: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, i.url, bytes.NewReader(payload))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create certificate request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if i.token != "" {
+		req.Header.Set("Authorization", "Bearer "+i.token)
+	}
+
+	resp, err := i.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to request certificate: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxCertSize))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read certificate response: %w", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("failed to request certificate: unexpected status %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	if mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type")); mediaType == "application/json" {
+		var response struct {
+			Certificate string `json:"certificate"`
+		}
+		if err := json.Unmarshal(body, &response); err != nil {
+			return nil, fmt.Errorf("failed to parse certificate response: %w", err)
+		}
+		body = []byte(response.Certificate)
+	}
+
+	return body, nil
+}
+
+// IssueCert gets a certificate for a key from the issuer and stores it next to the key,
+// after checking that it certifies that key
+func IssueCert(issuer CertIssuer, keyPath string, request CertRequest) (*CertInfo, error) {
+	publicKey, err := os.ReadFile(keyPath + ".pub")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read public key: %w", err)
+	}
+	request.PublicKey = strings.TrimSpace(string(publicKey))
+	if request.Type == "" {
+		request.Type = "user"
+	}
+
+	content, err := issuer.Issue(request)
+	if err != nil {
+		return nil, err
+	}
+
+	certPath := keyPath + CertSuffix
+	info, err := ParseCert(certPath, content)
+	if err != nil {
+		return nil, err
+	}
+
+	if !certifiesKey(content, publicKey) {
+		return nil, fmt.Errorf("the issued certificate is for a different public key")
+	}
+
+	if err := utils.WriteFileAtomic(certPath, append(bytes.TrimSpace(content), '\n'), 0644); err != nil {
+		return nil, fmt.Errorf("failed to write certificate: %w", err)
+	}
+
+	return info, nil
+}
+
+// standardCertExtensions are the extensions ssh-keygen sets by name with -O
+var standardCertExtensions = []string{
+	"no-touch-required",
+	"permit-X11-forwarding",
+	"permit-agent-forwarding",
+	"permit-port-forwarding",
+	"permit-pty",
+	"permit-user-rc",
+}
+
+// RenewRequest asks for a certificate like the current one, valid for as long as it was.
+// It fails when the options or extensions of the certificate cannot be asked for again.
+func (c CertInfo) RenewRequest() (CertRequest, error) {
+	request := CertRequest{
+		Identity:   c.KeyID,
+		Principals: c.Principals,
+		Type:       c.Type,
+	}
+
+	if !c.IsForever() && !c.ValidAfter.IsZero() {
+		lifetime := c.ValidBefore.Sub(c.ValidAfter).Truncate(time.Minute)
+		// ssh-keygen starts certificates a minute or two early to allow for clock skew,
+		// so round the lifetime down to the round number it was most likely asked for
+		for _, unit := range []time.Duration{24 * time.Hour, time.Hour, 15 * time.Minute, 5 * time.Minute} {
+			if lifetime >= unit && lifetime%unit <= 2*time.Minute {
+				lifetime = lifetime.Truncate(unit)
+				break
+			}
+		}
+		request.Validity = "+" + formatValidity(lifetime)
+	}
+
+	options, err := c.renewOptions()
+	if err != nil {
+		return CertRequest{}, err
+	}
+	request.Options = options
+
+	return request, nil
+}
+
+// renewOptions lists the ssh-keygen -O options that reproduce the critical options and
+// extensions of the certificate, starting from none
+func (c CertInfo) renewOptions() ([]string, error) {
+	if c.Type == "host" {
+		// ssh-keygen only sets options on user certificates
+		if len(c.CriticalOptions) > 0 || len(c.Extensions) > 0 {
+			return nil, fmt.Errorf("cannot reproduce the options or extensions of a host certificate")
+		}
+		return nil, nil
+	}
+
+	options := []string{"clear"}
+	for _, name := range slices.Sorted(maps.Keys(c.CriticalOptions)) {
+		value := c.CriticalOptions[name]
+		if strings.Contains(name, "=") {
+			return nil, fmt.Errorf("cannot reproduce critical option %q", name)
+		}
+
+		switch name {
+		case "force-command", "source-address":
+			options = append(options, name+"="+value)
+		case "verify-required":
+			if value != "" {
+				return nil, fmt.Errorf("cannot reproduce critical option %s with contents %q", name, value)
+			}
+			options = append(options, name)
+		default:
+			options = append(options, customCertOption("critical:", name, value))
+		}
+	}
+
+	for _, name := range c.Extensions {
+		value := c.ExtensionValues[name]
+		if strings.Contains(name, "=") {
+			return nil, fmt.Errorf("cannot reproduce extension %q", name)
+		}
+
+		if slices.Contains(standardCertExtensions, name) {
+			if value != "" {
+				return nil, fmt.Errorf("cannot reproduce extension %s with contents %q", name, value)
+			}
+			options = append(options, name)
+			continue
+		}
+		options = append(options, customCertOption("extension:", name, value))
+	}
+
+	return options, nil
+}
+
+func customCertOption(prefix, name, value string) string {
+	if value == "" {
+		return prefix + name
+	}
+	return prefix + name + "=" + value
+}
+
+func certifiesKey(certContent, publicKeyContent []byte) bool {
+	certKey, _, _, _, err := gossh.ParseAuthorizedKey(certContent)
+	if err != nil {
+		return false
+	}
+	publicKey, _, _, _, err := gossh.ParseAuthorizedKey(publicKeyContent)
+	if err != nil {
+		return false
+	}
+
+	cert, ok := certKey.(*gossh.Certificate)
+	return ok && bytes.Equal(cert.Key.Marshal(), publicKey.Marshal())
+}
+
+// formatValidity writes a duration in the time format of ssh-keygen -V, e.g. 8h or 90m
+func formatValidity(duration time.Duration) string {
+	switch {
+	case duration%(24*time.Hour) == 0:
+		return fmt.Sprintf("%dd", duration/(24*time.Hour))
+	case duration%time.Hour == 0:
+		return fmt.Sprintf("%dh", duration/time.Hour)
+	case duration%time.Minute == 0:
+		return fmt.Sprintf("%dm", duration/time.Minute)
+	default:
+		return fmt.Sprintf("%ds", duration/time.Second)
+	}
+}
@@ -0,0 +1,273 @@
+package ssh
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/residwi/sshman/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	gossh "golang.org/x/crypto/ssh"
+)
+
+// testCA signs certificates for the public keys it is asked about
+type testCA struct {
+	t        *testing.T
+	signer   gossh.Signer
+	requests []CertRequest
+}
+
+func newTestCA(t *testing.T) *testCA {
+	return &testCA{t: t, signer: newTestSigner(t)}
+}
+
+func (ca *testCA) Issue(request CertRequest) ([]byte, error) {
+	ca.requests = append(ca.requests, request)
+
+	publicKey, _, _, _, err := gossh.ParseAuthorizedKey([]byte(request.PublicKey))
+	require.NoError(ca.t, err)
+
+	now := time.Now()
+	cert := &gossh.Certificate{
+		Key:             publicKey,
+		CertType:        gossh.UserCert,
+		KeyId:           request.Identity,
+		ValidPrincipals: request.Principals,
+		ValidAfter:      uint64(now.Add(-time.Minute).Unix()),
+		ValidBefore:     uint64(now.Add(8 * time.Hour).Unix()),
+	}
+	require.NoError(ca.t, cert.SignCert(rand.Reader, ca.signer))
+
+	return gossh.MarshalAuthorizedKey(cert), nil
+}
+
+func TestHTTPCertIssuer_Issue_JSON(t *testing.T) {
+	ca := newTestCA(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+
+		var request CertRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+		cert, _ := ca.Issue(request)
+
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		json.NewEncoder(w).Encode(map[string]string{"certificate": string(cert)})
+	}))
+	defer server.Close()
+
+	keyPath := filepath.Join(t.TempDir(), "id_ed25519_work")
+	writeTestPublicKey(t, keyPath)
+
+	info, err := IssueCert(NewHTTPCertIssuer(server.URL, "secret"), keyPath, CertRequest{Identity: "alice", Principals: []string{"alice"}, Validity: "+8h"})
+
+	require.NoError(t, err)
+	assert.Equal(t, "alice", info.KeyID)
+	assert.Equal(t, []string{"alice"}, info.Principals)
+	require.Len(t, ca.requests, 1)
+	assert.Equal(t, "user", ca.requests[0].Type)
+	assert.Equal(t, "+8h", ca.requests[0].Validity)
+	assert.FileExists(t, keyPath+CertSuffix)
+}
+
+func TestHTTPCertIssuer_Issue_Text(t *testing.T) {
+	ca := newTestCA(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Empty(t, r.Header.Get("Authorization"))
+
+		var request CertRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+		cert, _ := ca.Issue(request)
+		w.Write(cert)
+	}))
+	defer server.Close()
+
+	keyPath := filepath.Join(t.TempDir(), "id_ed25519_work")
+	writeTestPublicKey(t, keyPath)
+
+	info, err := IssueCert(NewHTTPCertIssuer(server.URL, ""), keyPath, CertRequest{Identity: "alice", Principals: []string{"alice"}})
+
+	require.NoError(t, err)
+	assert.Equal(t, "alice", info.KeyID)
+}
+
+func TestHTTPCertIssuer_Issue_ErrorStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "principal not allowed", http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	_, err := NewHTTPCertIssuer(server.URL, "").Issue(CertRequest{PublicKey: "ssh-ed25519 AAAA"})
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "403")
+	assert.Contains(t, err.Error(), "principal not allowed")
+}
+
+// wrongKeyIssuer returns a certificate for a different key than the one requested
+type wrongKeyIssuer struct {
+	ca *testCA
+}
+
+func (i wrongKeyIssuer) Issue(request CertRequest) ([]byte, error) {
+	request.PublicKey = string(gossh.MarshalAuthorizedKey(newTestPublicKey(i.ca.t)))
+	return i.ca.Issue(request)
+}
+
+func TestIssueCert_DifferentKey(t *testing.T) {
+	keyPath := filepath.Join(t.TempDir(), "id_ed25519_work")
+	writeTestPublicKey(t, keyPath)
+
+	_, err := IssueCert(wrongKeyIssuer{ca: newTestCA(t)}, keyPath, CertRequest{Identity: "alice"})
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "different public key")
+	assert.NoFileExists(t, keyPath+CertSuffix)
+}
+
+func TestIssueCert_NotACertificate(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("<html>login required</html>"))
+	}))
+	defer server.Close()
+
+	keyPath := filepath.Join(t.TempDir(), "id_ed25519_work")
+	writeTestPublicKey(t, keyPath)
+
+	_, err := IssueCert(NewHTTPCertIssuer(server.URL, ""), keyPath, CertRequest{Identity: "alice"})
+
+	assert.Error(t, err)
+	assert.NoFileExists(t, keyPath+CertSuffix)
+}
+
+func TestLocalCertIssuer_Issue(t *testing.T) {
+	caKeyPath := filepath.Join(t.TempDir(), "ca")
+	require.NoError(t, os.WriteFile(caKeyPath, []byte("ca"), 0600))
+
+	mockExecutor := mocks.NewMockCommandExecutor(t)
+	mockExecutor.EXPECT().ExecuteWithCombinedOutput("ssh-keygen", mock.Anything).RunAndReturn(func(name string, args ...string) ([]byte, error) {
+		assert.Equal(t, []string{"-s", caKeyPath, "-I", "alice", "-n", "alice", "-V", "+8h"}, args[:8])
+
+		publicKeyPath := args[len(args)-1]
+		return nil, os.WriteFile(strings.TrimSuffix(publicKeyPath, ".pub")+CertSuffix, []byte("certificate"), 0644)
+	})
+
+	content, err := NewLocalCertIssuer(NewCertSigner(mockExecutor), caKeyPath).Issue(CertRequest{
+		PublicKey:  "ssh-ed25519 AAAA",
+		Identity:   "alice",
+		Principals: []string{"alice"},
+		Validity:   "+8h",
+		Type:       "user",
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, "certificate", string(content))
+}
+
+func TestCertInfo_RenewRequest(t *testing.T) {
+	validAfter := time.Date(2026, 10, 19, 8, 31, 0, 0, time.UTC)
+	tests := []struct {
+		name        string
+		validAfter  time.Time
+		validBefore time.Time
+		expected    string
+	}{
+		{"skew_allowance_dropped", validAfter, validAfter.Add(8*time.Hour + 62*time.Second), "+8h"},
+		{"days", validAfter, validAfter.Add(48 * time.Hour), "+2d"},
+		{"minutes", validAfter, validAfter.Add(45 * time.Minute), "+45m"},
+		{"minutes_skew_allowance_dropped", validAfter, validAfter.Add(32 * time.Minute), "+30m"},
+		{"odd_minutes", validAfter, validAfter.Add(38 * time.Minute), "+38m"},
+		{"forever", validAfter, time.Time{}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info := CertInfo{KeyID: "alice", Principals: []string{"alice"}, Type: "user", ValidAfter: tt.validAfter, ValidBefore: tt.validBefore}
+
+			request, err := info.RenewRequest()
+
+			require.NoError(t, err)
+			assert.Equal(t, []string{"clear"}, request.Options)
+			assert.Equal(t, tt.expected, request.Validity)
+			assert.Equal(t, "alice", request.Identity)
+			assert.Equal(t, []string{"alice"}, request.Principals)
+			assert.Equal(t, "user", request.Type)
+		})
+	}
+}
+
+func TestCertInfo_RenewRequest_SourceAddress(t *testing.T) {
+	content, _ := newTestCert(t, &gossh.Certificate{
+		CertType:        gossh.UserCert,
+		KeyId:           "alice",
+		ValidPrincipals: []string{"alice"},
+		ValidBefore:     gossh.CertTimeInfinity,
+		Permissions: gossh.Permissions{
+			CriticalOptions: map[string]string{"source-address": "10.0.0.0/8", "force-command": "uptime"},
+			Extensions:      map[string]string{"permit-pty": "", "login@example.com": "alice"},
+		},
+	})
+	current, err := ParseCert("id_ed25519-cert.pub", content)
+	require.NoError(t, err)
+
+	request, err := current.RenewRequest()
+	require.NoError(t, err)
+
+	expectedOptions := []string{"clear", "force-command=uptime", "source-address=10.0.0.0/8", "extension:login@example.com=alice", "permit-pty"}
+	assert.Equal(t, expectedOptions, request.Options)
+
+	// the local issuer passes the options to ssh-keygen
+	caKeyPath := filepath.Join(t.TempDir(), "ca")
+	require.NoError(t, os.WriteFile(caKeyPath, []byte("ca"), 0600))
+	mockExecutor := mocks.NewMockCommandExecutor(t)
+	mockExecutor.EXPECT().ExecuteWithCombinedOutput("ssh-keygen", mock.Anything).RunAndReturn(func(name string, args ...string) ([]byte, error) {
+		assert.Equal(t, []string{
+			"-O", "clear", "-O", "force-command=uptime", "-O", "source-address=10.0.0.0/8",
+			"-O", "extension:login@example.com=alice", "-O", "permit-pty",
+		}, args[6:len(args)-1])
+
+		publicKeyPath := args[len(args)-1]
+		return nil, os.WriteFile(strings.TrimSuffix(publicKeyPath, ".pub")+CertSuffix, []byte("certificate"), 0644)
+	})
+	request.PublicKey = "ssh-ed25519 AAAA"
+	_, err = NewLocalCertIssuer(NewCertSigner(mockExecutor), caKeyPath).Issue(request)
+	require.NoError(t, err)
+
+	// and the HTTP issuer sends them to the signing service
+	var received CertRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+		w.Write([]byte("certificate"))
+	}))
+	defer server.Close()
+	_, err = NewHTTPCertIssuer(server.URL, "").Issue(request)
+	require.NoError(t, err)
+	assert.Equal(t, expectedOptions, received.Options)
+}
+
+func TestCertInfo_RenewRequest_UnreproducibleOptions(t *testing.T) {
+	tests := []struct {
+		name string
+		info CertInfo
+	}{
+		{"host_cert_with_extensions", CertInfo{Type: "host", Extensions: []string{"permit-pty"}}},
+		{"flag_with_contents", CertInfo{Type: "user", CriticalOptions: map[string]string{"verify-required": "yes"}}},
+		{"extension_name_with_equals", CertInfo{Type: "user", Extensions: []string{"a=b@example.com"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.info.RenewRequest()
+
+			assert.ErrorContains(t, err, "cannot reproduce")
+		})
+	}
+}
@@ -2,18 +2,28 @@ package ssh
 
 import (
 	"fmt"
+	"os"
 	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/residwi/sshman/internal/interfaces"
 	"github.com/residwi/sshman/utils"
 )
 
 type KeyConfig struct {
+	Name     string
 	Type     string
 	Email    string
 	Purpose  string
 	Provider string
 	SSHPath  string
+	// Bits is the size of RSA keys, 4096 when not set
+	Bits int
+	// NoPassphrase creates the key unencrypted, otherwise ssh-keygen prompts for the passphrase
+	NoPassphrase bool
 }
 
 type KeyGenerator struct {
@@ -27,7 +37,10 @@ func NewKeyGenerator(executor interfaces.CommandExecutor) *KeyGenerator {
 }
 
 func (kg *KeyGenerator) GenerateKey(config KeyConfig) (string, error) {
-	keyName := generateKeyName(config.Type, config.Purpose)
+	keyName := config.Name
+	if keyName == "" {
+		keyName = GenerateKeyName(config.Type, config.Purpose)
+	}
 	filePath := filepath.Join(config.SSHPath, keyName)
 
 	if !utils.IsFileNotExist(filePath) {
@@ -38,11 +51,20 @@ func (kg *KeyGenerator) GenerateKey(config KeyConfig) (string, error) {
 	keygenArgs = append(keygenArgs, "-t", config.Type, "-f", filePath)
 
 	if config.Type == "rsa" {
-		keygenArgs = append(keygenArgs, "-b", "4096")
+		bits := config.Bits
+		if bits == 0 {
+			bits = 4096
+		}
+		keygenArgs = append(keygenArgs, "-b", strconv.Itoa(bits))
 	}
 
 	keygenArgs = append(keygenArgs, "-C", config.Email)
 
+	// a passphrase on the command line would be visible to other users in ps
+	if config.NoPassphrase {
+		keygenArgs = append(keygenArgs, "-N", "")
+	}
+
 	if err := kg.executor.Execute("ssh-keygen", keygenArgs...); err != nil {
 		return "", fmt.Errorf("failed to create SSH key: %w", err)
 	}
@@ -50,10 +72,61 @@ func (kg *KeyGenerator) GenerateKey(config KeyConfig) (string, error) {
 	return keyName, nil
 }
 
-func generateKeyName(keyType, purpose string) string {
+func GenerateKeyName(keyType, purpose string) string {
 	keyName := "id_" + keyType
 	if purpose != "" {
 		keyName += "_" + purpose
 	}
 	return keyName
 }
+
+// RotatedKeyName returns the name of the key replacing the one with the given type and purpose
+func RotatedKeyName(keyType, purpose string, now time.Time) string {
+	return GenerateKeyName(keyType, purpose) + "_" + now.Format("20060102")
+}
+
+// DerivePublicKey writes the .pub file of a private key, asking for its passphrase when it is encrypted
+func (kg *KeyGenerator) DerivePublicKey(privateKeyPath string) error {
+	output, err := kg.executor.ExecuteWithOutput("ssh-keygen", "-y", "-f", privateKeyPath)
+	if err != nil {
+		return fmt.Errorf("failed to derive public key: %w", err)
+	}
+
+	if err := os.WriteFile(privateKeyPath+".pub", output, 0644); err != nil {
+		return fmt.Errorf("failed to write public key: %w", err)
+	}
+
+	return nil
+}
+
+// PublicKeyFormats lists the formats a public key can be converted to, openssh being the .pub file itself
+var PublicKeyFormats = []string{"openssh", "rfc4716", "pkcs8", "pem"}
+
+// ConvertPublicKey returns a public key in the given format
+func (kg *KeyGenerator) ConvertPublicKey(publicKeyPath, format string) ([]byte, error) {
+	if !slices.Contains(PublicKeyFormats, format) {
+		return nil, fmt.Errorf("unsupported public key format: %s. Supported formats: %s", format, strings.Join(PublicKeyFormats, ", "))
+	}
+
+	content, err := os.ReadFile(publicKeyPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read public key: %w", err)
+	}
+
+	switch format {
+	case "openssh":
+		return content, nil
+	case "pkcs8", "pem":
+		// ssh-keygen only exports RSA and ECDSA keys in these formats
+		if keyType := KeyTypeFromPublicKey(string(content)); keyType != "rsa" && keyType != "ecdsa" {
+			return nil, fmt.Errorf("%s keys cannot be converted to %s, only rsa and ecdsa keys can", keyType, format)
+		}
+	}
+
+	output, err := kg.executor.ExecuteWithOutput("ssh-keygen", "-e", "-m", strings.ToUpper(format), "-f", publicKeyPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert public key to %s: %w", format, err)
+	}
+
+	return output, nil
+}
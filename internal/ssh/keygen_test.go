@@ -5,6 +5,7 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/residwi/sshman/mocks"
 	"github.com/stretchr/testify/assert"
@@ -28,7 +29,7 @@ func TestGenerateKeyName(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := generateKeyName(tt.keyType, tt.purpose)
+			result := GenerateKeyName(tt.keyType, tt.purpose)
 			assert.Equal(t, tt.expected, result)
 		})
 	}
@@ -80,6 +81,29 @@ func TestKeyGenerator_GenerateKey_RSA_WithoutPurpose_Success(t *testing.T) {
 	mockExecutor.AssertExpectations(t)
 }
 
+func TestKeyGenerator_GenerateKey_RSA_CustomBits_Success(t *testing.T) {
+	tempDir := t.TempDir()
+	mockExecutor := &mocks.MockCommandExecutor{}
+
+	expectedArgs := []string{"-t", "rsa", "-f", filepath.Join(tempDir, "id_rsa_work"), "-b", "8192", "-C", "rsa@example.com"}
+	mockExecutor.On("Execute", "ssh-keygen", expectedArgs).Return(nil)
+
+	keyGen := NewKeyGenerator(mockExecutor)
+
+	config := KeyConfig{
+		Type:    "rsa",
+		Email:   "rsa@example.com",
+		Purpose: "work",
+		SSHPath: tempDir,
+		Bits:    8192,
+	}
+
+	_, err := keyGen.GenerateKey(config)
+
+	assert.NoError(t, err)
+	mockExecutor.AssertExpectations(t)
+}
+
 func TestKeyGenerator_GenerateKey_KeyAlreadyExists_Error(t *testing.T) {
 	tempDir := t.TempDir()
 	mockExecutor := &mocks.MockCommandExecutor{}
@@ -127,3 +151,126 @@ func TestKeyGenerator_GenerateKey_SSHKeygenFails_Error(t *testing.T) {
 	assert.Contains(t, err.Error(), "ssh-keygen failed")
 	mockExecutor.AssertExpectations(t)
 }
+
+func TestKeyGenerator_GenerateKey_WithName_Success(t *testing.T) {
+	tempDir := t.TempDir()
+	mockExecutor := mocks.NewMockCommandExecutor(t)
+
+	expectedArgs := []string{"-t", "ed25519", "-f", filepath.Join(tempDir, "id_ed25519_work_20250101"), "-C", "test@example.com"}
+	mockExecutor.EXPECT().Execute("ssh-keygen", expectedArgs).Return(nil)
+
+	keyGen := NewKeyGenerator(mockExecutor)
+
+	config := KeyConfig{
+		Name:    "id_ed25519_work_20250101",
+		Type:    "ed25519",
+		Email:   "test@example.com",
+		Purpose: "work",
+		SSHPath: tempDir,
+	}
+
+	keyName, err := keyGen.GenerateKey(config)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "id_ed25519_work_20250101", keyName)
+}
+
+func TestRotatedKeyName(t *testing.T) {
+	now := time.Date(2025, 3, 14, 0, 0, 0, 0, time.UTC)
+
+	assert.Equal(t, "id_ed25519_work_20250314", RotatedKeyName("ed25519", "work", now))
+	assert.Equal(t, "id_rsa_20250314", RotatedKeyName("rsa", "", now))
+}
+
+func TestKeyGenerator_DerivePublicKey_Success(t *testing.T) {
+	keyPath := filepath.Join(t.TempDir(), "id_ed25519")
+	mockExecutor := mocks.NewMockCommandExecutor(t)
+	mockExecutor.EXPECT().ExecuteWithOutput("ssh-keygen", []string{"-y", "-f", keyPath}).Return([]byte("ssh-ed25519 AAAA test@example.com\n"), nil)
+
+	err := NewKeyGenerator(mockExecutor).DerivePublicKey(keyPath)
+	require.NoError(t, err)
+
+	content, err := os.ReadFile(keyPath + ".pub")
+	require.NoError(t, err)
+	assert.Equal(t, "ssh-ed25519 AAAA test@example.com\n", string(content))
+}
+
+func TestKeyGenerator_DerivePublicKey_Error(t *testing.T) {
+	mockExecutor := mocks.NewMockCommandExecutor(t)
+	mockExecutor.EXPECT().ExecuteWithOutput("ssh-keygen", []string{"-y", "-f", "/keys/id_ed25519"}).Return(nil, fmt.Errorf("incorrect passphrase"))
+
+	err := NewKeyGenerator(mockExecutor).DerivePublicKey("/keys/id_ed25519")
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to derive public key")
+}
+
+func TestKeyGenerator_GenerateKey_NoPassphrase_Success(t *testing.T) {
+	tempDir := t.TempDir()
+	mockExecutor := mocks.NewMockCommandExecutor(t)
+
+	expectedArgs := []string{"-t", "ed25519", "-f", filepath.Join(tempDir, "id_ed25519_work"), "-C", "test@example.com", "-N", ""}
+	mockExecutor.EXPECT().Execute("ssh-keygen", expectedArgs).Return(nil)
+
+	keyName, err := NewKeyGenerator(mockExecutor).GenerateKey(KeyConfig{
+		Type:         "ed25519",
+		Email:        "test@example.com",
+		Purpose:      "work",
+		SSHPath:      tempDir,
+		NoPassphrase: true,
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "id_ed25519_work", keyName)
+}
+
+func TestKeyGenerator_ConvertPublicKey_OpenSSH(t *testing.T) {
+	publicKeyPath := filepath.Join(t.TempDir(), "id_ed25519.pub")
+	require.NoError(t, os.WriteFile(publicKeyPath, []byte("ssh-ed25519 AAAA test@example.com\n"), 0644))
+
+	output, err := NewKeyGenerator(mocks.NewMockCommandExecutor(t)).ConvertPublicKey(publicKeyPath, "openssh")
+
+	require.NoError(t, err)
+	assert.Equal(t, "ssh-ed25519 AAAA test@example.com\n", string(output))
+}
+
+func TestKeyGenerator_ConvertPublicKey_RFC4716(t *testing.T) {
+	publicKeyPath := filepath.Join(t.TempDir(), "id_ed25519.pub")
+	require.NoError(t, os.WriteFile(publicKeyPath, []byte("ssh-ed25519 AAAA test@example.com\n"), 0644))
+	mockExecutor := mocks.NewMockCommandExecutor(t)
+	mockExecutor.EXPECT().ExecuteWithOutput("ssh-keygen", []string{"-e", "-m", "RFC4716", "-f", publicKeyPath}).Return([]byte("---- BEGIN SSH2 PUBLIC KEY ----\n"), nil)
+
+	output, err := NewKeyGenerator(mockExecutor).ConvertPublicKey(publicKeyPath, "rfc4716")
+
+	require.NoError(t, err)
+	assert.Equal(t, "---- BEGIN SSH2 PUBLIC KEY ----\n", string(output))
+}
+
+func TestKeyGenerator_ConvertPublicKey_Error(t *testing.T) {
+	publicKeyPath := filepath.Join(t.TempDir(), "id_rsa.pub")
+	require.NoError(t, os.WriteFile(publicKeyPath, []byte("ssh-rsa AAAA test@example.com\n"), 0644))
+	mockExecutor := mocks.NewMockCommandExecutor(t)
+	mockExecutor.EXPECT().ExecuteWithOutput("ssh-keygen", []string{"-e", "-m", "PEM", "-f", publicKeyPath}).Return(nil, fmt.Errorf("exit status 255"))
+
+	_, err := NewKeyGenerator(mockExecutor).ConvertPublicKey(publicKeyPath, "pem")
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to convert public key to pem")
+}
+
+func TestKeyGenerator_ConvertPublicKey_PKCS8_ED25519(t *testing.T) {
+	publicKeyPath := filepath.Join(t.TempDir(), "id_ed25519.pub")
+	require.NoError(t, os.WriteFile(publicKeyPath, []byte("ssh-ed25519 AAAA test@example.com\n"), 0644))
+
+	_, err := NewKeyGenerator(mocks.NewMockCommandExecutor(t)).ConvertPublicKey(publicKeyPath, "pkcs8")
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "ed25519 keys cannot be converted to pkcs8")
+}
+
+func TestKeyGenerator_ConvertPublicKey_UnsupportedFormat(t *testing.T) {
+	_, err := NewKeyGenerator(mocks.NewMockCommandExecutor(t)).ConvertPublicKey("/keys/id_ed25519.pub", "x509")
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "unsupported public key format")
+}
@@ -0,0 +1,137 @@
+package ssh
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/residwi/sshman/utils"
+)
+
+const ArchiveDirName = ".sshman-archive"
+
+// ReadPublicKeyComment returns the comment of a public key, which holds the email the key was created with
+func ReadPublicKeyComment(publicKeyPath string) (string, error) {
+	content, err := os.ReadFile(publicKeyPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read public key: %w", err)
+	}
+
+	fields := strings.Fields(string(content))
+	if len(fields) < 2 {
+		return "", fmt.Errorf("invalid public key: %s", publicKeyPath)
+	}
+
+	return strings.Join(fields[2:], " "), nil
+}
+
+// KeyTypeFromPublicKey maps the algorithm of a public key to the key type passed to ssh-keygen -t
+func KeyTypeFromPublicKey(publicKey string) string {
+	fields := strings.Fields(publicKey)
+	if len(fields) == 0 {
+		return ""
+	}
+
+	switch algorithm := fields[0]; {
+	case algorithm == "ssh-ed25519":
+		return "ed25519"
+	case algorithm == "ssh-rsa":
+		return "rsa"
+	case algorithm == "ssh-dss":
+		return "dsa"
+	case strings.HasPrefix(algorithm, "ecdsa-"):
+		return "ecdsa"
+	case strings.HasPrefix(algorithm, "sk-ssh-ed25519"):
+		return "ed25519-sk"
+	case strings.HasPrefix(algorithm, "sk-ecdsa"):
+		return "ecdsa-sk"
+	default:
+		return algorithm
+	}
+}
+
+// ArchiveKey moves a key pair, and its certificate if there is one, into the archive
+// directory instead of deleting it, so a rotated key can still be recovered. Files
+// already moved are put back when a later move fails.
+func ArchiveKey(sshPath, keyName string) (string, error) {
+	archiveDir := filepath.Join(sshPath, ArchiveDirName)
+	if err := os.MkdirAll(archiveDir, 0700); err != nil {
+		return "", fmt.Errorf("failed to create archive directory: %w", err)
+	}
+
+	archivedPath := filepath.Join(archiveDir, keyName+"."+time.Now().Format("20060102150405"))
+
+	var moved []string
+	for _, suffix := range []string{"", ".pub", CertSuffix} {
+		keyPath := filepath.Join(sshPath, keyName+suffix)
+		if suffix != "" && utils.IsFileNotExist(keyPath) {
+			continue
+		}
+
+		if err := os.Rename(keyPath, archivedPath+suffix); err != nil {
+			for _, movedSuffix := range moved {
+				os.Rename(archivedPath+movedSuffix, filepath.Join(sshPath, keyName+movedSuffix))
+			}
+			return "", fmt.Errorf("failed to archive SSH key [%s]: %w", keyName+suffix, err)
+		}
+		moved = append(moved, suffix)
+	}
+
+	return archivedPath, nil
+}
+
+// UnarchiveKey moves a key pair archived by ArchiveKey back to its name
+func UnarchiveKey(sshPath, keyName, archivedPath string) error {
+	for _, suffix := range []string{"", ".pub", CertSuffix} {
+		if suffix != "" && utils.IsFileNotExist(archivedPath+suffix) {
+			continue
+		}
+
+		if err := os.Rename(archivedPath+suffix, filepath.Join(sshPath, keyName+suffix)); err != nil {
+			return fmt.Errorf("failed to restore archived SSH key [%s]: %w", keyName+suffix, err)
+		}
+	}
+
+	return nil
+}
+
+// RenameKey moves a key pair, and its certificate if there is one, to a new name.
+// Files already moved are put back when a later move fails.
+func RenameKey(sshPath, oldName, newName string) error {
+	if !filepath.IsLocal(newName) {
+		return fmt.Errorf("invalid key name: %s", newName)
+	}
+
+	if utils.IsFileNotExist(filepath.Join(sshPath, oldName)) {
+		return fmt.Errorf("SSH key [%s] does not exist", oldName)
+	}
+
+	if !utils.IsFileNotExist(filepath.Join(sshPath, newName)) {
+		return fmt.Errorf("SSH key [%s] already exists", newName)
+	}
+
+	var moved []string
+	for _, suffix := range []string{"", ".pub", "-cert.pub"} {
+		oldPath := filepath.Join(sshPath, oldName+suffix)
+		if suffix != "" && utils.IsFileNotExist(oldPath) {
+			continue
+		}
+
+		if err := os.Rename(oldPath, filepath.Join(sshPath, newName+suffix)); err != nil {
+			for _, movedSuffix := range moved {
+				os.Rename(filepath.Join(sshPath, newName+movedSuffix), filepath.Join(sshPath, oldName+movedSuffix))
+			}
+			return fmt.Errorf("failed to rename SSH key [%s]: %w", oldName+suffix, err)
+		}
+		moved = append(moved, suffix)
+	}
+
+	return nil
+}
+
+// IsSSHManDir reports whether a directory holds sshman's own files, such as archived keys
+func IsSSHManDir(name string) bool {
+	return strings.HasPrefix(name, ".sshman")
+}
@@ -0,0 +1,182 @@
+package ssh
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadPublicKeyComment(t *testing.T) {
+	tests := []struct {
+		name        string
+		content     string
+		expected    string
+		expectError bool
+	}{
+		{"with_email", "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAI test@example.com\n", "test@example.com", false},
+		{"with_spaces", "ssh-rsa AAAAB3NzaC1yc2E John Doe <john@example.com>", "John Doe <john@example.com>", false},
+		{"without_comment", "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAI", "", false},
+		{"invalid_key", "garbage", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			publicKeyPath := filepath.Join(t.TempDir(), "id_ed25519.pub")
+			require.NoError(t, os.WriteFile(publicKeyPath, []byte(tt.content), 0644))
+
+			comment, err := ReadPublicKeyComment(publicKeyPath)
+			if tt.expectError {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, comment)
+		})
+	}
+}
+
+func TestReadPublicKeyComment_NotExists_Error(t *testing.T) {
+	_, err := ReadPublicKeyComment(filepath.Join(t.TempDir(), "missing.pub"))
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to read public key")
+}
+
+func TestArchiveKey_Success(t *testing.T) {
+	tempDir := t.TempDir()
+
+	keyPath := filepath.Join(tempDir, "id_ed25519_work")
+	require.NoError(t, os.WriteFile(keyPath, []byte("private"), 0600))
+	require.NoError(t, os.WriteFile(keyPath+".pub", []byte("public"), 0644))
+	require.NoError(t, os.WriteFile(keyPath+CertSuffix, []byte("certificate"), 0644))
+
+	archivedPath, err := ArchiveKey(tempDir, "id_ed25519_work")
+	require.NoError(t, err)
+
+	assert.Equal(t, filepath.Join(tempDir, ArchiveDirName), filepath.Dir(archivedPath))
+	assert.NoFileExists(t, keyPath)
+	assert.NoFileExists(t, keyPath+".pub")
+	assert.NoFileExists(t, keyPath+CertSuffix)
+	assert.FileExists(t, archivedPath)
+	assert.FileExists(t, archivedPath+".pub")
+	assert.FileExists(t, archivedPath+CertSuffix)
+}
+
+func TestArchiveKey_WithoutPublicKey_Success(t *testing.T) {
+	tempDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, "id_rsa"), []byte("private"), 0600))
+
+	archivedPath, err := ArchiveKey(tempDir, "id_rsa")
+
+	assert.NoError(t, err)
+	assert.FileExists(t, archivedPath)
+}
+
+func TestArchiveKey_KeyNotExists_Error(t *testing.T) {
+	_, err := ArchiveKey(t.TempDir(), "missing")
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to archive SSH key [missing]")
+}
+
+func TestUnarchiveKey_Success(t *testing.T) {
+	tempDir := t.TempDir()
+
+	keyPath := filepath.Join(tempDir, "id_ed25519_work")
+	require.NoError(t, os.WriteFile(keyPath, []byte("private"), 0600))
+	require.NoError(t, os.WriteFile(keyPath+".pub", []byte("public"), 0644))
+	require.NoError(t, os.WriteFile(keyPath+CertSuffix, []byte("certificate"), 0644))
+
+	archivedPath, err := ArchiveKey(tempDir, "id_ed25519_work")
+	require.NoError(t, err)
+
+	require.NoError(t, UnarchiveKey(tempDir, "id_ed25519_work", archivedPath))
+
+	assert.FileExists(t, keyPath)
+	assert.FileExists(t, keyPath+".pub")
+	assert.FileExists(t, keyPath+CertSuffix)
+	assert.NoFileExists(t, archivedPath)
+}
+
+func TestIsSSHManDir(t *testing.T) {
+	assert.True(t, IsSSHManDir(ArchiveDirName))
+	assert.True(t, IsSSHManDir(".sshman-trash"))
+	assert.False(t, IsSSHManDir("keys"))
+	assert.False(t, IsSSHManDir(".ssh"))
+}
+
+func TestKeyTypeFromPublicKey(t *testing.T) {
+	tests := []struct {
+		publicKey string
+		expected  string
+	}{
+		{"ssh-ed25519 AAAAC3 test@example.com", "ed25519"},
+		{"ssh-rsa AAAAB3", "rsa"},
+		{"ecdsa-sha2-nistp256 AAAAE2", "ecdsa"},
+		{"sk-ssh-ed25519@openssh.com AAAA", "ed25519-sk"},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expected, func(t *testing.T) {
+			assert.Equal(t, tt.expected, KeyTypeFromPublicKey(tt.publicKey))
+		})
+	}
+}
+
+func TestRenameKey_Success(t *testing.T) {
+	tempDir := t.TempDir()
+	for _, name := range []string{"id_old", "id_old.pub", "id_old-cert.pub"} {
+		require.NoError(t, os.WriteFile(filepath.Join(tempDir, name), []byte(name), 0600))
+	}
+
+	err := RenameKey(tempDir, "id_old", "id_new")
+	require.NoError(t, err)
+
+	for _, name := range []string{"id_new", "id_new.pub", "id_new-cert.pub"} {
+		content, err := os.ReadFile(filepath.Join(tempDir, name))
+		require.NoError(t, err)
+		assert.Equal(t, strings.Replace(name, "new", "old", 1), string(content))
+	}
+	assert.NoFileExists(t, filepath.Join(tempDir, "id_old"))
+}
+
+func TestRenameKey_WithoutPublicKey_Success(t *testing.T) {
+	tempDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, "id_old"), []byte("private"), 0600))
+
+	err := RenameKey(tempDir, "id_old", "id_new")
+
+	assert.NoError(t, err)
+	assert.FileExists(t, filepath.Join(tempDir, "id_new"))
+}
+
+func TestRenameKey_Errors(t *testing.T) {
+	tempDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, "id_old"), []byte("private"), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, "id_taken"), []byte("private"), 0600))
+
+	tests := []struct {
+		name     string
+		oldName  string
+		newName  string
+		expected string
+	}{
+		{"missing_key", "id_missing", "id_new", "does not exist"},
+		{"existing_target", "id_old", "id_taken", "already exists"},
+		{"outside_ssh_path", "id_old", "../id_new", "invalid key name"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := RenameKey(tempDir, tt.oldName, tt.newName)
+			assert.Error(t, err)
+			assert.Contains(t, err.Error(), tt.expected)
+		})
+	}
+}
@@ -0,0 +1,247 @@
+package ssh
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/residwi/sshman/internal/interfaces"
+	"github.com/residwi/sshman/utils"
+	gossh "golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+const KnownHostsFileName = "known_hosts"
+
+// KnownHost is an entry of the known_hosts file
+type KnownHost struct {
+	Marker      string
+	Hosts       []string
+	Type        string
+	Fingerprint string
+	Line        string
+}
+
+// IsHashed reports whether the hostnames of the entry are hashed, as ssh does with HashKnownHosts
+func (h KnownHost) IsHashed() bool {
+	return slices.ContainsFunc(h.Hosts, isHashedHost)
+}
+
+// Matches reports whether the entry is for the hostname, comparing hashed hostnames by their hash
+func (h KnownHost) Matches(hostname string) bool {
+	hostname = knownhosts.Normalize(hostname)
+	for _, host := range h.Hosts {
+		if isHashedHost(host) {
+			if matchHashedHost(host, hostname) {
+				return true
+			}
+		} else if strings.EqualFold(host, hostname) {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseKnownHosts parses the entries of known_hosts content, skipping comments and invalid lines
+func ParseKnownHosts(content []byte) []KnownHost {
+	var hosts []KnownHost
+	for line := range strings.SplitSeq(string(content), "\n") {
+		if host, ok := parseKnownHostLine(line); ok {
+			hosts = append(hosts, host)
+		}
+	}
+	return hosts
+}
+
+func ReadKnownHosts(sshPath string) ([]KnownHost, error) {
+	content, err := readKnownHostsFile(sshPath)
+	if err != nil {
+		return nil, err
+	}
+	return ParseKnownHosts(content), nil
+}
+
+// RemoveKnownHost removes the entries for the hostname, like ssh-keygen -R
+func RemoveKnownHost(sshPath, hostname string) ([]KnownHost, error) {
+	content, err := readKnownHostsFile(sshPath)
+	if err != nil {
+		return nil, err
+	}
+
+	removed, remaining := splitKnownHosts(content, hostname)
+	if len(removed) == 0 {
+		return nil, nil
+	}
+
+	if err := writeKnownHostsFile(sshPath, remaining); err != nil {
+		return nil, err
+	}
+
+	return removed, nil
+}
+
+// PinHostKeys replaces the entries for the hostname with the scanned host keys, after checking
+// every scanned key against the published fingerprints. It returns the pinned entries, or none
+// when exactly these keys were already known.
+func PinHostKeys(sshPath, hostname string, scanned []KnownHost, fingerprints []string) ([]KnownHost, error) {
+	if len(scanned) == 0 {
+		return nil, fmt.Errorf("no host keys found for %s", hostname)
+	}
+
+	for _, host := range scanned {
+		if !slices.Contains(fingerprints, host.Fingerprint) {
+			return nil, fmt.Errorf("host key %s of %s does not match any published fingerprint, it was not pinned", host.Fingerprint, hostname)
+		}
+	}
+
+	content, err := readKnownHostsFile(sshPath)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, remaining := splitKnownHosts(content, hostname)
+	if isSameHostKeys(existing, scanned) {
+		return nil, nil
+	}
+
+	if remaining != "" && !strings.HasSuffix(remaining, "\n") {
+		remaining += "\n"
+	}
+	for _, host := range scanned {
+		remaining += host.Line + "\n"
+	}
+
+	if err := writeKnownHostsFile(sshPath, remaining); err != nil {
+		return nil, err
+	}
+
+	return scanned, nil
+}
+
+// HostKeyScanner fetches the host keys a server offers with ssh-keyscan
+type HostKeyScanner struct {
+	executor interfaces.CommandExecutor
+}
+
+func NewHostKeyScanner(executor interfaces.CommandExecutor) *HostKeyScanner {
+	return &HostKeyScanner{
+		executor: executor,
+	}
+}
+
+func (s *HostKeyScanner) Scan(hostname string) ([]KnownHost, error) {
+	output, err := s.executor.ExecuteWithOutput("ssh-keyscan", "-T", "10", "-t", "ed25519,ecdsa,rsa", hostname)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan host keys of %s: %w", hostname, err)
+	}
+
+	var hosts []KnownHost
+	for line := range strings.SplitSeq(string(output), "\n") {
+		_, _, publicKey, _, _, err := gossh.ParseKnownHosts([]byte(line))
+		if err != nil {
+			continue
+		}
+
+		// written the way ssh looks the hostname up, which differs from ssh-keyscan for non-standard ports
+		if host, ok := parseKnownHostLine(knownhosts.Line([]string{hostname}, publicKey)); ok {
+			hosts = append(hosts, host)
+		}
+	}
+
+	return hosts, nil
+}
+
+func parseKnownHostLine(line string) (KnownHost, bool) {
+	line = strings.TrimSpace(line)
+	if line == "" || strings.HasPrefix(line, "#") {
+		return KnownHost{}, false
+	}
+
+	marker, hosts, publicKey, _, _, err := gossh.ParseKnownHosts([]byte(line))
+	if err != nil {
+		return KnownHost{}, false
+	}
+
+	return KnownHost{
+		Marker:      marker,
+		Hosts:       hosts,
+		Type:        KeyTypeFromPublicKey(publicKey.Type()),
+		Fingerprint: gossh.FingerprintSHA256(publicKey),
+		Line:        line,
+	}, true
+}
+
+// splitKnownHosts separates the entries for the hostname from the rest of the content
+func splitKnownHosts(content []byte, hostname string) ([]KnownHost, string) {
+	var matches []KnownHost
+	var remaining []string
+	for line := range strings.SplitSeq(string(content), "\n") {
+		if host, ok := parseKnownHostLine(line); ok && host.Marker == "" && host.Matches(hostname) {
+			matches = append(matches, host)
+			continue
+		}
+		remaining = append(remaining, line)
+	}
+	return matches, strings.Join(remaining, "\n")
+}
+
+func isSameHostKeys(a, b []KnownHost) bool {
+	fingerprints := func(hosts []KnownHost) []string {
+		var result []string
+		for _, host := range hosts {
+			result = append(result, host.Fingerprint)
+		}
+		slices.Sort(result)
+		return slices.Compact(result)
+	}
+	return len(a) == len(b) && slices.Equal(fingerprints(a), fingerprints(b))
+}
+
+func isHashedHost(host string) bool {
+	return strings.HasPrefix(host, "|1|")
+}
+
+// matchHashedHost compares a hostname with a |1|salt|hash entry, where hash is the
+// HMAC-SHA1 of the hostname keyed with salt
+func matchHashedHost(hashed, hostname string) bool {
+	parts := strings.Split(hashed, "|")
+	if len(parts) != 4 {
+		return false
+	}
+
+	salt, err := base64.StdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return false
+	}
+	hash, err := base64.StdEncoding.DecodeString(parts[3])
+	if err != nil {
+		return false
+	}
+
+	mac := hmac.New(sha1.New, salt)
+	mac.Write([]byte(hostname))
+	return hmac.Equal(mac.Sum(nil), hash)
+}
+
+func readKnownHostsFile(sshPath string) ([]byte, error) {
+	content, err := os.ReadFile(filepath.Join(sshPath, KnownHostsFileName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read known_hosts: %w", err)
+	}
+	return content, nil
+}
+
+func writeKnownHostsFile(sshPath, content string) error {
+	if err := utils.WriteFileAtomic(filepath.Join(sshPath, KnownHostsFileName), []byte(content), 0644); err != nil {
+		return fmt.Errorf("failed to write known_hosts: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+package ssh
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/residwi/sshman/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	gossh "golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+func TestParseKnownHosts(t *testing.T) {
+	githubKey, serverKey, caKey := newTestPublicKey(t), newTestPublicKey(t), newTestPublicKey(t)
+	content := "# comment\n" +
+		knownhosts.Line([]string{"github.com"}, githubKey) + "\n" +
+		knownhosts.HashHostname("server.example.com") + " " + knownhosts.Line([]string{"x"}, serverKey)[2:] + "\n" +
+		"@cert-authority *.example.com " + knownhosts.Line([]string{"x"}, caKey)[2:] + "\n" +
+		"invalid line\n"
+
+	hosts := ParseKnownHosts([]byte(content))
+
+	require.Len(t, hosts, 3)
+	assert.Equal(t, []string{"github.com"}, hosts[0].Hosts)
+	assert.Equal(t, "ed25519", hosts[0].Type)
+	assert.Equal(t, gossh.FingerprintSHA256(githubKey), hosts[0].Fingerprint)
+	assert.False(t, hosts[0].IsHashed())
+
+	assert.True(t, hosts[1].IsHashed())
+	assert.True(t, hosts[1].Matches("server.example.com"))
+	assert.False(t, hosts[1].Matches("other.example.com"))
+
+	assert.Equal(t, "cert-authority", hosts[2].Marker)
+}
+
+func TestKnownHost_Matches_Port(t *testing.T) {
+	host, ok := parseKnownHostLine(knownhosts.Line([]string{"server.example.com:2222"}, newTestPublicKey(t)))
+	require.True(t, ok)
+
+	assert.True(t, host.Matches("server.example.com:2222"))
+	assert.False(t, host.Matches("server.example.com"))
+}
+
+func TestRemoveKnownHost(t *testing.T) {
+	sshPath := t.TempDir()
+	kept := knownhosts.Line([]string{"gitlab.com"}, newTestPublicKey(t))
+	content := "# comment\n" +
+		knownhosts.Line([]string{"github.com"}, newTestPublicKey(t)) + "\n" +
+		kept + "\n" +
+		knownhosts.HashHostname("github.com") + " " + knownhosts.Line([]string{"x"}, newTestPublicKey(t))[2:] + "\n"
+	require.NoError(t, os.WriteFile(filepath.Join(sshPath, KnownHostsFileName), []byte(content), 0644))
+
+	removed, err := RemoveKnownHost(sshPath, "github.com")
+
+	require.NoError(t, err)
+	assert.Len(t, removed, 2)
+
+	result, err := os.ReadFile(filepath.Join(sshPath, KnownHostsFileName))
+	require.NoError(t, err)
+	assert.Equal(t, "# comment\n"+kept+"\n", string(result))
+
+	removed, err = RemoveKnownHost(sshPath, "github.com")
+	require.NoError(t, err)
+	assert.Empty(t, removed)
+}
+
+func TestHostKeyScanner_Scan(t *testing.T) {
+	hostKey := newTestPublicKey(t)
+	output := "# github.com:22 SSH-2.0-babeld\n" + knownhosts.Line([]string{"github.com"}, hostKey) + "\n"
+	mockExecutor := mocks.NewMockCommandExecutor(t)
+	mockExecutor.EXPECT().ExecuteWithOutput("ssh-keyscan", []string{"-T", "10", "-t", "ed25519,ecdsa,rsa", "github.com"}).Return([]byte(output), nil)
+
+	hosts, err := NewHostKeyScanner(mockExecutor).Scan("github.com")
+
+	require.NoError(t, err)
+	require.Len(t, hosts, 1)
+	assert.Equal(t, []string{"github.com"}, hosts[0].Hosts)
+	assert.Equal(t, gossh.FingerprintSHA256(hostKey), hosts[0].Fingerprint)
+}
+
+func TestHostKeyScanner_Scan_Error(t *testing.T) {
+	mockExecutor := mocks.NewMockCommandExecutor(t)
+	mockExecutor.EXPECT().ExecuteWithOutput("ssh-keyscan", []string{"-T", "10", "-t", "ed25519,ecdsa,rsa", "github.com"}).Return(nil, fmt.Errorf("exit status 1"))
+
+	_, err := NewHostKeyScanner(mockExecutor).Scan("github.com")
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to scan host keys of github.com")
+}
+
+func TestPinHostKeys(t *testing.T) {
+	sshPath := t.TempDir()
+	hostKey := newTestPublicKey(t)
+	other := knownhosts.Line([]string{"gitlab.com"}, newTestPublicKey(t))
+	stale := knownhosts.Line([]string{"github.com"}, newTestPublicKey(t))
+	require.NoError(t, os.WriteFile(filepath.Join(sshPath, KnownHostsFileName), []byte(other+"\n"+stale+"\n"), 0644))
+
+	scanned, ok := parseKnownHostLine(knownhosts.Line([]string{"github.com"}, hostKey))
+	require.True(t, ok)
+
+	pinned, err := PinHostKeys(sshPath, "github.com", []KnownHost{scanned}, []string{gossh.FingerprintSHA256(hostKey)})
+
+	require.NoError(t, err)
+	assert.Len(t, pinned, 1)
+
+	content, err := os.ReadFile(filepath.Join(sshPath, KnownHostsFileName))
+	require.NoError(t, err)
+	assert.Equal(t, other+"\n"+scanned.Line+"\n", string(content))
+
+	pinned, err = PinHostKeys(sshPath, "github.com", []KnownHost{scanned}, []string{gossh.FingerprintSHA256(hostKey)})
+	require.NoError(t, err)
+	assert.Empty(t, pinned)
+}
+
+func TestPinHostKeys_FingerprintMismatch(t *testing.T) {
+	sshPath := t.TempDir()
+	scanned, ok := parseKnownHostLine(knownhosts.Line([]string{"github.com"}, newTestPublicKey(t)))
+	require.True(t, ok)
+
+	_, err := PinHostKeys(sshPath, "github.com", []KnownHost{scanned}, []string{"SHA256:published"})
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "does not match any published fingerprint")
+	assert.NoFileExists(t, filepath.Join(sshPath, KnownHostsFileName))
+}
+
+func TestPinHostKeys_NoKeys(t *testing.T) {
+	_, err := PinHostKeys(t.TempDir(), "github.com", nil, []string{"SHA256:published"})
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "no host keys found")
+}
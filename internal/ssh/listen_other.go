@@ -0,0 +1,22 @@
+//go:build !unix
+
+package ssh
+
+import (
+	"net"
+	"os"
+)
+
+// listenPrivate listens on a Unix socket and restricts it to the current user
+func listenPrivate(socket string) (net.Listener, error) {
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.Chmod(socket, 0600); err != nil {
+		listener.Close()
+		return nil, err
+	}
+	return listener, nil
+}
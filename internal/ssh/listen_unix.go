@@ -0,0 +1,18 @@
+//go:build unix
+
+package ssh
+
+import (
+	"net"
+	"syscall"
+)
+
+// listenPrivate listens on a Unix socket that only the current user can connect to. The
+// umask applies when the socket is created, so there is no moment it is open to others.
+func listenPrivate(socket string) (net.Listener, error) {
+	// the umask is process wide, the agent creates no other files meanwhile
+	oldMask := syscall.Umask(0177)
+	defer syscall.Umask(oldMask)
+
+	return net.Listen("unix", socket)
+}
@@ -0,0 +1,97 @@
+package ssh
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/residwi/sshman/utils"
+)
+
+const MetadataFileName = ".sshman-metadata.json"
+
+const (
+	ExpiryNone    = "no expiry"
+	ExpiryValid   = "valid"
+	ExpirySoon    = "expiring soon"
+	ExpiryExpired = "expired"
+)
+
+type KeyMetadata struct {
+	Type        string    `json:"type"`
+	Provider    string    `json:"provider"`
+	Purpose     string    `json:"purpose,omitempty"`
+	Email       string    `json:"email,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	ExpiresAt   time.Time `json:"expires_at,omitzero"`
+	RotatedFrom string    `json:"rotated_from,omitempty"`
+}
+
+// ExpiryStatus reports whether the key has expired or expires within the warning window
+func (km KeyMetadata) ExpiryStatus(now time.Time, warnWithin time.Duration) string {
+	switch {
+	case km.ExpiresAt.IsZero():
+		return ExpiryNone
+	case !now.Before(km.ExpiresAt):
+		return ExpiryExpired
+	case km.ExpiresAt.Sub(now) <= warnWithin:
+		return ExpirySoon
+	default:
+		return ExpiryValid
+	}
+}
+
+// Metadata holds what sshman knows about the keys it manages, indexed by key name
+type Metadata struct {
+	Keys map[string]KeyMetadata `json:"keys"`
+}
+
+func LoadMetadata(sshPath string) (*Metadata, error) {
+	metadata := &Metadata{Keys: map[string]KeyMetadata{}}
+
+	content, err := os.ReadFile(filepath.Join(sshPath, MetadataFileName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return metadata, nil
+		}
+		return nil, fmt.Errorf("failed to read sshman metadata: %w", err)
+	}
+
+	if err := json.Unmarshal(content, metadata); err != nil {
+		return nil, fmt.Errorf("failed to parse sshman metadata: %w", err)
+	}
+
+	if metadata.Keys == nil {
+		metadata.Keys = map[string]KeyMetadata{}
+	}
+
+	return metadata, nil
+}
+
+func (m *Metadata) Save(sshPath string) error {
+	content, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode sshman metadata: %w", err)
+	}
+
+	if err := utils.WriteFileAtomic(filepath.Join(sshPath, MetadataFileName), append(content, '\n'), 0600); err != nil {
+		return fmt.Errorf("failed to write sshman metadata: %w", err)
+	}
+
+	return nil
+}
+
+func (m *Metadata) Get(keyName string) (KeyMetadata, bool) {
+	keyMetadata, exists := m.Keys[keyName]
+	return keyMetadata, exists
+}
+
+func (m *Metadata) Set(keyName string, keyMetadata KeyMetadata) {
+	m.Keys[keyName] = keyMetadata
+}
+
+func (m *Metadata) Delete(keyName string) {
+	delete(m.Keys, keyName)
+}
@@ -0,0 +1,91 @@
+package ssh
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadMetadata_NoFile_Empty(t *testing.T) {
+	metadata, err := LoadMetadata(t.TempDir())
+
+	assert.NoError(t, err)
+	assert.NotNil(t, metadata.Keys)
+	assert.Empty(t, metadata.Keys)
+}
+
+func TestMetadata_SaveAndLoad_Success(t *testing.T) {
+	tempDir := t.TempDir()
+	createdAt := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	metadata, err := LoadMetadata(tempDir)
+	require.NoError(t, err)
+
+	metadata.Set("id_ed25519_work", KeyMetadata{
+		Type:      "ed25519",
+		Provider:  "github",
+		Purpose:   "work",
+		Email:     "work@company.com",
+		CreatedAt: createdAt,
+		ExpiresAt: createdAt.Add(90 * 24 * time.Hour),
+	})
+	metadata.Set("id_rsa_old", KeyMetadata{Type: "rsa", Provider: "generic"})
+	metadata.Delete("id_rsa_old")
+
+	require.NoError(t, metadata.Save(tempDir))
+
+	info, err := os.Stat(filepath.Join(tempDir, MetadataFileName))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	loaded, err := LoadMetadata(tempDir)
+	require.NoError(t, err)
+
+	keyMetadata, exists := loaded.Get("id_ed25519_work")
+	assert.True(t, exists)
+	assert.Equal(t, "github", keyMetadata.Provider)
+	assert.Equal(t, "work", keyMetadata.Purpose)
+	assert.True(t, createdAt.Equal(keyMetadata.CreatedAt))
+	assert.True(t, createdAt.Add(90*24*time.Hour).Equal(keyMetadata.ExpiresAt))
+
+	_, exists = loaded.Get("id_rsa_old")
+	assert.False(t, exists)
+}
+
+func TestLoadMetadata_InvalidJSON_Error(t *testing.T) {
+	tempDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, MetadataFileName), []byte("{invalid"), 0600))
+
+	_, err := LoadMetadata(tempDir)
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to parse sshman metadata")
+}
+
+func TestKeyMetadata_ExpiryStatus(t *testing.T) {
+	now := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	warnWithin := 14 * 24 * time.Hour
+
+	tests := []struct {
+		name      string
+		expiresAt time.Time
+		expected  string
+	}{
+		{"no_expiry", time.Time{}, ExpiryNone},
+		{"valid", now.Add(30 * 24 * time.Hour), ExpiryValid},
+		{"expiring_soon", now.Add(7 * 24 * time.Hour), ExpirySoon},
+		{"expires_now", now, ExpiryExpired},
+		{"expired", now.Add(-24 * time.Hour), ExpiryExpired},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keyMetadata := KeyMetadata{ExpiresAt: tt.expiresAt}
+			assert.Equal(t, tt.expected, keyMetadata.ExpiryStatus(now, warnWithin))
+		})
+	}
+}
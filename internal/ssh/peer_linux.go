@@ -0,0 +1,29 @@
+package ssh
+
+import (
+	"net"
+	"syscall"
+)
+
+// peerCred returns the PID and UID of the process on the other end of a Unix socket,
+// 0 and -1 when unknown
+func peerCred(conn net.Conn) (int, int) {
+	unixConn, ok := conn.(*net.UnixConn)
+	if !ok {
+		return 0, -1
+	}
+
+	rawConn, err := unixConn.SyscallConn()
+	if err != nil {
+		return 0, -1
+	}
+
+	var cred *syscall.Ucred
+	rawConn.Control(func(fd uintptr) {
+		cred, err = syscall.GetsockoptUcred(int(fd), syscall.SOL_SOCKET, syscall.SO_PEERCRED)
+	})
+	if err != nil || cred == nil {
+		return 0, -1
+	}
+	return int(cred.Pid), int(cred.Uid)
+}
@@ -0,0 +1,328 @@
+package ssh
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/residwi/sshman/utils"
+	gossh "golang.org/x/crypto/ssh"
+)
+
+const (
+	TrashDirName      = ".sshman-trash"
+	trashManifestName = "manifest.json"
+	// trashFilesDirName keeps the key files apart from the manifest, so no key name can clash with it
+	trashFilesDirName = "files"
+)
+
+// TrashEntry describes a deleted key and everything needed to restore it
+type TrashEntry struct {
+	ID        string    `json:"id"`
+	KeyName   string    `json:"key_name"`
+	DeletedAt time.Time `json:"deleted_at"`
+	Files     []string  `json:"files"`
+	Hosts     []string  `json:"hosts,omitempty"`
+	// ConfigBlocks holds the Host blocks removed from each SSH config file
+	ConfigBlocks map[string]string `json:"config_blocks,omitempty"`
+	Metadata     *KeyMetadata      `json:"metadata,omitempty"`
+	// KeptHosts are Host blocks that still list the key next to other identities
+	KeptHosts []string `json:"-"`
+}
+
+// PlanTrash works out what deleting a key would move and remove, without changing anything
+func PlanTrash(sshPath, keyName string) (*TrashEntry, error) {
+	keyPath := filepath.Join(sshPath, keyName)
+	if utils.IsFileNotExist(keyPath) {
+		return nil, fmt.Errorf("SSH key [%s] does not exist", keyName)
+	}
+
+	now := time.Now()
+	entry := &TrashEntry{
+		ID:        now.Format("20060102150405") + "-" + strings.ReplaceAll(keyName, string(filepath.Separator), "_"),
+		KeyName:   keyName,
+		DeletedAt: now,
+	}
+
+	for _, suffix := range []string{"", ".pub", "-cert.pub"} {
+		if !utils.IsFileNotExist(keyPath + suffix) {
+			entry.Files = append(entry.Files, keyName+suffix)
+		}
+	}
+
+	entries, err := ReadConfig(sshPath)
+	if err != nil {
+		return nil, err
+	}
+	for _, configEntry := range FindEntriesByIdentityFile(entries, keyPath) {
+		entry.Hosts = append(entry.Hosts, configEntry.Host)
+	}
+
+	for _, path := range ConfigFilePaths(sshPath) {
+		content, err := readConfigFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		_, blocks, kept := removeHostBlocks(content, keyPath)
+		if blocks != "" {
+			if entry.ConfigBlocks == nil {
+				entry.ConfigBlocks = map[string]string{}
+			}
+			entry.ConfigBlocks[path] = blocks
+		}
+		entry.KeptHosts = append(entry.KeptHosts, kept...)
+	}
+
+	metadata, err := LoadMetadata(sshPath)
+	if err != nil {
+		return nil, err
+	}
+	if keyMetadata, exists := metadata.Get(keyName); exists {
+		entry.Metadata = &keyMetadata
+	}
+
+	return entry, nil
+}
+
+// TrashKey moves a key pair into the trash and removes its Host blocks and metadata.
+// Every step is undone if a later one fails, so a key is never left half deleted.
+func TrashKey(sshPath, keyName string) (*TrashEntry, error) {
+	entry, err := PlanTrash(sshPath, keyName)
+	if err != nil {
+		return nil, err
+	}
+
+	trashDir := filepath.Join(sshPath, TrashDirName, entry.ID)
+	filesDir := filepath.Join(trashDir, trashFilesDirName)
+	if err := os.MkdirAll(filesDir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create trash directory: %w", err)
+	}
+
+	var moved []string
+	rollback := func() {
+		for _, name := range moved {
+			os.Rename(filepath.Join(filesDir, filepath.Base(name)), filepath.Join(sshPath, name))
+		}
+		os.RemoveAll(trashDir)
+	}
+
+	for _, name := range entry.Files {
+		if err := os.Rename(filepath.Join(sshPath, name), filepath.Join(filesDir, filepath.Base(name))); err != nil {
+			rollback()
+			return nil, fmt.Errorf("failed to move %s to trash: %w", name, err)
+		}
+		moved = append(moved, name)
+	}
+
+	if err := writeTrashManifest(trashDir, entry); err != nil {
+		rollback()
+		return nil, err
+	}
+
+	originals := map[string]string{}
+	for _, path := range slices.Sorted(maps.Keys(entry.ConfigBlocks)) {
+		content, err := readConfigFile(path)
+		if err != nil {
+			restoreConfigFiles(originals)
+			rollback()
+			return nil, err
+		}
+
+		newContent, _, _ := removeHostBlocks(content, filepath.Join(sshPath, keyName))
+		if err := utils.WriteFileAtomic(path, []byte(newContent), 0600); err != nil {
+			restoreConfigFiles(originals)
+			rollback()
+			return nil, fmt.Errorf("failed to write SSH config file: %w", err)
+		}
+		originals[path] = content
+	}
+
+	if entry.Metadata != nil {
+		if err := deleteMetadataEntry(sshPath, keyName); err != nil {
+			restoreConfigFiles(originals)
+			rollback()
+			return nil, err
+		}
+	}
+
+	return entry, nil
+}
+
+// ListTrash returns the trashed keys, most recently deleted first
+func ListTrash(sshPath string) ([]TrashEntry, error) {
+	dirEntries, err := os.ReadDir(filepath.Join(sshPath, TrashDirName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []TrashEntry{}, nil
+		}
+		return nil, fmt.Errorf("failed to read trash: %w", err)
+	}
+
+	entries := []TrashEntry{}
+	for _, dirEntry := range dirEntries {
+		if !dirEntry.IsDir() {
+			continue
+		}
+
+		entry, err := readTrashEntry(sshPath, dirEntry.Name())
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, *entry)
+	}
+
+	slices.SortFunc(entries, func(a, b TrashEntry) int {
+		return b.DeletedAt.Compare(a.DeletedAt)
+	})
+
+	return entries, nil
+}
+
+// RestoreFromTrash moves a trashed key back and restores its Host blocks and metadata.
+// Every step is undone if a later one fails, so the key stays in the trash.
+func RestoreFromTrash(sshPath, id string) (*TrashEntry, error) {
+	entry, err := readTrashEntry(sshPath, id)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, name := range entry.Files {
+		if !utils.IsFileNotExist(filepath.Join(sshPath, name)) {
+			return nil, fmt.Errorf("cannot restore [%s], %s already exists", entry.KeyName, name)
+		}
+	}
+
+	trashDir := filepath.Join(sshPath, TrashDirName, id)
+	filesDir := filepath.Join(trashDir, trashFilesDirName)
+
+	var restored []string
+	rollback := func() {
+		for _, name := range restored {
+			os.Rename(filepath.Join(sshPath, name), filepath.Join(filesDir, filepath.Base(name)))
+		}
+	}
+
+	for _, name := range entry.Files {
+		if err := os.Rename(filepath.Join(filesDir, filepath.Base(name)), filepath.Join(sshPath, name)); err != nil {
+			rollback()
+			return nil, fmt.Errorf("failed to restore %s: %w", name, err)
+		}
+		restored = append(restored, name)
+	}
+
+	originals := map[string]string{}
+	for _, path := range slices.Sorted(maps.Keys(entry.ConfigBlocks)) {
+		content, err := readConfigFile(path)
+		if err != nil {
+			restoreConfigFiles(originals)
+			rollback()
+			return nil, err
+		}
+
+		newContent := content
+		if newContent != "" && !strings.HasSuffix(newContent, "\n") {
+			newContent += "\n"
+		}
+		if err := utils.WriteFileAtomic(path, []byte(newContent+entry.ConfigBlocks[path]), 0600); err != nil {
+			restoreConfigFiles(originals)
+			rollback()
+			return nil, fmt.Errorf("failed to write SSH config file: %w", err)
+		}
+		originals[path] = content
+	}
+
+	if entry.Metadata != nil {
+		if err := restoreMetadataEntry(sshPath, entry.KeyName, *entry.Metadata); err != nil {
+			restoreConfigFiles(originals)
+			rollback()
+			return nil, err
+		}
+	}
+
+	if err := os.RemoveAll(trashDir); err != nil {
+		return nil, fmt.Errorf("failed to remove trash entry: %w", err)
+	}
+
+	return entry, nil
+}
+
+// PurgeTrash permanently deletes a trashed key
+func PurgeTrash(sshPath, id string) error {
+	if _, err := readTrashEntry(sshPath, id); err != nil {
+		return err
+	}
+
+	if err := os.RemoveAll(filepath.Join(sshPath, TrashDirName, id)); err != nil {
+		return fmt.Errorf("failed to purge trash entry: %w", err)
+	}
+
+	return nil
+}
+
+// KeyFingerprint returns the SHA256 fingerprint of a private key, or an empty string
+// when its public key cannot be read
+func KeyFingerprint(privateKeyPath string) string {
+	publicKey := readKeyPublicKey(privateKeyPath)
+	if publicKey == nil {
+		return ""
+	}
+	return gossh.FingerprintSHA256(publicKey)
+}
+
+func readTrashEntry(sshPath, id string) (*TrashEntry, error) {
+	if !filepath.IsLocal(id) || strings.ContainsRune(id, filepath.Separator) {
+		return nil, fmt.Errorf("invalid trash entry: %s", id)
+	}
+
+	content, err := os.ReadFile(filepath.Join(sshPath, TrashDirName, id, trashManifestName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("trash entry [%s] does not exist", id)
+		}
+		return nil, fmt.Errorf("failed to read trash entry: %w", err)
+	}
+
+	var entry TrashEntry
+	if err := json.Unmarshal(content, &entry); err != nil {
+		return nil, fmt.Errorf("failed to parse trash entry [%s]: %w", id, err)
+	}
+
+	return &entry, nil
+}
+
+func writeTrashManifest(trashDir string, entry *TrashEntry) error {
+	content, err := json.MarshalIndent(entry, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode trash manifest: %w", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(trashDir, trashManifestName), content, 0600); err != nil {
+		return fmt.Errorf("failed to write trash manifest: %w", err)
+	}
+
+	return nil
+}
+
+func restoreMetadataEntry(sshPath, keyName string, keyMetadata KeyMetadata) error {
+	metadata, err := LoadMetadata(sshPath)
+	if err != nil {
+		return err
+	}
+	metadata.Set(keyName, keyMetadata)
+	return metadata.Save(sshPath)
+}
+
+func deleteMetadataEntry(sshPath, keyName string) error {
+	metadata, err := LoadMetadata(sshPath)
+	if err != nil {
+		return err
+	}
+	metadata.Delete(keyName)
+	return metadata.Save(sshPath)
+}
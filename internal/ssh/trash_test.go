@@ -0,0 +1,220 @@
+package ssh
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupTrashTest(t *testing.T) (string, string) {
+	t.Helper()
+
+	sshPath := t.TempDir()
+	privateKey := newTestPrivateKey(t)
+	keyPath := writeTestKeyPair(t, sshPath, "id_ed25519_work", privateKey, "", true)
+
+	config := "Host other\n\tHostName server.com\n\n# Generated by sshman\nHost github-work\n\tHostName github.com\n\tIdentityFile " + keyPath + "\n"
+	require.NoError(t, os.WriteFile(filepath.Join(sshPath, "config"), []byte(config), 0600))
+
+	metadata, err := LoadMetadata(sshPath)
+	require.NoError(t, err)
+	metadata.Set("id_ed25519_work", KeyMetadata{Type: "ed25519", Provider: "github", CreatedAt: time.Now()})
+	require.NoError(t, metadata.Save(sshPath))
+
+	return sshPath, config
+}
+
+func TestPlanTrash_NoChanges(t *testing.T) {
+	sshPath, config := setupTrashTest(t)
+
+	entry, err := PlanTrash(sshPath, "id_ed25519_work")
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"id_ed25519_work", "id_ed25519_work.pub"}, entry.Files)
+	assert.Equal(t, []string{"github-work"}, entry.Hosts)
+	assert.Contains(t, entry.ConfigBlocks[filepath.Join(sshPath, "config")], "Host github-work")
+	require.NotNil(t, entry.Metadata)
+	assert.Equal(t, "github", entry.Metadata.Provider)
+
+	content, err := os.ReadFile(filepath.Join(sshPath, "config"))
+	require.NoError(t, err)
+	assert.Equal(t, config, string(content))
+	assert.FileExists(t, filepath.Join(sshPath, "id_ed25519_work"))
+}
+
+func TestTrashKey_RestoreFromTrash_RoundTrip(t *testing.T) {
+	sshPath, _ := setupTrashTest(t)
+
+	entry, err := TrashKey(sshPath, "id_ed25519_work")
+	require.NoError(t, err)
+
+	assert.NoFileExists(t, filepath.Join(sshPath, "id_ed25519_work"))
+	assert.NoFileExists(t, filepath.Join(sshPath, "id_ed25519_work.pub"))
+	assert.FileExists(t, filepath.Join(sshPath, TrashDirName, entry.ID, trashFilesDirName, "id_ed25519_work"))
+
+	content, err := os.ReadFile(filepath.Join(sshPath, "config"))
+	require.NoError(t, err)
+	assert.Equal(t, "Host other\n\tHostName server.com\n", string(content))
+
+	metadata, err := LoadMetadata(sshPath)
+	require.NoError(t, err)
+	_, exists := metadata.Get("id_ed25519_work")
+	assert.False(t, exists)
+
+	entries, err := ListTrash(sshPath)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, entry.ID, entries[0].ID)
+
+	_, err = RestoreFromTrash(sshPath, entry.ID)
+	require.NoError(t, err)
+
+	assert.FileExists(t, filepath.Join(sshPath, "id_ed25519_work"))
+	assert.FileExists(t, filepath.Join(sshPath, "id_ed25519_work.pub"))
+	configEntries, err := ReadConfig(sshPath)
+	require.NoError(t, err)
+	assert.Len(t, FindEntriesByIdentityFile(configEntries, filepath.Join(sshPath, "id_ed25519_work")), 1)
+
+	metadata, err = LoadMetadata(sshPath)
+	require.NoError(t, err)
+	_, exists = metadata.Get("id_ed25519_work")
+	assert.True(t, exists)
+
+	entries, err = ListTrash(sshPath)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestTrashKey_MissingPublicKey_Success(t *testing.T) {
+	sshPath := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(sshPath, "id_rsa"), []byte("private"), 0600))
+
+	entry, err := TrashKey(sshPath, "id_rsa")
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"id_rsa"}, entry.Files)
+	assert.NoFileExists(t, filepath.Join(sshPath, "id_rsa"))
+}
+
+func TestTrashKey_CorruptMetadata_NothingChanged(t *testing.T) {
+	sshPath, config := setupTrashTest(t)
+	require.NoError(t, os.WriteFile(filepath.Join(sshPath, MetadataFileName), []byte("{"), 0600))
+
+	_, err := TrashKey(sshPath, "id_ed25519_work")
+	require.Error(t, err)
+
+	assert.FileExists(t, filepath.Join(sshPath, "id_ed25519_work"))
+	assert.FileExists(t, filepath.Join(sshPath, "id_ed25519_work.pub"))
+	content, err := os.ReadFile(filepath.Join(sshPath, "config"))
+	require.NoError(t, err)
+	assert.Equal(t, config, string(content))
+
+	entries, err := ListTrash(sshPath)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestTrashKey_KeyNamedLikeManifest_RoundTrip(t *testing.T) {
+	sshPath := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(sshPath, trashManifestName), []byte("private"), 0600))
+
+	entry, err := TrashKey(sshPath, trashManifestName)
+	require.NoError(t, err)
+
+	entries, err := ListTrash(sshPath)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, trashManifestName, entries[0].KeyName)
+
+	_, err = RestoreFromTrash(sshPath, entry.ID)
+	require.NoError(t, err)
+
+	content, err := os.ReadFile(filepath.Join(sshPath, trashManifestName))
+	require.NoError(t, err)
+	assert.Equal(t, "private", string(content))
+}
+
+func TestRestoreFromTrash_CorruptMetadata_StaysInTrash(t *testing.T) {
+	sshPath, _ := setupTrashTest(t)
+	entry, err := TrashKey(sshPath, "id_ed25519_work")
+	require.NoError(t, err)
+
+	configAfterTrash, err := os.ReadFile(filepath.Join(sshPath, "config"))
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(sshPath, MetadataFileName), []byte("{"), 0600))
+
+	_, err = RestoreFromTrash(sshPath, entry.ID)
+	require.Error(t, err)
+
+	assert.NoFileExists(t, filepath.Join(sshPath, "id_ed25519_work"))
+	assert.NoFileExists(t, filepath.Join(sshPath, "id_ed25519_work.pub"))
+	assert.FileExists(t, filepath.Join(sshPath, TrashDirName, entry.ID, trashFilesDirName, "id_ed25519_work"))
+	content, err := os.ReadFile(filepath.Join(sshPath, "config"))
+	require.NoError(t, err)
+	assert.Equal(t, string(configAfterTrash), string(content))
+}
+
+func TestTrashKey_IncludedConfigFile_RoundTrip(t *testing.T) {
+	sshPath, includedPath := setupIncludedConfigTest(t)
+	writeTestKeyPair(t, sshPath, "id_ed25519_work", newTestPrivateKey(t), "", true)
+	mainConfigPath := filepath.Join(sshPath, "config")
+	mainConfig, err := os.ReadFile(mainConfigPath)
+	require.NoError(t, err)
+
+	entry, err := TrashKey(sshPath, "id_ed25519_work")
+	require.NoError(t, err)
+
+	assert.ElementsMatch(t, []string{"server", "github-work"}, entry.Hosts)
+	assert.Contains(t, entry.ConfigBlocks[mainConfigPath], "Host server")
+	assert.Contains(t, entry.ConfigBlocks[includedPath], "Host github-work")
+	entries, err := ReadConfig(sshPath)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+
+	_, err = RestoreFromTrash(sshPath, entry.ID)
+	require.NoError(t, err)
+
+	content, err := os.ReadFile(mainConfigPath)
+	require.NoError(t, err)
+	assert.Equal(t, string(mainConfig), string(content))
+	entries, err = ReadConfig(sshPath)
+	require.NoError(t, err)
+	assert.Len(t, entries, 2)
+}
+
+func TestRestoreFromTrash_Conflict_Error(t *testing.T) {
+	sshPath, _ := setupTrashTest(t)
+	entry, err := TrashKey(sshPath, "id_ed25519_work")
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(sshPath, "id_ed25519_work"), []byte("new"), 0600))
+
+	_, err = RestoreFromTrash(sshPath, entry.ID)
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "already exists")
+}
+
+func TestPurgeTrash(t *testing.T) {
+	sshPath, _ := setupTrashTest(t)
+	entry, err := TrashKey(sshPath, "id_ed25519_work")
+	require.NoError(t, err)
+
+	require.NoError(t, PurgeTrash(sshPath, entry.ID))
+
+	assert.NoDirExists(t, filepath.Join(sshPath, TrashDirName, entry.ID))
+	assert.Error(t, PurgeTrash(sshPath, entry.ID))
+	assert.Error(t, PurgeTrash(sshPath, "../config"))
+}
+
+func TestKeyFingerprint(t *testing.T) {
+	sshPath := t.TempDir()
+	privateKey := newTestPrivateKey(t)
+	keyPath := writeTestKeyPair(t, sshPath, "id_ed25519", privateKey, "", false)
+
+	assert.Regexp(t, `^SHA256:`, KeyFingerprint(keyPath))
+	assert.Empty(t, KeyFingerprint(filepath.Join(sshPath, "missing")))
+}
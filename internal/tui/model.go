@@ -0,0 +1,309 @@
+package tui
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/fatih/color"
+	"github.com/residwi/sshman/internal/ssh"
+	"github.com/residwi/sshman/utils"
+)
+
+// Key is a key as shown in the UI
+type Key struct {
+	Name        string
+	Path        string
+	Type        string
+	Age         string
+	Fingerprint string
+	Email       string
+	Provider    string
+	Purpose     string
+	Expires     string
+	Loaded      bool
+	Aliases     []string
+}
+
+// Actions performs the operations offered by the UI, using the same code as the CLI
+// commands. ToggleAgent, Rotate and Delete run with the terminal released to them,
+// so they can print and prompt for passphrases or confirmation.
+type Actions interface {
+	LoadKeys() ([]Key, error)
+	CopyPublicKey(key Key) (string, error)
+	TestKey(key Key) ([]ssh.ConnectionResult, error)
+	ToggleAgent(key Key) error
+	Rotate(key Key) error
+	Delete(key Key) error
+}
+
+type keysLoadedMsg struct {
+	keys []Key
+	err  error
+}
+
+type statusMsg struct {
+	text string
+	err  error
+}
+
+type testResultMsg struct {
+	keyName string
+	results []ssh.ConnectionResult
+	err     error
+}
+
+type actionDoneMsg struct {
+	text string
+	err  error
+}
+
+type Model struct {
+	actions Actions
+	keys    []Key
+	cursor  int
+	status  string
+	isError bool
+	results map[string][]ssh.ConnectionResult
+}
+
+func NewModel(actions Actions) Model {
+	return Model{
+		actions: actions,
+		results: map[string][]ssh.ConnectionResult{},
+	}
+}
+
+func (m Model) Init() tea.Cmd {
+	return m.loadKeys
+}
+
+func (m Model) loadKeys() tea.Msg {
+	keys, err := m.actions.LoadKeys()
+	return keysLoadedMsg{keys: keys, err: err}
+}
+
+func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case keysLoadedMsg:
+		if msg.err != nil {
+			m.setStatus("", msg.err)
+			return m, nil
+		}
+		m.keys = msg.keys
+		m.cursor = min(m.cursor, max(len(m.keys)-1, 0))
+		return m, nil
+
+	case statusMsg:
+		m.setStatus(msg.text, msg.err)
+		return m, nil
+
+	case testResultMsg:
+		if msg.err != nil {
+			m.setStatus("", msg.err)
+			return m, nil
+		}
+		m.results[msg.keyName] = msg.results
+		m.setStatus("Connectivity tested for ["+msg.keyName+"]", nil)
+		return m, nil
+
+	case actionDoneMsg:
+		m.setStatus(msg.text, msg.err)
+		return m, m.loadKeys
+
+	case tea.KeyMsg:
+		return m.handleKey(msg)
+	}
+
+	return m, nil
+}
+
+func (m Model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "q", "ctrl+c", "esc":
+		return m, tea.Quit
+	case "up", "k":
+		if m.cursor > 0 {
+			m.cursor--
+		}
+		return m, nil
+	case "down", "j":
+		if m.cursor < len(m.keys)-1 {
+			m.cursor++
+		}
+		return m, nil
+	case "R":
+		m.setStatus("Refreshed", nil)
+		return m, m.loadKeys
+	}
+
+	key, selected := m.selected()
+	if !selected {
+		return m, nil
+	}
+
+	switch msg.String() {
+	case "a", "enter":
+		done := "SSH key [" + key.Name + "] loaded into agent"
+		if key.Loaded {
+			done = "SSH key [" + key.Name + "] removed from agent"
+		}
+		return m, runAction(func() error { return m.actions.ToggleAgent(key) }, done, false)
+	case "c":
+		return m, func() tea.Msg {
+			method, err := m.actions.CopyPublicKey(key)
+			return statusMsg{text: "Public key of [" + key.Name + "] copied with " + method, err: err}
+		}
+	case "t":
+		m.setStatus("Testing ["+key.Name+"]...", nil)
+		return m, func() tea.Msg {
+			results, err := m.actions.TestKey(key)
+			return testResultMsg{keyName: key.Name, results: results, err: err}
+		}
+	case "r":
+		return m, runAction(func() error { return m.actions.Rotate(key) }, "SSH key ["+key.Name+"] rotated", true)
+	case "d":
+		return m, runAction(func() error { return m.actions.Delete(key) }, "", true)
+	}
+
+	return m, nil
+}
+
+func (m *Model) setStatus(text string, err error) {
+	m.isError = err != nil
+	m.status = text
+	if err != nil {
+		m.status = err.Error()
+	}
+}
+
+func (m Model) selected() (Key, bool) {
+	if m.cursor < 0 || m.cursor >= len(m.keys) {
+		return Key{}, false
+	}
+	return m.keys[m.cursor], true
+}
+
+func (m Model) View() string {
+	var b strings.Builder
+
+	b.WriteString(color.New(color.Bold).Sprintf("sshman: %d SSH keys", len(m.keys)) + "\n\n")
+
+	if len(m.keys) == 0 {
+		b.WriteString("  No SSH keys found\n")
+	} else {
+		m.writeTable(&b)
+		b.WriteString("\n")
+		m.writeDetails(&b)
+	}
+
+	b.WriteString("\n")
+	if m.status != "" {
+		if m.isError {
+			b.WriteString(color.RedString("✖ "+m.status) + "\n")
+		} else {
+			b.WriteString(color.GreenString("✔ "+m.status) + "\n")
+		}
+	}
+	b.WriteString(color.HiBlackString("↑/↓ move • a load/unload • c copy public key • t test • r rotate • d delete • R refresh • q quit") + "\n")
+
+	return b.String()
+}
+
+func (m Model) writeTable(w io.Writer) {
+	var table strings.Builder
+	tw := tabwriter.NewWriter(&table, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "  NAME\tTYPE\tAGENT\tALIASES\tAGE")
+	for _, key := range m.keys {
+		agent := "-"
+		if key.Loaded {
+			agent = "loaded"
+		}
+		aliases := "-"
+		if len(key.Aliases) > 0 {
+			aliases = strings.Join(key.Aliases, ", ")
+		}
+		fmt.Fprintf(tw, "  %s\t%s\t%s\t%s\t%s\n", key.Name, key.Type, agent, aliases, key.Age)
+	}
+	tw.Flush()
+
+	// colors are applied after alignment, tabwriter counts escape codes as width
+	lines := strings.Split(strings.TrimSuffix(table.String(), "\n"), "\n")
+	for i, line := range lines {
+		switch {
+		case i == 0:
+			line = color.New(color.Bold).Sprint(line)
+		case i-1 == m.cursor:
+			line = color.New(color.FgCyan, color.Bold).Sprint(">" + line[1:])
+		}
+		fmt.Fprintln(w, line)
+	}
+}
+
+func (m Model) writeDetails(w io.Writer) {
+	key, selected := m.selected()
+	if !selected {
+		return
+	}
+
+	details := [][2]string{
+		{"Path", utils.ReplaceHomeDirWithTilde(key.Path)},
+		{"Fingerprint", key.Fingerprint},
+		{"Email", key.Email},
+		{"Provider", key.Provider},
+		{"Purpose", key.Purpose},
+		{"Expires", key.Expires},
+	}
+	for _, detail := range details {
+		if detail[1] != "" {
+			fmt.Fprintf(w, "  %-12s %s\n", detail[0]+":", detail[1])
+		}
+	}
+
+	for _, result := range m.results[key.Name] {
+		text := result.Status
+		if result.Account != "" {
+			text += ", authenticated as " + result.Account
+		} else if result.Message != "" {
+			text += ", " + result.Message
+		}
+
+		if result.Status == ssh.StatusSuccess {
+			fmt.Fprintf(w, "  %-12s %s\n", "Test:", color.GreenString("%s %s", result.Host, text))
+		} else {
+			fmt.Fprintf(w, "  %-12s %s\n", "Test:", color.RedString("%s %s", result.Host, text))
+		}
+	}
+}
+
+// runAction hands the terminal to an action, optionally waiting for Enter afterwards so
+// its output can be read before the UI is redrawn
+func runAction(action func() error, done string, pause bool) tea.Cmd {
+	return tea.Exec(&actionExec{action: action, pause: pause}, func(err error) tea.Msg {
+		return actionDoneMsg{text: done, err: err}
+	})
+}
+
+type actionExec struct {
+	action func() error
+	pause  bool
+}
+
+func (e *actionExec) Run() error {
+	err := e.action()
+	if err != nil {
+		utils.PrintError(err.Error())
+	}
+
+	if e.pause {
+		utils.Prompt("Press Enter to return to sshman", "")
+	}
+
+	return err
+}
+
+func (e *actionExec) SetStdin(io.Reader)  {}
+func (e *actionExec) SetStdout(io.Writer) {}
+func (e *actionExec) SetStderr(io.Writer) {}
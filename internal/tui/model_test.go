@@ -0,0 +1,159 @@
+package tui
+
+import (
+	"fmt"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/residwi/sshman/internal/ssh"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeActions struct {
+	keys    []Key
+	copied  []string
+	tested  []string
+	copyErr error
+}
+
+func (f *fakeActions) LoadKeys() ([]Key, error) { return f.keys, nil }
+
+func (f *fakeActions) CopyPublicKey(key Key) (string, error) {
+	f.copied = append(f.copied, key.Name)
+	return "xclip", f.copyErr
+}
+
+func (f *fakeActions) TestKey(key Key) ([]ssh.ConnectionResult, error) {
+	f.tested = append(f.tested, key.Name)
+	return []ssh.ConnectionResult{{Host: key.Aliases[0], Status: ssh.StatusSuccess, Account: "octocat"}}, nil
+}
+
+func (f *fakeActions) ToggleAgent(key Key) error { return nil }
+func (f *fakeActions) Rotate(key Key) error      { return nil }
+func (f *fakeActions) Delete(key Key) error      { return nil }
+
+func newLoadedModel(t *testing.T, actions *fakeActions) Model {
+	t.Helper()
+
+	model := NewModel(actions)
+	updated, _ := model.Update(model.Init()())
+	return updated.(Model)
+}
+
+func press(t *testing.T, model Model, key string) (Model, tea.Cmd) {
+	t.Helper()
+
+	var msg tea.KeyMsg
+	switch key {
+	case "up":
+		msg = tea.KeyMsg{Type: tea.KeyUp}
+	case "down":
+		msg = tea.KeyMsg{Type: tea.KeyDown}
+	default:
+		msg = tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)}
+	}
+
+	updated, cmd := model.Update(msg)
+	return updated.(Model), cmd
+}
+
+func testKeys() []Key {
+	return []Key{
+		{Name: "id_ed25519_work", Type: "ED25519", Loaded: true, Aliases: []string{"github-work"}, Age: "12d", Fingerprint: "SHA256:abc"},
+		{Name: "id_rsa", Type: "RSA", Age: "3y"},
+	}
+}
+
+func TestModel_Navigation(t *testing.T) {
+	model := newLoadedModel(t, &fakeActions{keys: testKeys()})
+
+	model, _ = press(t, model, "down")
+	assert.Equal(t, 1, model.cursor)
+
+	model, _ = press(t, model, "down")
+	assert.Equal(t, 1, model.cursor, "cursor should stop at the last key")
+
+	model, _ = press(t, model, "k")
+	assert.Equal(t, 0, model.cursor)
+
+	model, _ = press(t, model, "up")
+	assert.Equal(t, 0, model.cursor)
+}
+
+func TestModel_View_ShowsKeysAndDetails(t *testing.T) {
+	model := newLoadedModel(t, &fakeActions{keys: testKeys()})
+
+	view := model.View()
+
+	assert.Contains(t, view, "id_ed25519_work")
+	assert.Contains(t, view, "github-work")
+	assert.Contains(t, view, "loaded")
+	assert.Contains(t, view, "SHA256:abc")
+	assert.Contains(t, view, "id_rsa")
+}
+
+func TestModel_CopyPublicKey(t *testing.T) {
+	actions := &fakeActions{keys: testKeys()}
+	model := newLoadedModel(t, actions)
+
+	model, cmd := press(t, model, "c")
+	require.NotNil(t, cmd)
+	updated, _ := model.Update(cmd())
+	model = updated.(Model)
+
+	assert.Equal(t, []string{"id_ed25519_work"}, actions.copied)
+	assert.Contains(t, model.status, "copied with xclip")
+	assert.False(t, model.isError)
+}
+
+func TestModel_CopyPublicKey_Error(t *testing.T) {
+	actions := &fakeActions{keys: testKeys(), copyErr: fmt.Errorf("no clipboard")}
+	model := newLoadedModel(t, actions)
+
+	_, cmd := press(t, model, "c")
+	updated, _ := model.Update(cmd())
+	model = updated.(Model)
+
+	assert.Equal(t, "no clipboard", model.status)
+	assert.True(t, model.isError)
+}
+
+func TestModel_TestKey_ShowsResults(t *testing.T) {
+	actions := &fakeActions{keys: testKeys()}
+	model := newLoadedModel(t, actions)
+
+	model, cmd := press(t, model, "t")
+	require.NotNil(t, cmd)
+	assert.Contains(t, model.status, "Testing")
+
+	updated, _ := model.Update(cmd())
+	model = updated.(Model)
+
+	assert.Equal(t, []string{"id_ed25519_work"}, actions.tested)
+	assert.Contains(t, model.View(), "authenticated as octocat")
+}
+
+func TestModel_ActionDone_ReloadsKeys(t *testing.T) {
+	actions := &fakeActions{keys: testKeys()}
+	model := newLoadedModel(t, actions)
+	model, _ = press(t, model, "down")
+
+	actions.keys = actions.keys[:1]
+	updated, cmd := model.Update(actionDoneMsg{text: "SSH key [id_rsa] deleted"})
+	require.NotNil(t, cmd)
+	updated, _ = updated.(Model).Update(cmd())
+	model = updated.(Model)
+
+	assert.Len(t, model.keys, 1)
+	assert.Equal(t, 0, model.cursor, "cursor should move back onto a remaining key")
+}
+
+func TestModel_Quit(t *testing.T) {
+	model := newLoadedModel(t, &fakeActions{})
+
+	_, cmd := press(t, model, "q")
+
+	require.NotNil(t, cmd)
+	assert.Equal(t, tea.Quit(), cmd())
+}
@@ -5,6 +5,8 @@
 package mocks
 
 import (
+	"context"
+
 	mock "github.com/stretchr/testify/mock"
 )
 
@@ -101,6 +103,238 @@ func (_c *MockCommandExecutor_Execute_Call) RunAndReturn(run func(name string, a
 	return _c
 }
 
+// ExecuteWithCombinedOutput provides a mock function for the type MockCommandExecutor
+func (_mock *MockCommandExecutor) ExecuteWithCombinedOutput(name string, args ...string) ([]byte, error) {
+	var tmpRet mock.Arguments
+	if len(args) > 0 {
+		tmpRet = _mock.Called(name, args)
+	} else {
+		tmpRet = _mock.Called(name)
+	}
+	ret := tmpRet
+
+	if len(ret) == 0 {
+		panic("no return value specified for ExecuteWithCombinedOutput")
+	}
+
+	var r0 []byte
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(string, ...string) ([]byte, error)); ok {
+		return returnFunc(name, args...)
+	}
+	if returnFunc, ok := ret.Get(0).(func(string, ...string) []byte); ok {
+		r0 = returnFunc(name, args...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]byte)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(string, ...string) error); ok {
+		r1 = returnFunc(name, args...)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockCommandExecutor_ExecuteWithCombinedOutput_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ExecuteWithCombinedOutput'
+type MockCommandExecutor_ExecuteWithCombinedOutput_Call struct {
+	*mock.Call
+}
+
+// ExecuteWithCombinedOutput is a helper method to define mock.On call
+//   - name string
+//   - args ...string
+func (_e *MockCommandExecutor_Expecter) ExecuteWithCombinedOutput(name interface{}, args ...interface{}) *MockCommandExecutor_ExecuteWithCombinedOutput_Call {
+	return &MockCommandExecutor_ExecuteWithCombinedOutput_Call{Call: _e.mock.On("ExecuteWithCombinedOutput",
+		append([]interface{}{name}, args...)...)}
+}
+
+func (_c *MockCommandExecutor_ExecuteWithCombinedOutput_Call) Run(run func(name string, args ...string)) *MockCommandExecutor_ExecuteWithCombinedOutput_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 string
+		if args[0] != nil {
+			arg0 = args[0].(string)
+		}
+		var arg1 []string
+		var variadicArgs []string
+		if len(args) > 1 {
+			variadicArgs = args[1].([]string)
+		}
+		arg1 = variadicArgs
+		run(
+			arg0,
+			arg1...,
+		)
+	})
+	return _c
+}
+
+func (_c *MockCommandExecutor_ExecuteWithCombinedOutput_Call) Return(bytes []byte, err error) *MockCommandExecutor_ExecuteWithCombinedOutput_Call {
+	_c.Call.Return(bytes, err)
+	return _c
+}
+
+func (_c *MockCommandExecutor_ExecuteWithCombinedOutput_Call) RunAndReturn(run func(name string, args ...string) ([]byte, error)) *MockCommandExecutor_ExecuteWithCombinedOutput_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ExecuteWithCombinedOutputContext provides a mock function for the type MockCommandExecutor
+func (_mock *MockCommandExecutor) ExecuteWithCombinedOutputContext(ctx context.Context, name string, args ...string) ([]byte, error) {
+	var tmpRet mock.Arguments
+	if len(args) > 0 {
+		tmpRet = _mock.Called(ctx, name, args)
+	} else {
+		tmpRet = _mock.Called(ctx, name)
+	}
+	ret := tmpRet
+
+	if len(ret) == 0 {
+		panic("no return value specified for ExecuteWithCombinedOutputContext")
+	}
+
+	var r0 []byte
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, ...string) ([]byte, error)); ok {
+		return returnFunc(ctx, name, args...)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, ...string) []byte); ok {
+		r0 = returnFunc(ctx, name, args...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]byte)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, ...string) error); ok {
+		r1 = returnFunc(ctx, name, args...)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockCommandExecutor_ExecuteWithCombinedOutputContext_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ExecuteWithCombinedOutputContext'
+type MockCommandExecutor_ExecuteWithCombinedOutputContext_Call struct {
+	*mock.Call
+}
+
+// ExecuteWithCombinedOutputContext is a helper method to define mock.On call
+//   - ctx context.Context
+//   - name string
+//   - args ...string
+func (_e *MockCommandExecutor_Expecter) ExecuteWithCombinedOutputContext(ctx interface{}, name interface{}, args ...interface{}) *MockCommandExecutor_ExecuteWithCombinedOutputContext_Call {
+	return &MockCommandExecutor_ExecuteWithCombinedOutputContext_Call{Call: _e.mock.On("ExecuteWithCombinedOutputContext",
+		append([]interface{}{ctx, name}, args...)...)}
+}
+
+func (_c *MockCommandExecutor_ExecuteWithCombinedOutputContext_Call) Run(run func(ctx context.Context, name string, args ...string)) *MockCommandExecutor_ExecuteWithCombinedOutputContext_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 []string
+		var variadicArgs []string
+		if len(args) > 2 {
+			variadicArgs = args[2].([]string)
+		}
+		arg2 = variadicArgs
+		run(
+			arg0,
+			arg1,
+			arg2...,
+		)
+	})
+	return _c
+}
+
+func (_c *MockCommandExecutor_ExecuteWithCombinedOutputContext_Call) Return(bytes []byte, err error) *MockCommandExecutor_ExecuteWithCombinedOutputContext_Call {
+	_c.Call.Return(bytes, err)
+	return _c
+}
+
+func (_c *MockCommandExecutor_ExecuteWithCombinedOutputContext_Call) RunAndReturn(run func(ctx context.Context, name string, args ...string) ([]byte, error)) *MockCommandExecutor_ExecuteWithCombinedOutputContext_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ExecuteWithInput provides a mock function for the type MockCommandExecutor
+func (_mock *MockCommandExecutor) ExecuteWithInput(input []byte, name string, args ...string) error {
+	var tmpRet mock.Arguments
+	if len(args) > 0 {
+		tmpRet = _mock.Called(input, name, args)
+	} else {
+		tmpRet = _mock.Called(input, name)
+	}
+	ret := tmpRet
+
+	if len(ret) == 0 {
+		panic("no return value specified for ExecuteWithInput")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func([]byte, string, ...string) error); ok {
+		r0 = returnFunc(input, name, args...)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// MockCommandExecutor_ExecuteWithInput_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ExecuteWithInput'
+type MockCommandExecutor_ExecuteWithInput_Call struct {
+	*mock.Call
+}
+
+// ExecuteWithInput is a helper method to define mock.On call
+//   - input []byte
+//   - name string
+//   - args ...string
+func (_e *MockCommandExecutor_Expecter) ExecuteWithInput(input interface{}, name interface{}, args ...interface{}) *MockCommandExecutor_ExecuteWithInput_Call {
+	return &MockCommandExecutor_ExecuteWithInput_Call{Call: _e.mock.On("ExecuteWithInput",
+		append([]interface{}{input, name}, args...)...)}
+}
+
+func (_c *MockCommandExecutor_ExecuteWithInput_Call) Run(run func(input []byte, name string, args ...string)) *MockCommandExecutor_ExecuteWithInput_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 []byte
+		if args[0] != nil {
+			arg0 = args[0].([]byte)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 []string
+		var variadicArgs []string
+		if len(args) > 2 {
+			variadicArgs = args[2].([]string)
+		}
+		arg2 = variadicArgs
+		run(
+			arg0,
+			arg1,
+			arg2...,
+		)
+	})
+	return _c
+}
+
+func (_c *MockCommandExecutor_ExecuteWithInput_Call) Return(err error) *MockCommandExecutor_ExecuteWithInput_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *MockCommandExecutor_ExecuteWithInput_Call) RunAndReturn(run func(input []byte, name string, args ...string) error) *MockCommandExecutor_ExecuteWithInput_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // ExecuteWithOutput provides a mock function for the type MockCommandExecutor
 func (_mock *MockCommandExecutor) ExecuteWithOutput(name string, args ...string) ([]byte, error) {
 	var tmpRet mock.Arguments
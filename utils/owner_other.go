@@ -0,0 +1,10 @@
+//go:build !unix
+
+package utils
+
+import "os"
+
+// fileOwner reports the owner as unknown, as files have no UID outside Unix
+func fileOwner(info os.FileInfo) (int, bool) {
+	return 0, false
+}
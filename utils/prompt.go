@@ -0,0 +1,113 @@
+package utils
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// stdinReader is shared by all prompts so buffered input is not lost between them
+var stdinReader = bufio.NewReader(os.Stdin)
+
+func IsTerminal() bool {
+	return term.IsTerminal(int(os.Stdin.Fd()))
+}
+
+// ReadPassword prompts for a secret without echoing it. When stdin is not a
+// terminal, a single line is read instead so passphrases can be piped in.
+func ReadPassword(prompt string) (string, error) {
+	fmt.Fprint(os.Stderr, prompt)
+
+	if IsTerminal() {
+		password, err := term.ReadPassword(int(os.Stdin.Fd()))
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return "", fmt.Errorf("failed to read input: %w", err)
+		}
+		return string(password), nil
+	}
+
+	return readLine(stdinReader)
+}
+
+// ReadNewPassword prompts for a new secret twice and checks that both entries match
+func ReadNewPassword(prompt string) (string, error) {
+	password, err := ReadPassword(prompt)
+	if err != nil {
+		return "", err
+	}
+
+	if !IsTerminal() {
+		return password, nil
+	}
+
+	confirmation, err := ReadPassword("Confirm " + strings.ToLower(prompt[:1]) + prompt[1:])
+	if err != nil {
+		return "", err
+	}
+
+	if password != confirmation {
+		return "", fmt.Errorf("passphrases do not match")
+	}
+
+	return password, nil
+}
+
+func readLine(reader *bufio.Reader) (string, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil && (err != io.EOF || line == "") {
+		return "", fmt.Errorf("failed to read input: %w", err)
+	}
+
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// Prompt asks for a line of input and returns defaultValue when the answer is empty
+func Prompt(label, defaultValue string) (string, error) {
+	if defaultValue != "" {
+		fmt.Fprintf(os.Stderr, "%s [%s]: ", label, defaultValue)
+	} else {
+		fmt.Fprintf(os.Stderr, "%s: ", label)
+	}
+
+	answer, err := readLine(stdinReader)
+	if err != nil {
+		return "", err
+	}
+
+	if answer = strings.TrimSpace(answer); answer == "" {
+		return defaultValue, nil
+	}
+	return answer, nil
+}
+
+// Confirm asks a yes/no question and returns defaultYes when the answer is empty
+func Confirm(prompt string, defaultYes bool) (bool, error) {
+	options := "[y/N]"
+	if defaultYes {
+		options = "[Y/n]"
+	}
+	fmt.Fprintf(os.Stderr, "%s %s ", prompt, options)
+
+	answer, err := readLine(stdinReader)
+	if err != nil {
+		return false, err
+	}
+
+	return parseYesNo(answer, defaultYes), nil
+}
+
+func parseYesNo(answer string, defaultYes bool) bool {
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "":
+		return defaultYes
+	case "y", "yes":
+		return true
+	default:
+		return false
+	}
+}
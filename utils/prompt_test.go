@@ -0,0 +1,46 @@
+package utils
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseYesNo(t *testing.T) {
+	tests := []struct {
+		name       string
+		answer     string
+		defaultYes bool
+		expected   bool
+	}{
+		{"empty_default_yes", "", true, true},
+		{"empty_default_no", "  ", false, false},
+		{"yes", "y", false, true},
+		{"yes_uppercase", "YES", false, true},
+		{"no", "n", true, false},
+		{"other", "maybe", true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, parseYesNo(tt.answer, tt.defaultYes))
+		})
+	}
+}
+
+func TestReadLine(t *testing.T) {
+	reader := bufio.NewReader(strings.NewReader("first\r\nlast"))
+
+	line, err := readLine(reader)
+	assert.NoError(t, err)
+	assert.Equal(t, "first", line)
+
+	line, err = readLine(reader)
+	assert.NoError(t, err)
+	assert.Equal(t, "last", line)
+
+	_, err = readLine(reader)
+	assert.Error(t, err)
+}
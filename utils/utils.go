@@ -4,8 +4,11 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
 	"text/tabwriter"
+	"time"
 
 	"github.com/fatih/color"
 )
@@ -29,6 +32,37 @@ func IsDirectoryNotExist(dirPath string) bool {
 	return !info.IsDir()
 }
 
+// WriteFileAtomic writes data to a temporary file next to the target and renames it
+// into place, so readers never see a partially written file. A symlink is followed, so the
+// file it points at is replaced rather than the link itself.
+func WriteFileAtomic(filePath string, data []byte, perm os.FileMode) error {
+	if resolvedPath, err := filepath.EvalSymlinks(filePath); err == nil {
+		filePath = resolvedPath
+	}
+
+	tempFile, err := os.CreateTemp(filepath.Dir(filePath), "."+filepath.Base(filePath)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tempPath := tempFile.Name()
+	defer os.Remove(tempPath)
+
+	if _, err := tempFile.Write(data); err != nil {
+		tempFile.Close()
+		return err
+	}
+
+	if err := tempFile.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Chmod(tempPath, perm); err != nil {
+		return err
+	}
+
+	return os.Rename(tempPath, filePath)
+}
+
 func PrintSuccess(message string) {
 	color.Green("%s %s\n", SuccessSymbol, message)
 }
@@ -41,6 +75,12 @@ func PrintWarning(message string) {
 	color.Yellow("%s %s\n", WarningSymbol, message)
 }
 
+// MessagesToStderr sends success, warning and error messages to stderr, keeping stdout
+// for output that is parsed, such as JSON
+func MessagesToStderr() {
+	color.Output = color.Error
+}
+
 func PrintTable(w io.Writer, headers []string, rows [][]string) {
 	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
 
@@ -64,3 +104,113 @@ func ReplaceHomeDirWithTilde(path string) string {
 	}
 	return path
 }
+
+// ShellQuote quotes a string for POSIX shells
+func ShellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+func ExpandTilde(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+
+	return filepath.Join(home, path[1:])
+}
+
+// IsSamePath reports whether two paths, which may start with ~, name the same file
+func IsSamePath(a, b string) bool {
+	absA, errA := filepath.Abs(ExpandTilde(a))
+	absB, errB := filepath.Abs(ExpandTilde(b))
+	return errA == nil && errB == nil && absA == absB
+}
+
+// ConfigDir returns the sshman configuration directory, following the XDG base directory spec
+func ConfigDir() string {
+	if xdgConfigHome := os.Getenv("XDG_CONFIG_HOME"); xdgConfigHome != "" {
+		return filepath.Join(xdgConfigHome, "sshman")
+	}
+
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".config", "sshman")
+}
+
+// RuntimeDir returns the sshman directory for sockets, following the XDG base directory
+// spec, or a per-user directory in the temporary directory when $XDG_RUNTIME_DIR is not set.
+// Create it with EnsurePrivateDir, as the fallback name is predictable.
+func RuntimeDir() string {
+	if xdgRuntimeDir := os.Getenv("XDG_RUNTIME_DIR"); xdgRuntimeDir != "" {
+		return filepath.Join(xdgRuntimeDir, "sshman")
+	}
+
+	return filepath.Join(os.TempDir(), fmt.Sprintf("sshman-%d", os.Getuid()))
+}
+
+// EnsurePrivateDir creates dir with mode 0700 and refuses it unless it is a real directory
+// owned by the current user that no one else can access. Other users could otherwise
+// create a predictable directory such as /tmp/sshman-<uid> first.
+func EnsurePrivateDir(dir string) error {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	info, err := os.Lstat(dir)
+	if err != nil {
+		return fmt.Errorf("failed to check directory: %w", err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("%s is not a directory", dir)
+	}
+
+	// outside Unix, files have neither an owner UID nor permission bits to check
+	owner, known := fileOwner(info)
+	if !known {
+		return nil
+	}
+	if owner != os.Getuid() {
+		return fmt.Errorf("%s is owned by another user", dir)
+	}
+	if info.Mode().Perm() != 0700 {
+		return fmt.Errorf("%s must only be accessible by its owner, its mode is %04o", dir, info.Mode().Perm())
+	}
+
+	return nil
+}
+
+// ParseDuration extends time.ParseDuration with day (d) and week (w) units, e.g. "90d" or "2w"
+func ParseDuration(value string) (time.Duration, error) {
+	value = strings.TrimSpace(value)
+
+	for unit, size := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+		if number, found := strings.CutSuffix(value, unit); found {
+			count, err := strconv.Atoi(number)
+			if err != nil || count < 0 {
+				return 0, fmt.Errorf("invalid duration: %s", value)
+			}
+			return time.Duration(count) * size, nil
+		}
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration: %s", value)
+	}
+	return duration, nil
+}
+
+// FormatAge formats a duration in its largest whole unit, e.g. "12d", "5h" or "30m"
+func FormatAge(duration time.Duration) string {
+	switch {
+	case duration >= 24*time.Hour:
+		return fmt.Sprintf("%dd", int(duration.Hours()/24))
+	case duration >= time.Hour:
+		return fmt.Sprintf("%dh", int(duration.Hours()))
+	default:
+		return fmt.Sprintf("%dm", int(duration.Minutes()))
+	}
+}
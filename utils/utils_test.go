@@ -2,10 +2,12 @@ package utils
 
 import (
 	"bytes"
+	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -150,6 +152,193 @@ func TestReplaceHomeDirWithTilde(t *testing.T) {
 	}
 }
 
+func TestExpandTilde(t *testing.T) {
+	homeDir, err := os.UserHomeDir()
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		path     string
+		expected string
+	}{
+		{"tilde_only", "~", homeDir},
+		{"tilde_with_path", "~/.ssh/id_ed25519", filepath.Join(homeDir, ".ssh", "id_ed25519")},
+		{"absolute_path", "/etc/ssh/ssh_config", "/etc/ssh/ssh_config"},
+		{"relative_path", "./relative/path", "./relative/path"},
+		{"tilde_user", "~other/.ssh", "~other/.ssh"},
+		{"empty_path", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := ExpandTilde(tt.path)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+func TestIsSamePath(t *testing.T) {
+	homeDir, err := os.UserHomeDir()
+	require.NoError(t, err)
+
+	assert.True(t, IsSamePath("~/.ssh/id_ed25519", filepath.Join(homeDir, ".ssh", "id_ed25519")))
+	assert.True(t, IsSamePath("/etc/ssh/../ssh/ssh_config", "/etc/ssh/ssh_config"))
+	assert.False(t, IsSamePath("~/.ssh/id_ed25519", "~/.ssh/id_rsa"))
+}
+
+func TestConfigDir(t *testing.T) {
+	t.Run("xdg_config_home", func(t *testing.T) {
+		t.Setenv("XDG_CONFIG_HOME", "/tmp/xdg")
+		assert.Equal(t, filepath.Join("/tmp/xdg", "sshman"), ConfigDir())
+	})
+
+	t.Run("default", func(t *testing.T) {
+		t.Setenv("XDG_CONFIG_HOME", "")
+		homeDir, err := os.UserHomeDir()
+		require.NoError(t, err)
+		assert.Equal(t, filepath.Join(homeDir, ".config", "sshman"), ConfigDir())
+	})
+}
+
+func TestRuntimeDir(t *testing.T) {
+	t.Run("xdg_runtime_dir", func(t *testing.T) {
+		t.Setenv("XDG_RUNTIME_DIR", "/run/user/1000")
+		assert.Equal(t, filepath.Join("/run/user/1000", "sshman"), RuntimeDir())
+	})
+
+	t.Run("default", func(t *testing.T) {
+		t.Setenv("XDG_RUNTIME_DIR", "")
+		assert.Equal(t, filepath.Join(os.TempDir(), fmt.Sprintf("sshman-%d", os.Getuid())), RuntimeDir())
+	})
+}
+
+func TestEnsurePrivateDir(t *testing.T) {
+	t.Run("created", func(t *testing.T) {
+		dir := filepath.Join(t.TempDir(), "sshman")
+
+		require.NoError(t, EnsurePrivateDir(dir))
+
+		info, err := os.Stat(dir)
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0700), info.Mode().Perm())
+	})
+
+	t.Run("too open", func(t *testing.T) {
+		dir := filepath.Join(t.TempDir(), "sshman")
+		require.NoError(t, os.Mkdir(dir, 0700))
+		require.NoError(t, os.Chmod(dir, 0755))
+
+		assert.ErrorContains(t, EnsurePrivateDir(dir), "must only be accessible by its owner")
+	})
+
+	t.Run("symlink", func(t *testing.T) {
+		tempDir := t.TempDir()
+		target := filepath.Join(tempDir, "target")
+		require.NoError(t, os.Mkdir(target, 0700))
+		dir := filepath.Join(tempDir, "sshman")
+		require.NoError(t, os.Symlink(target, dir))
+
+		assert.ErrorContains(t, EnsurePrivateDir(dir), "is not a directory")
+	})
+}
+
+func TestWriteFileAtomic(t *testing.T) {
+	tempDir := t.TempDir()
+	filePath := filepath.Join(tempDir, "config")
+
+	require.NoError(t, os.WriteFile(filePath, []byte("old content"), 0644))
+
+	err := WriteFileAtomic(filePath, []byte("new content"), 0600)
+	require.NoError(t, err)
+
+	content, err := os.ReadFile(filePath)
+	require.NoError(t, err)
+	assert.Equal(t, "new content", string(content))
+
+	info, err := os.Stat(filePath)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	entries, err := os.ReadDir(tempDir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1, "temporary file should be cleaned up")
+}
+
+func TestWriteFileAtomic_Symlink_KeepsLink(t *testing.T) {
+	tempDir := t.TempDir()
+	targetPath := filepath.Join(tempDir, "dotfiles-config")
+	linkPath := filepath.Join(tempDir, "config")
+	require.NoError(t, os.WriteFile(targetPath, []byte("old content"), 0600))
+	require.NoError(t, os.Symlink(targetPath, linkPath))
+
+	require.NoError(t, WriteFileAtomic(linkPath, []byte("new content"), 0600))
+
+	info, err := os.Lstat(linkPath)
+	require.NoError(t, err)
+	assert.Equal(t, os.ModeSymlink, info.Mode().Type())
+	content, err := os.ReadFile(targetPath)
+	require.NoError(t, err)
+	assert.Equal(t, "new content", string(content))
+}
+
+func TestWriteFileAtomic_InvalidDirectory_Error(t *testing.T) {
+	err := WriteFileAtomic("/nonexistent/directory/file", []byte("content"), 0600)
+
+	assert.Error(t, err)
+}
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		name        string
+		value       string
+		expected    time.Duration
+		expectError bool
+	}{
+		{"days", "90d", 90 * 24 * time.Hour, false},
+		{"weeks", "2w", 14 * 24 * time.Hour, false},
+		{"hours", "8h", 8 * time.Hour, false},
+		{"minutes", "30m", 30 * time.Minute, false},
+		{"zero_days", "0d", 0, false},
+		{"negative_days", "-1d", 0, true},
+		{"invalid_days", "xd", 0, true},
+		{"invalid_unit", "10y", 0, true},
+		{"empty", "", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := ParseDuration(tt.value)
+			if tt.expectError {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+func TestFormatAge(t *testing.T) {
+	tests := []struct {
+		name     string
+		duration time.Duration
+		expected string
+	}{
+		{"days", 90*24*time.Hour + 5*time.Hour, "90d"},
+		{"one_day", 24 * time.Hour, "1d"},
+		{"hours", 5*time.Hour + 30*time.Minute, "5h"},
+		{"minutes", 42 * time.Minute, "42m"},
+		{"zero", 0, "0m"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, FormatAge(tt.duration))
+		})
+	}
+}
+
 func TestPrintTable(t *testing.T) {
 	var buf bytes.Buffer
 	headers := []string{"Name", "Type", "Status"}